@@ -0,0 +1,79 @@
+/*
+Copyright 2024 invioteq llc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Kinds a TestCase can reference its template by.
+const (
+	ObjectTemplateKind        = "ObjectTemplate"
+	ClusterObjectTemplateKind = "ClusterObjectTemplate"
+)
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:scope=Cluster
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp",description="Age"
+// +kubebuilder:printcolumn:name="Ready",type="string",JSONPath=".status.conditions[?(@.type=='Ready')].status",description="Ready"
+// +kubebuilder:printcolumn:name="Group",type=string,JSONPath=`.status.group`
+// +kubebuilder:printcolumn:name="Version",type=string,JSONPath=`.status.version`
+// +kubebuilder:printcolumn:name="Kind",type=string,JSONPath=`.status.kind`
+
+// ClusterObjectTemplate is the Schema for the clusterobjecttemplates API, a template shared by the
+// TestCases of all namespaces
+type ClusterObjectTemplate struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   ObjectTemplateSpec   `json:"spec,omitempty"`
+	Status ObjectTemplateStatus `json:"status,omitempty"`
+}
+
+func (in *ClusterObjectTemplate) GetConditions() []metav1.Condition {
+	return in.Status.Conditions
+}
+
+func (in *ClusterObjectTemplate) SetConditions(conditions []metav1.Condition) {
+	in.Status.Conditions = conditions
+}
+
+// AsObjectTemplate returns the ClusterObjectTemplate as an ObjectTemplate without namespace, which
+// runs are made with.
+func (in *ClusterObjectTemplate) AsObjectTemplate() *ObjectTemplate {
+	objTpl := &ObjectTemplate{
+		TypeMeta: metav1.TypeMeta{APIVersion: GroupVersion.String(), Kind: ObjectTemplateKind},
+	}
+	in.ObjectMeta.DeepCopyInto(&objTpl.ObjectMeta)
+	in.Spec.DeepCopyInto(&objTpl.Spec)
+	in.Status.DeepCopyInto(&objTpl.Status)
+	return objTpl
+}
+
+//+kubebuilder:object:root=true
+
+// ClusterObjectTemplateList contains a list of ClusterObjectTemplate
+type ClusterObjectTemplateList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ClusterObjectTemplate `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&ClusterObjectTemplate{}, &ClusterObjectTemplateList{})
+}
@@ -25,21 +25,370 @@ import (
 
 // ReportSpec defines the desired state of Report
 type ReportSpec struct {
-	// INSERT ADDITIONAL SPEC FIELDS - desired state of cluster
-	// Important: Run "make" to regenerate code after modifying this file
+	// TestCaseRef is the name of the TestCase the report was produced for
+	TestCaseRef string `json:"testCaseRef"`
+	// Run holds the metadata of the test run
+	Run RunInfo `json:"run"`
+	// Assertions lists the outcome of every check evaluated for the run
+	Assertions []Assertion `json:"assertions,omitempty"`
+	// Latencies holds the latency distributions measured during the run
+	Latencies []LatencySummary `json:"latencies,omitempty"`
+	// Metrics holds the series collected for the TestCase TargetMetrics
+	Metrics []MetricSeries `json:"metrics,omitempty"`
+	// Timeline holds the number of created and ready objects over the course of the run
+	Timeline []TimelinePoint `json:"timeline,omitempty"`
+	// Requests describes the requests the run sent to create objects and how they were throttled
+	Requests *RequestStats `json:"requests,omitempty"`
+	// Errors counts the instances that could not be created by the reason of their error
+	Errors []ErrorCount `json:"errors,omitempty"`
+	// ObjectAssertions holds the outcome of the assertions of the TestCase evaluated against every object
+	ObjectAssertions []ObjectAssertionResult `json:"objectAssertions,omitempty"`
+	// Writes breaks down the writes to the objects of the run observed by a watch, by object and field manager
+	Writes *WriteStats `json:"writes,omitempty"`
+	// Events aggregates the Kubernetes Events emitted for the objects of the run
+	Events *EventStats `json:"events,omitempty"`
+	// APIServer holds the change of the metrics of the API server over the run
+	APIServer *APIServerStats `json:"apiServer,omitempty"`
+	// Diagnostics describes the snapshots of objects captured before the objects of a failed or aborted run were torn down
+	Diagnostics *Diagnostics `json:"diagnostics,omitempty"`
+}
+
+// Diagnostics describes the snapshots of a sample of the objects of a failed or aborted run: their manifest, their
+// Events and their children.
+type Diagnostics struct {
+	// ConfigMapName is the name of the ConfigMap in the namespace of the Report holding the snapshots, one key per object
+	ConfigMapName string `json:"configMapName,omitempty"`
+	// Path is the directory a local run wrote the snapshots to, one file per object
+	Path string `json:"path,omitempty"`
+	// Objects lists the objects captured
+	Objects []ObjectDiagnostic `json:"objects,omitempty"`
+}
+
+// ObjectDiagnostic describes the snapshot of an object.
+type ObjectDiagnostic struct {
+	// Namespace is the namespace of the object
+	Namespace string `json:"namespace,omitempty"`
+	// Name is the name of the object
+	Name string `json:"name"`
+	// Ready reports whether the object was ready when it was captured
+	Ready bool `json:"ready"`
+	// Key is the key of the snapshot in the ConfigMap, or its file name in Path
+	Key string `json:"key"`
+	// Events is the number of Events of the object captured
+	Events int `json:"events"`
+	// Children is the number of children of the object captured
+	Children int `json:"children"`
+}
+
+// WriteStats counts the writes to the objects of a run after they were created, that is the changes
+// of their resourceVersion observed by a watch.
+type WriteStats struct {
+	// Objects is the number of objects watched
+	Objects int `json:"objects"`
+	// Total is the number of writes observed
+	Total int64 `json:"total"`
+	// PerObject is the mean number of writes per object, formatted as a decimal number
+	PerObject string `json:"perObject"`
+	// Max is the number of writes of the object written most
+	Max int64 `json:"max"`
+	// Managers counts the writes by the field manager they were attributed to from the managedFields of the objects
+	Managers []ManagerWrites `json:"managers,omitempty"`
+	// HotObjects lists the objects written most
+	HotObjects []ObjectWrites `json:"hotObjects,omitempty"`
+}
+
+// EventStats aggregates the Kubernetes Events emitted for the objects of a run, repeated occurrences of
+// an Event counted.
+type EventStats struct {
+	// Total is the number of Events
+	Total int64 `json:"total"`
+	// Warnings is the number of Warning Events
+	Warnings int64 `json:"warnings"`
+	// Objects is the number of objects with Events
+	Objects int `json:"objects"`
+	// Reasons lists the most frequent Events by type, reason and reporting controller, Warning Events first
+	Reasons []EventReason `json:"reasons,omitempty"`
+}
+
+// EventReason is the number of Events with the same type, reason and reporting controller.
+type EventReason struct {
+	// Type is the type of the Events, Normal or Warning
+	Type string `json:"type"`
+	// Reason is the reason of the Events
+	Reason string `json:"reason"`
+	// Controller is the controller reporting the Events
+	Controller string `json:"controller,omitempty"`
+	// Count is the number of Events
+	Count int64 `json:"count"`
+	// Objects is the number of objects the Events were emitted for
+	Objects int `json:"objects"`
+	// Message is the note of the latest Event
+	Message string `json:"message,omitempty"`
+}
+
+// APIServerStats is the change of the metrics of the API server between a scrape when the run started and
+// one when it was reported. The metrics are those of the API server instance that served each scrape:
+// behind a load balancer the scrapes may hit different replicas, the counters that went down are left
+// out and the others only cover the requests the scraped replicas served.
+type APIServerStats struct {
+	// Interval is the time between the scrapes
+	Interval metav1.Duration `json:"interval"`
+	// Requests is the number of requests the scraped API server replica served
+	Requests int64 `json:"requests"`
+	// RequestCounts lists the requests served by verb, resource, code and component, the most frequent first. The
+	// API server metrics do not tell clients apart, attributing requests to a client takes audit logs or the APF
+	// metrics of a flow schema matching it
+	RequestCounts []APIServerRequests `json:"requestCounts,omitempty"`
+	// Latencies summarizes the latency of the requests served by verb and resource, estimated from the buckets of the histogram
+	Latencies []APIServerLatency `json:"latencies,omitempty"`
+	// StorageLatencies summarizes the latency of the requests the API server sent to etcd, with the operation as
+	// Verb and the object type as Resource
+	StorageLatencies []APIServerLatency `json:"storageLatencies,omitempty"`
+	// StoredObjects is the number of objects of the resource of the run stored by the API server
+	StoredObjects *StoredObjects `json:"storedObjects,omitempty"`
+	// Rejected is the number of requests rejected by API Priority and Fairness
+	Rejected int64 `json:"rejected"`
+	// Rejections lists the requests rejected by API Priority and Fairness by priority level, flow schema and reason
+	Rejections []APFRejections `json:"rejections,omitempty"`
+}
+
+// APIServerRequests is the number of requests with the same verb, resource, code and component served by the API server.
+type APIServerRequests struct {
+	// Verb is the verb of the requests, e.g. LIST or PATCH
+	Verb string `json:"verb"`
+	// Resource is the resource of the requests, qualified by its group
+	Resource string `json:"resource,omitempty"`
+	// Subresource is the subresource of the requests, e.g. status
+	Subresource string `json:"subresource,omitempty"`
+	// Code is the HTTP status code of the responses
+	Code string `json:"code,omitempty"`
+	// Component is the component of the API server that served the requests, e.g. apiserver. It is not the
+	// client that sent them, the counts cover the requests of every client
+	Component string `json:"component,omitempty"`
+	// Count is the number of requests
+	Count int64 `json:"count"`
+}
+
+// APIServerLatency summarizes the latency of the requests with the same verb and resource served by the API server.
+type APIServerLatency struct {
+	// Verb is the verb of the requests
+	Verb string `json:"verb"`
+	// Resource is the resource of the requests, qualified by its group
+	Resource string `json:"resource,omitempty"`
+	// Count is the number of requests
+	Count int64 `json:"count"`
+	// Mean is the mean latency of the requests
+	Mean metav1.Duration `json:"mean"`
+	// P50 is the estimated 50th percentile latency
+	P50 metav1.Duration `json:"p50"`
+	// P90 is the estimated 90th percentile latency
+	P90 metav1.Duration `json:"p90"`
+	// P99 is the estimated 99th percentile latency
+	P99 metav1.Duration `json:"p99"`
+}
+
+// StoredObjects is the number of objects of a resource stored by the API server.
+type StoredObjects struct {
+	// Resource is the resource of the objects, qualified by its group
+	Resource string `json:"resource"`
+	// Before is the number of objects when the run started
+	Before int64 `json:"before"`
+	// After is the number of objects when the run was reported
+	After int64 `json:"after"`
+}
+
+// APFRejections is the number of requests rejected by API Priority and Fairness for the same priority level, flow schema and reason.
+type APFRejections struct {
+	// PriorityLevel is the priority level of the requests
+	PriorityLevel string `json:"priorityLevel"`
+	// FlowSchema is the flow schema the requests matched
+	FlowSchema string `json:"flowSchema"`
+	// Reason is why the requests were rejected, e.g. queue-full or timeout
+	Reason string `json:"reason"`
+	// Count is the number of requests rejected
+	Count int64 `json:"count"`
+}
+
+// ManagerWrites is the number of writes attributed to a field manager.
+type ManagerWrites struct {
+	// Manager is the name of the field manager, Unknown when a write could not be attributed
+	Manager string `json:"manager"`
+	// Operation is the operation of the writes, Update or Apply
+	Operation string `json:"operation,omitempty"`
+	// Subresource is the subresource written, e.g. status
+	Subresource string `json:"subresource,omitempty"`
+	// Writes is the number of writes attributed to the manager
+	Writes int64 `json:"writes"`
+}
+
+// ObjectWrites is the number of writes to an object.
+type ObjectWrites struct {
+	// Namespace is the namespace of the object
+	Namespace string `json:"namespace,omitempty"`
+	// Name is the name of the object
+	Name string `json:"name"`
+	// Writes is the number of writes observed
+	Writes int64 `json:"writes"`
+	// Managers counts the writes to the object by field manager
+	Managers []ManagerWrites `json:"managers,omitempty"`
+}
+
+// ObjectAssertionResult is the outcome of an assertion of a TestCase evaluated against the objects of a run.
+type ObjectAssertionResult struct {
+	// Name identifies the assertion
+	Name string `json:"name"`
+	// Passed is the number of objects the assertion held for
+	Passed int `json:"passed"`
+	// Failed is the number of objects the assertion did not hold for
+	Failed int `json:"failed"`
+	// Failures holds sample objects the assertion did not hold for
+	Failures []ObjectFailure `json:"failures,omitempty"`
+}
+
+// ObjectFailure is an object an assertion did not hold for.
+type ObjectFailure struct {
+	// Namespace is the namespace of the object
+	Namespace string `json:"namespace,omitempty"`
+	// Name is the name of the object
+	Name string `json:"name"`
+	// Message describes why the assertion did not hold
+	Message string `json:"message"`
+}
+
+// ErrorCount is the number of errors with a reason.
+type ErrorCount struct {
+	// Reason classifies the error, e.g. Forbidden, Invalid, AlreadyExists, TooManyRequests or WebhookDenied
+	Reason string `json:"reason"`
+	// Count is the number of errors with the reason
+	Count int `json:"count"`
+	// Message is the message of the last error with the reason
+	Message string `json:"message,omitempty"`
+}
+
+// RequestStats counts the requests sent by the client a run creates its objects with.
+type RequestStats struct {
+	// Sent is the number of requests sent to the API server, retries included
+	Sent int64 `json:"sent"`
+	// ClientThrottled is the number of requests delayed by the client-side rate limiter
+	ClientThrottled int64 `json:"clientThrottled"`
+	// ClientThrottledTime is the total time requests waited for the client-side rate limiter
+	ClientThrottledTime metav1.Duration `json:"clientThrottledTime"`
+	// ServerThrottled is the number of requests the API server rejected with 429 Too Many Requests
+	ServerThrottled int64 `json:"serverThrottled"`
+}
+
+// RunInfo describes a single execution of a TestCase.
+type RunInfo struct {
+	// Phase is the phase the TestCase ended the run in
+	Phase string `json:"phase,omitempty"`
+	// StartTime is the time object creation started
+	StartTime *metav1.Time `json:"startTime,omitempty"`
+	// CompletionTime is the time the run finished
+	CompletionTime *metav1.Time `json:"completionTime,omitempty"`
+	// ObjectTemplate is the name of the ObjectTemplate used for the run
+	ObjectTemplate string `json:"objectTemplate,omitempty"`
+	// Group is the API group of the created objects
+	Group string `json:"group,omitempty"`
+	// Version is the API version of the created objects
+	Version string `json:"version,omitempty"`
+	// Kind is the kind of the created objects
+	Kind string `json:"kind,omitempty"`
+	// Count is the number of instances requested by the TestCase
+	Count int `json:"count"`
+	// Concurrency is the concurrency requested by the TestCase
+	Concurrency int `json:"concurrency"`
+	// Created is the number of objects found in the cluster for the run
+	Created int `json:"created"`
+	// Ready is the number of created objects that reported readiness
+	Ready int `json:"ready"`
+	// Partial is set when the operator restarted during the run. What the run measured in memory until
+	// then, like the latencies of the requests, the writes to the objects and their Events, is missing
+	Partial bool `json:"partial,omitempty"`
+}
+
+// Assertion is the outcome of a single check evaluated for a run.
+type Assertion struct {
+	// Name identifies the check
+	Name string `json:"name"`
+	// Passed reports whether the check succeeded
+	Passed bool `json:"passed"`
+	// Message describes the outcome of the check
+	Message string `json:"message,omitempty"`
+}
+
+// LatencySummary holds the percentiles of a latency distribution.
+type LatencySummary struct {
+	// Name identifies the measured latency, e.g. timeToReady
+	Name string `json:"name"`
+	// Count is the number of samples in the distribution
+	Count int `json:"count"`
+	// Min is the smallest sample
+	Min metav1.Duration `json:"min"`
+	// Max is the largest sample
+	Max metav1.Duration `json:"max"`
+	// Mean is the arithmetic mean of the samples
+	Mean metav1.Duration `json:"mean"`
+	// P50 is the 50th percentile
+	P50 metav1.Duration `json:"p50"`
+	// P90 is the 90th percentile
+	P90 metav1.Duration `json:"p90"`
+	// P95 is the 95th percentile
+	P95 metav1.Duration `json:"p95"`
+	// P99 is the 99th percentile
+	P99 metav1.Duration `json:"p99"`
+	// Buckets is a histogram of the samples with equal width buckets between Min and Max
+	Buckets []LatencyBucket `json:"buckets,omitempty"`
+}
+
+// LatencyBucket is a single bucket of a latency histogram.
+type LatencyBucket struct {
+	// UpperBound is the inclusive upper bound of the bucket
+	UpperBound metav1.Duration `json:"le"`
+	// Count is the number of samples that fall into the bucket
+	Count int `json:"count"`
+}
+
+// TimelinePoint is the state of the created objects at a point in time of the run.
+type TimelinePoint struct {
+	// Offset is the time elapsed since the start of the run
+	Offset metav1.Duration `json:"offset"`
+	// Created is the number of objects created so far
+	Created int `json:"created"`
+	// Ready is the number of objects ready so far
+	Ready int `json:"ready"`
+}
+
+// MetricSeries is a time series collected for a MetricTarget.
+type MetricSeries struct {
+	// Name is the name of the MetricTarget
+	Name string `json:"name"`
+	// Expr is the expression of the MetricTarget
+	Expr string `json:"expr,omitempty"`
+	// Samples are the collected data points ordered by time
+	Samples []MetricSample `json:"samples,omitempty"`
+}
 
-	// Foo is an example field of Report. Edit report_types.go to remove/update
-	Foo string `json:"foo,omitempty"`
+// MetricSample is a single data point of a MetricSeries.
+type MetricSample struct {
+	// Time is the time the sample was taken
+	Time metav1.Time `json:"time"`
+	// Value is the sampled value, formatted as a decimal number
+	Value string `json:"value"`
 }
 
 // ReportStatus defines the observed state of Report
 type ReportStatus struct {
-	// INSERT ADDITIONAL STATUS FIELD - define observed state of cluster
-	// Important: Run "make" to regenerate code after modifying this file
+	// Exports lists the destinations the report was written to
+	Exports []string `json:"exports,omitempty"`
 }
 
 //+kubebuilder:object:root=true
 //+kubebuilder:subresource:status
+//+kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp",description="Age"
+//+kubebuilder:printcolumn:name="TestCase",type=string,JSONPath=`.spec.testCaseRef`
+//+kubebuilder:printcolumn:name="Phase",type=string,JSONPath=`.spec.run.phase`
+//+kubebuilder:printcolumn:name="Created",type=integer,JSONPath=`.spec.run.created`
+//+kubebuilder:printcolumn:name="Ready",type=integer,JSONPath=`.spec.run.ready`
 
 // Report is the Schema for the reports API
 type Report struct {
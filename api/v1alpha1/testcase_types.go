@@ -35,17 +35,306 @@ type TestCaseSpec struct {
 	// Concurrency specifies how many operations can be performed concurrently
 	Concurrency int `json:"concurrency"`
 	// DynamicFields specifies how to dynamically set fields in the ObjectTemplate based on the test case.
+	// Each of the Count instances takes one value of every field, see DynamicField.Values
 	DynamicFields []DynamicField `json:"dynamicFields,omitempty"`
-	// TargetMetrics defines the metrics that should be collected during the test
+	// TargetMetrics defines the metrics that should be collected during the test, they are queried from the
+	// Prometheus server the operator is configured with over the run window
 	TargetMetrics []MetricTarget `json:"targetMetrics,omitempty"`
+	// Reporting configures where the Report of a run is exported to
+	Reporting *ReportingSpec `json:"reporting,omitempty"`
+	// Suspend pauses the creation of objects while set, creation resumes where it stopped once it is cleared
+	Suspend bool `json:"suspend,omitempty"`
+	// Abort stops the run, a Report of the objects created so far is recorded and the run ends in the Aborted phase
+	Abort bool `json:"abort,omitempty"`
+	// TeardownOnAbort selects whether the objects created so far are deleted when the run is aborted
+	// +kubebuilder:default=true
+	TeardownOnAbort *bool `json:"teardownOnAbort,omitempty"`
+	// CleanupPolicy selects when the objects created by a finished run are deleted
+	// +kubebuilder:default=Always
+	CleanupPolicy CleanupPolicy `json:"cleanupPolicy,omitempty"`
+	// CleanupDelay is how long the objects are kept after the run finished with the AfterDelay policy
+	CleanupDelay *metav1.Duration `json:"cleanupDelay,omitempty"`
+	// NamespaceFanOut spreads the instances across namespaces generated for the run
+	NamespaceFanOut *NamespaceFanOut `json:"namespaceFanOut,omitempty"`
+	// ClientRateLimit limits the rate of the requests the run sends to create objects, runs without it use the limit the operator was started with
+	ClientRateLimit *ClientRateLimit `json:"clientRateLimit,omitempty"`
+	// Retry configures how requests failing with a retriable error (429, 5xx, conflicts and timeouts) are retried
+	Retry *RetryPolicy `json:"retry,omitempty"`
+	// ErrorBudget is the number of instances that may fail to be created before the run fails
+	// +kubebuilder:validation:Minimum=0
+	ErrorBudget int `json:"errorBudget,omitempty"`
+	// ApplyStrategy selects the requests the instances are written with
+	// +kubebuilder:default=Create
+	ApplyStrategy ApplyStrategy `json:"applyStrategy,omitempty"`
+	// Verification lists the child objects the target controller is expected to create for every instance
+	Verification *Verification `json:"verification,omitempty"`
+	// Assertions lists checks evaluated against every instance once the instances are ready, the run fails when one does not hold for every instance by the AssertionsTimeout
+	Assertions []ObjectAssertion `json:"assertions,omitempty"`
+	// AssertionsTimeout is how long the assertions may take to hold for every instance, they are evaluated again until then. It defaults to 5m
+	AssertionsTimeout *metav1.Duration `json:"assertionsTimeout,omitempty"`
+	// ExpectedFailure makes the TestCase a negative test, the run succeeds when every instance fails the way it describes instead of becoming ready
+	ExpectedFailure *ExpectedFailure `json:"expectedFailure,omitempty"`
+	// Updates modifies every instance once it is ready and measures how long the target controller takes to observe every new generation, the run fails when some instances do not converge
+	Updates *Updates `json:"updates,omitempty"`
+	// Drift disturbs every instance once it is ready and measures how long the target controller takes to correct it, the run fails when some instances do not converge
+	Drift *Drift `json:"drift,omitempty"`
+	// Events bounds the Warning Events emitted for the instances of the run, the run fails when they exceed it
+	Events *EventExpectation `json:"events,omitempty"`
+	// APIServerMetrics scrapes the metrics of the API server when the run starts and when it is reported, the report holds their change
+	APIServerMetrics bool `json:"apiServerMetrics,omitempty"`
 }
 
+// EventExpectation bounds the Kubernetes Events the instances of a run get, the Events are checked once
+// the instances are verified.
+type EventExpectation struct {
+	// MaxWarnings is the number of Warning Events the instances may get, zero expects none
+	// +kubebuilder:validation:Minimum=0
+	MaxWarnings *int64 `json:"maxWarnings,omitempty"`
+	// IgnoredReasons lists the reasons of the Warning Events not counted against MaxWarnings
+	IgnoredReasons []string `json:"ignoredReasons,omitempty"`
+}
+
+// Updates defines the update rounds applied to every instance of a run. An instance converged once the
+// observedGeneration in its status, or else in its conditions, caught up with the generation the update produced.
+type Updates struct {
+	// Path is the path of the field updated, in the dotted form of DynamicFields, e.g. spec.replicas
+	Path string `json:"path"`
+	// Values are the values the field is set to, an update round is applied for every value in turn
+	// +kubebuilder:validation:MinItems=1
+	Values []extv1.JSON `json:"values"`
+	// Timeout is how long the instances may take to converge after an update round
+	// +kubebuilder:default="5m"
+	Timeout *metav1.Duration `json:"timeout,omitempty"`
+}
+
+// Drift defines the disturbance applied to every instance of a run to measure how the target controller corrects drift.
+type Drift struct {
+	// Action selects what is disturbed
+	Action DriftAction `json:"action"`
+	// Child is the name of the ChildExpectation of the verification that selects the children of an instance, it is required with the DeleteChild and PatchChild actions
+	Child string `json:"child,omitempty"`
+	// Path is the path of the field set by the PatchChild and PatchInstance actions, in the dotted form of DynamicFields, e.g. spec.replicas
+	Path string `json:"path,omitempty"`
+	// Value is the value the field is set to
+	Value *extv1.JSON `json:"value,omitempty"`
+	// Timeout is how long an instance may take to converge
+	// +kubebuilder:default="5m"
+	Timeout *metav1.Duration `json:"timeout,omitempty"`
+}
+
+// DriftAction selects how the instances of a run are disturbed.
+// +kubebuilder:validation:Enum=DeleteChild;PatchChild;PatchInstance
+type DriftAction string
+
+const (
+	// DriftDeleteChild deletes a child of every instance, an instance converged once it has its expected children again.
+	DriftDeleteChild DriftAction = "DeleteChild"
+	// DriftPatchChild sets a field of a child of every instance, an instance converged once the field is back to its former value.
+	DriftPatchChild DriftAction = "PatchChild"
+	// DriftPatchInstance sets a field of every instance, an instance converged once the field is back to its former value.
+	DriftPatchInstance DriftAction = "PatchInstance"
+)
+
+// ExpectedFailure defines how the instances of a negative test are expected to fail. Exactly one of Admission and Condition is set.
+type ExpectedFailure struct {
+	// Admission expects the API server to reject the instances
+	Admission *AdmissionFailure `json:"admission,omitempty"`
+	// Condition expects the target controller to set a failure condition on the instances
+	Condition *ConditionFailure `json:"condition,omitempty"`
+	// Timeout is how long the instances may take to get the failure condition, the run fails when some do not have it by then
+	// +kubebuilder:default="5m"
+	Timeout *metav1.Duration `json:"timeout,omitempty"`
+}
+
+// AdmissionFailure defines the rejection of the instances by the API server, e.g. by a validating webhook or a CRD schema.
+type AdmissionFailure struct {
+	// Reason is the reason the rejection is classified with, e.g. WebhookDenied, Invalid or Forbidden, any reason matches when it is empty
+	Reason string `json:"reason,omitempty"`
+	// MessagePattern is a regular expression the message of the rejection must match
+	MessagePattern string `json:"messagePattern,omitempty"`
+}
+
+// ConditionFailure defines the condition the target controller sets on the instances it fails.
+type ConditionFailure struct {
+	// Type is the type of the condition
+	// +kubebuilder:default=Ready
+	Type string `json:"type,omitempty"`
+	// Status is the status of the condition
+	// +kubebuilder:validation:Enum=True;False;Unknown
+	// +kubebuilder:default=False
+	Status metav1.ConditionStatus `json:"status,omitempty"`
+	// Reason is the reason of the condition, e.g. InvalidSpec, any reason matches when it is empty
+	Reason string `json:"reason,omitempty"`
+	// MessagePattern is a regular expression the message of the condition must match
+	MessagePattern string `json:"messagePattern,omitempty"`
+}
+
+// ObjectAssertion is a check of the final state of the instances of a run, with either a JSONPath or a CEL expression.
+type ObjectAssertion struct {
+	// Name identifies the check in the Report
+	Name string `json:"name"`
+	// JSONPath selects a field of the instance, e.g. {.status.endpoint}. The check holds when the field is found and not empty, or has Value when it is set
+	JSONPath string `json:"jsonPath,omitempty"`
+	// Value is the value the field selected by JSONPath must have
+	Value *string `json:"value,omitempty"`
+	// CEL is an expression over the instance, bound to object, that must evaluate to true, e.g. object.status.observedGeneration == object.metadata.generation
+	CEL string `json:"cel,omitempty"`
+}
+
+// Verification defines the side effects of the target controller checked once the instances are ready.
+type Verification struct {
+	// Children lists the child objects expected for every instance
+	// +kubebuilder:validation:MinItems=1
+	Children []ChildExpectation `json:"children"`
+	// Timeout is how long the children may take to appear once the instances are ready, the run fails when some are still missing
+	// +kubebuilder:default="5m"
+	Timeout *metav1.Duration `json:"timeout,omitempty"`
+}
+
+// ChildExpectation defines the child objects expected for every instance of a run.
+type ChildExpectation struct {
+	// Name identifies the expectation in the Report, it defaults to the kind of the children
+	Name string `json:"name,omitempty"`
+	// APIVersion is the API version of the children
+	APIVersion string `json:"apiVersion"`
+	// Kind is the kind of the children
+	Kind string `json:"kind"`
+	// Match selects how the children of an instance are found
+	// +kubebuilder:default=OwnerReference
+	Match ChildMatch `json:"match,omitempty"`
+	// Selector holds labels the children of an instance carry, it is required with the LabelSelector match and narrows the owned objects with the OwnerReference one. Values are Go templates with the .Name, .Namespace, .UID and .Index of the instance
+	Selector map[string]string `json:"selector,omitempty"`
+	// Count is the number of children expected for every instance
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:default=1
+	Count int `json:"count,omitempty"`
+}
+
+// ChildMatch selects how child objects are matched to the instance they belong to.
+// +kubebuilder:validation:Enum=OwnerReference;LabelSelector
+type ChildMatch string
+
+const (
+	// ChildMatchOwnerReference matches the objects with an owner reference to the instance.
+	ChildMatchOwnerReference ChildMatch = "OwnerReference"
+	// ChildMatchLabelSelector matches the objects carrying the labels of the Selector.
+	ChildMatchLabelSelector ChildMatch = "LabelSelector"
+)
+
+// ApplyStrategy selects how the instances of a run are written to the API server.
+// +kubebuilder:validation:Enum=CreateOrUpdate;Create;ServerSideApply;MergePatch;JSONPatch
+type ApplyStrategy string
+
+const (
+	// ApplyCreateOrUpdate gets the object, then creates it or updates the existing one, two requests per instance.
+	ApplyCreateOrUpdate ApplyStrategy = "CreateOrUpdate"
+	// ApplyCreate only creates the object, an existing object fails the instance.
+	ApplyCreate ApplyStrategy = "Create"
+	// ApplyServerSideApply applies the object with server-side apply, as the tofan field manager.
+	ApplyServerSideApply ApplyStrategy = "ServerSideApply"
+	// ApplyMergePatch creates the object, and sends it as a JSON merge patch when it exists already.
+	ApplyMergePatch ApplyStrategy = "MergePatch"
+	// ApplyJSONPatch creates the object, and sends its fields as a JSON patch when it exists already.
+	ApplyJSONPatch ApplyStrategy = "JSONPatch"
+)
+
+// RetryPolicy configures the retries of the requests failing with a retriable error.
+type RetryPolicy struct {
+	// Attempts is the maximum number of attempts of a request, the first one included
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:default=5
+	Attempts int `json:"attempts,omitempty"`
+	// Backoff is the wait before the first retry, it doubles with every retry
+	// +kubebuilder:default="100ms"
+	Backoff *metav1.Duration `json:"backoff,omitempty"`
+	// MaxBackoff caps the wait between two attempts
+	// +kubebuilder:default="10s"
+	MaxBackoff *metav1.Duration `json:"maxBackoff,omitempty"`
+}
+
+// ClientRateLimit configures the client-side rate limiter of the client a run creates its objects with.
+type ClientRateLimit struct {
+	// QPS is the sustained number of requests per second, zero removes the limit
+	// +kubebuilder:validation:Minimum=0
+	QPS int32 `json:"qps"`
+	// Burst is the number of requests that may be sent at once above QPS, it defaults to QPS
+	// +kubebuilder:validation:Minimum=0
+	Burst int32 `json:"burst,omitempty"`
+}
+
+// NamespaceFanOut defines the namespaces generated for a run and how instances are spread across them.
+type NamespaceFanOut struct {
+	// Count is the number of namespaces generated
+	// +kubebuilder:validation:Minimum=1
+	Count int `json:"count"`
+	// Prefix is the prefix of the generated namespace names, it defaults to the TestCase name
+	Prefix string `json:"prefix,omitempty"`
+	// Distribution selects how instances are assigned to the namespaces
+	// +kubebuilder:default=RoundRobin
+	Distribution NamespaceDistribution `json:"distribution,omitempty"`
+	// Labels are set on the generated namespaces, values are Go templates with the .Index of the namespace, the .TestCase name and the .RunID
+	Labels map[string]string `json:"labels,omitempty"`
+}
+
+// NamespaceDistribution selects how instances are assigned to generated namespaces.
+// +kubebuilder:validation:Enum=RoundRobin;Random
+type NamespaceDistribution string
+
+const (
+	// NamespaceDistributionRoundRobin assigns the instances to the namespaces in turn.
+	NamespaceDistributionRoundRobin NamespaceDistribution = "RoundRobin"
+	// NamespaceDistributionRandom assigns every instance to a random namespace. The choice is stable
+	// for an instance of a run, so a resumed run keeps the assignment.
+	NamespaceDistributionRandom NamespaceDistribution = "Random"
+)
+
+// CleanupPolicy selects when the objects created by a run are deleted.
+// +kubebuilder:validation:Enum=Always;OnSuccess;OnFailure;Never;AfterDelay
+type CleanupPolicy string
+
+const (
+	// CleanupAlways deletes the objects as soon as the run finished.
+	CleanupAlways CleanupPolicy = "Always"
+	// CleanupOnSuccess deletes the objects when the run completed, they are kept for inspection when it failed.
+	CleanupOnSuccess CleanupPolicy = "OnSuccess"
+	// CleanupOnFailure deletes the objects when the run failed, they are kept when it completed.
+	CleanupOnFailure CleanupPolicy = "OnFailure"
+	// CleanupNever keeps the objects until the TestCase is deleted.
+	CleanupNever CleanupPolicy = "Never"
+	// CleanupAfterDelay deletes the objects once CleanupDelay elapsed after the run finished.
+	CleanupAfterDelay CleanupPolicy = "AfterDelay"
+)
+
+// ReportingSpec defines the export destinations of the Report produced by a run.
+type ReportingSpec struct {
+	// Formats lists the formats the Report is rendered in
+	Formats []ReportFormat `json:"formats,omitempty"`
+	// ConfigMapName is the name of a ConfigMap in the TestCase namespace the rendered reports are written to
+	ConfigMapName string `json:"configMapName,omitempty"`
+	// Path is a directory, relative to the report volume mounted into the operator, the rendered reports are written to
+	Path string `json:"path,omitempty"`
+}
+
+// ReportFormat is a format a Report can be rendered in. JSON and CSV hold every section of the Report.
+// JUnit has a test case per assertion and object assertion, and the totals of the other sections as
+// properties. HTML charts the assertions, latencies, timeline and metrics.
+// +kubebuilder:validation:Enum=junit;json;csv;html
+type ReportFormat string
+
+const (
+	ReportFormatJUnit ReportFormat = "junit"
+	ReportFormatJSON  ReportFormat = "json"
+	ReportFormatCSV   ReportFormat = "csv"
+	ReportFormatHTML  ReportFormat = "html"
+)
+
 // DynamicField defines a field to dynamically set based on TestCase parameters.
 type DynamicField struct {
 	// Path specifies the JSON path to the field within the ObjectTemplate that needs to be dynamically set.
 	Path string `json:"path"`
 
-	// Values are the values to apply to the dynamic field as simple strings.
+	// Values are the values to apply to the dynamic field, keyed by name. The instances take them in turn,
+	// in the order of the sorted keys: with keys a and b, instance 0 gets a, instance 1 gets b, instance 2 gets a.
 	Values map[string]extv1.JSON `json:"values"`
 }
 
@@ -53,9 +342,11 @@ type DynamicField struct {
 type objectTemplateReference struct {
 	// Name of the ObjectTemplate.
 	Name string `json:"name,omitempty"`
-	// Kind specifies the kind of the referenced resource, which should be "ObjectTemplate".
+	// Kind specifies the kind of the referenced resource, "ObjectTemplate" (the default) in the
+	// namespace of the TestCase or the cluster-scoped "ClusterObjectTemplate".
+	// +kubebuilder:validation:Enum=ObjectTemplate;ClusterObjectTemplate
 	Kind string `json:"kind,omitempty"`
-	// Group is the API group of the SpaceTemplate,  "tofan.io/v1alpha1".
+	// Group is the API group of the ObjectTemplate,  "tofan.io/v1alpha1".
 	Group string `json:"group,omitempty"`
 }
 
@@ -74,6 +365,26 @@ type TestCaseStatus struct {
 	Phase string `json:"phase,omitempty"`
 	// Conditions List of status conditions to indicate the status of Space
 	Conditions []metav1.Condition `json:"conditions,omitempty"`
+	// RunID is a random token generated for the run, it makes the names of the created objects unique
+	RunID string `json:"runID,omitempty"`
+	// Created is the number of instances processed so far, creation resumes from it
+	Created int `json:"created,omitempty"`
+	// Failed is the number of instances that could not be created
+	Failed int `json:"failed,omitempty"`
+	// Rejected is the number of instances the API server rejected the way the ExpectedFailure of the TestCase expects
+	Rejected int `json:"rejected,omitempty"`
+	// Retries is the number of requests retried after a retriable error
+	Retries int `json:"retries,omitempty"`
+	// Errors counts the instances that could not be created by the reason of their error
+	Errors []ErrorCount `json:"errors,omitempty"`
+	// StartTime is the time object creation started
+	StartTime *metav1.Time `json:"startTime,omitempty"`
+	// CompletionTime is the time the run finished
+	CompletionTime *metav1.Time `json:"completionTime,omitempty"`
+	// ReportRef is the name of the Report produced by the run
+	ReportRef string `json:"reportRef,omitempty"`
+	// CleanupTime is the time the objects created by the run were deleted
+	CleanupTime *metav1.Time `json:"cleanupTime,omitempty"`
 }
 
 //+kubebuilder:object:root=true
@@ -81,6 +392,9 @@ type TestCaseStatus struct {
 //+kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp",description="Age"
 //+kubebuilder:printcolumn:name="Ready",type="string",JSONPath=".status.conditions[?(@.type=='Ready')].status",description="Ready"
 // +kubebuilder:printcolumn:name="Phase",type=string,JSONPath=`.status.phase`
+// +kubebuilder:printcolumn:name="Created",type=integer,JSONPath=`.status.created`
+// +kubebuilder:printcolumn:name="Failed",type=integer,JSONPath=`.status.failed`,priority=1
+// +kubebuilder:printcolumn:name="Count",type=integer,JSONPath=`.spec.count`
 
 // TestCase is the Schema for the testcases API
 type TestCase struct {
@@ -27,24 +27,501 @@ import (
 	"k8s.io/apimachinery/pkg/runtime"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *APFRejections) DeepCopyInto(out *APFRejections) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new APFRejections.
+func (in *APFRejections) DeepCopy() *APFRejections {
+	if in == nil {
+		return nil
+	}
+	out := new(APFRejections)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *APIServerLatency) DeepCopyInto(out *APIServerLatency) {
+	*out = *in
+	out.Mean = in.Mean
+	out.P50 = in.P50
+	out.P90 = in.P90
+	out.P99 = in.P99
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new APIServerLatency.
+func (in *APIServerLatency) DeepCopy() *APIServerLatency {
+	if in == nil {
+		return nil
+	}
+	out := new(APIServerLatency)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *APIServerRequests) DeepCopyInto(out *APIServerRequests) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new APIServerRequests.
+func (in *APIServerRequests) DeepCopy() *APIServerRequests {
+	if in == nil {
+		return nil
+	}
+	out := new(APIServerRequests)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *APIServerStats) DeepCopyInto(out *APIServerStats) {
+	*out = *in
+	out.Interval = in.Interval
+	if in.RequestCounts != nil {
+		in, out := &in.RequestCounts, &out.RequestCounts
+		*out = make([]APIServerRequests, len(*in))
+		copy(*out, *in)
+	}
+	if in.Latencies != nil {
+		in, out := &in.Latencies, &out.Latencies
+		*out = make([]APIServerLatency, len(*in))
+		copy(*out, *in)
+	}
+	if in.StorageLatencies != nil {
+		in, out := &in.StorageLatencies, &out.StorageLatencies
+		*out = make([]APIServerLatency, len(*in))
+		copy(*out, *in)
+	}
+	if in.StoredObjects != nil {
+		in, out := &in.StoredObjects, &out.StoredObjects
+		*out = new(StoredObjects)
+		**out = **in
+	}
+	if in.Rejections != nil {
+		in, out := &in.Rejections, &out.Rejections
+		*out = make([]APFRejections, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new APIServerStats.
+func (in *APIServerStats) DeepCopy() *APIServerStats {
+	if in == nil {
+		return nil
+	}
+	out := new(APIServerStats)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AdmissionFailure) DeepCopyInto(out *AdmissionFailure) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AdmissionFailure.
+func (in *AdmissionFailure) DeepCopy() *AdmissionFailure {
+	if in == nil {
+		return nil
+	}
+	out := new(AdmissionFailure)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Assertion) DeepCopyInto(out *Assertion) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Assertion.
+func (in *Assertion) DeepCopy() *Assertion {
+	if in == nil {
+		return nil
+	}
+	out := new(Assertion)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ChildExpectation) DeepCopyInto(out *ChildExpectation) {
+	*out = *in
+	if in.Selector != nil {
+		in, out := &in.Selector, &out.Selector
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChildExpectation.
+func (in *ChildExpectation) DeepCopy() *ChildExpectation {
+	if in == nil {
+		return nil
+	}
+	out := new(ChildExpectation)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClientRateLimit) DeepCopyInto(out *ClientRateLimit) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClientRateLimit.
+func (in *ClientRateLimit) DeepCopy() *ClientRateLimit {
+	if in == nil {
+		return nil
+	}
+	out := new(ClientRateLimit)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterObjectTemplate) DeepCopyInto(out *ClusterObjectTemplate) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterObjectTemplate.
+func (in *ClusterObjectTemplate) DeepCopy() *ClusterObjectTemplate {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterObjectTemplate)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ClusterObjectTemplate) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterObjectTemplateList) DeepCopyInto(out *ClusterObjectTemplateList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]ClusterObjectTemplate, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterObjectTemplateList.
+func (in *ClusterObjectTemplateList) DeepCopy() *ClusterObjectTemplateList {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterObjectTemplateList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ClusterObjectTemplateList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ConditionFailure) DeepCopyInto(out *ConditionFailure) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ConditionFailure.
+func (in *ConditionFailure) DeepCopy() *ConditionFailure {
+	if in == nil {
+		return nil
+	}
+	out := new(ConditionFailure)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Diagnostics) DeepCopyInto(out *Diagnostics) {
+	*out = *in
+	if in.Objects != nil {
+		in, out := &in.Objects, &out.Objects
+		*out = make([]ObjectDiagnostic, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Diagnostics.
+func (in *Diagnostics) DeepCopy() *Diagnostics {
+	if in == nil {
+		return nil
+	}
+	out := new(Diagnostics)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Drift) DeepCopyInto(out *Drift) {
+	*out = *in
+	if in.Value != nil {
+		in, out := &in.Value, &out.Value
+		*out = new(apiextensionsv1.JSON)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Timeout != nil {
+		in, out := &in.Timeout, &out.Timeout
+		*out = new(v1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Drift.
+func (in *Drift) DeepCopy() *Drift {
+	if in == nil {
+		return nil
+	}
+	out := new(Drift)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *DynamicField) DeepCopyInto(out *DynamicField) {
 	*out = *in
-	if in.Values != nil {
-		in, out := &in.Values, &out.Values
-		*out = make(map[string]apiextensionsv1.JSON, len(*in))
-		for key, val := range *in {
-			(*out)[key] = *val.DeepCopy()
+	if in.Values != nil {
+		in, out := &in.Values, &out.Values
+		*out = make(map[string]apiextensionsv1.JSON, len(*in))
+		for key, val := range *in {
+			(*out)[key] = *val.DeepCopy()
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DynamicField.
+func (in *DynamicField) DeepCopy() *DynamicField {
+	if in == nil {
+		return nil
+	}
+	out := new(DynamicField)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ErrorCount) DeepCopyInto(out *ErrorCount) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ErrorCount.
+func (in *ErrorCount) DeepCopy() *ErrorCount {
+	if in == nil {
+		return nil
+	}
+	out := new(ErrorCount)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *EventExpectation) DeepCopyInto(out *EventExpectation) {
+	*out = *in
+	if in.MaxWarnings != nil {
+		in, out := &in.MaxWarnings, &out.MaxWarnings
+		*out = new(int64)
+		**out = **in
+	}
+	if in.IgnoredReasons != nil {
+		in, out := &in.IgnoredReasons, &out.IgnoredReasons
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EventExpectation.
+func (in *EventExpectation) DeepCopy() *EventExpectation {
+	if in == nil {
+		return nil
+	}
+	out := new(EventExpectation)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *EventReason) DeepCopyInto(out *EventReason) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EventReason.
+func (in *EventReason) DeepCopy() *EventReason {
+	if in == nil {
+		return nil
+	}
+	out := new(EventReason)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *EventStats) DeepCopyInto(out *EventStats) {
+	*out = *in
+	if in.Reasons != nil {
+		in, out := &in.Reasons, &out.Reasons
+		*out = make([]EventReason, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EventStats.
+func (in *EventStats) DeepCopy() *EventStats {
+	if in == nil {
+		return nil
+	}
+	out := new(EventStats)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ExpectedFailure) DeepCopyInto(out *ExpectedFailure) {
+	*out = *in
+	if in.Admission != nil {
+		in, out := &in.Admission, &out.Admission
+		*out = new(AdmissionFailure)
+		**out = **in
+	}
+	if in.Condition != nil {
+		in, out := &in.Condition, &out.Condition
+		*out = new(ConditionFailure)
+		**out = **in
+	}
+	if in.Timeout != nil {
+		in, out := &in.Timeout, &out.Timeout
+		*out = new(v1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ExpectedFailure.
+func (in *ExpectedFailure) DeepCopy() *ExpectedFailure {
+	if in == nil {
+		return nil
+	}
+	out := new(ExpectedFailure)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LatencyBucket) DeepCopyInto(out *LatencyBucket) {
+	*out = *in
+	out.UpperBound = in.UpperBound
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LatencyBucket.
+func (in *LatencyBucket) DeepCopy() *LatencyBucket {
+	if in == nil {
+		return nil
+	}
+	out := new(LatencyBucket)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LatencySummary) DeepCopyInto(out *LatencySummary) {
+	*out = *in
+	out.Min = in.Min
+	out.Max = in.Max
+	out.Mean = in.Mean
+	out.P50 = in.P50
+	out.P90 = in.P90
+	out.P95 = in.P95
+	out.P99 = in.P99
+	if in.Buckets != nil {
+		in, out := &in.Buckets, &out.Buckets
+		*out = make([]LatencyBucket, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LatencySummary.
+func (in *LatencySummary) DeepCopy() *LatencySummary {
+	if in == nil {
+		return nil
+	}
+	out := new(LatencySummary)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ManagerWrites) DeepCopyInto(out *ManagerWrites) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ManagerWrites.
+func (in *ManagerWrites) DeepCopy() *ManagerWrites {
+	if in == nil {
+		return nil
+	}
+	out := new(ManagerWrites)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MetricSample) DeepCopyInto(out *MetricSample) {
+	*out = *in
+	in.Time.DeepCopyInto(&out.Time)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MetricSample.
+func (in *MetricSample) DeepCopy() *MetricSample {
+	if in == nil {
+		return nil
+	}
+	out := new(MetricSample)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MetricSeries) DeepCopyInto(out *MetricSeries) {
+	*out = *in
+	if in.Samples != nil {
+		in, out := &in.Samples, &out.Samples
+		*out = make([]MetricSample, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DynamicField.
-func (in *DynamicField) DeepCopy() *DynamicField {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MetricSeries.
+func (in *MetricSeries) DeepCopy() *MetricSeries {
 	if in == nil {
 		return nil
 	}
-	out := new(DynamicField)
+	out := new(MetricSeries)
 	in.DeepCopyInto(out)
 	return out
 }
@@ -64,6 +541,98 @@ func (in *MetricTarget) DeepCopy() *MetricTarget {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NamespaceFanOut) DeepCopyInto(out *NamespaceFanOut) {
+	*out = *in
+	if in.Labels != nil {
+		in, out := &in.Labels, &out.Labels
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NamespaceFanOut.
+func (in *NamespaceFanOut) DeepCopy() *NamespaceFanOut {
+	if in == nil {
+		return nil
+	}
+	out := new(NamespaceFanOut)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ObjectAssertion) DeepCopyInto(out *ObjectAssertion) {
+	*out = *in
+	if in.Value != nil {
+		in, out := &in.Value, &out.Value
+		*out = new(string)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ObjectAssertion.
+func (in *ObjectAssertion) DeepCopy() *ObjectAssertion {
+	if in == nil {
+		return nil
+	}
+	out := new(ObjectAssertion)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ObjectAssertionResult) DeepCopyInto(out *ObjectAssertionResult) {
+	*out = *in
+	if in.Failures != nil {
+		in, out := &in.Failures, &out.Failures
+		*out = make([]ObjectFailure, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ObjectAssertionResult.
+func (in *ObjectAssertionResult) DeepCopy() *ObjectAssertionResult {
+	if in == nil {
+		return nil
+	}
+	out := new(ObjectAssertionResult)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ObjectDiagnostic) DeepCopyInto(out *ObjectDiagnostic) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ObjectDiagnostic.
+func (in *ObjectDiagnostic) DeepCopy() *ObjectDiagnostic {
+	if in == nil {
+		return nil
+	}
+	out := new(ObjectDiagnostic)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ObjectFailure) DeepCopyInto(out *ObjectFailure) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ObjectFailure.
+func (in *ObjectFailure) DeepCopy() *ObjectFailure {
+	if in == nil {
+		return nil
+	}
+	out := new(ObjectFailure)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ObjectTemplate) DeepCopyInto(out *ObjectTemplate) {
 	*out = *in
@@ -161,13 +730,33 @@ func (in *ObjectTemplateStatus) DeepCopy() *ObjectTemplateStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ObjectWrites) DeepCopyInto(out *ObjectWrites) {
+	*out = *in
+	if in.Managers != nil {
+		in, out := &in.Managers, &out.Managers
+		*out = make([]ManagerWrites, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ObjectWrites.
+func (in *ObjectWrites) DeepCopy() *ObjectWrites {
+	if in == nil {
+		return nil
+	}
+	out := new(ObjectWrites)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Report) DeepCopyInto(out *Report) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
-	out.Spec = in.Spec
-	out.Status = in.Status
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Report.
@@ -223,6 +812,68 @@ func (in *ReportList) DeepCopyObject() runtime.Object {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ReportSpec) DeepCopyInto(out *ReportSpec) {
 	*out = *in
+	in.Run.DeepCopyInto(&out.Run)
+	if in.Assertions != nil {
+		in, out := &in.Assertions, &out.Assertions
+		*out = make([]Assertion, len(*in))
+		copy(*out, *in)
+	}
+	if in.Latencies != nil {
+		in, out := &in.Latencies, &out.Latencies
+		*out = make([]LatencySummary, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Metrics != nil {
+		in, out := &in.Metrics, &out.Metrics
+		*out = make([]MetricSeries, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Timeline != nil {
+		in, out := &in.Timeline, &out.Timeline
+		*out = make([]TimelinePoint, len(*in))
+		copy(*out, *in)
+	}
+	if in.Requests != nil {
+		in, out := &in.Requests, &out.Requests
+		*out = new(RequestStats)
+		**out = **in
+	}
+	if in.Errors != nil {
+		in, out := &in.Errors, &out.Errors
+		*out = make([]ErrorCount, len(*in))
+		copy(*out, *in)
+	}
+	if in.ObjectAssertions != nil {
+		in, out := &in.ObjectAssertions, &out.ObjectAssertions
+		*out = make([]ObjectAssertionResult, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Writes != nil {
+		in, out := &in.Writes, &out.Writes
+		*out = new(WriteStats)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Events != nil {
+		in, out := &in.Events, &out.Events
+		*out = new(EventStats)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.APIServer != nil {
+		in, out := &in.APIServer, &out.APIServer
+		*out = new(APIServerStats)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Diagnostics != nil {
+		in, out := &in.Diagnostics, &out.Diagnostics
+		*out = new(Diagnostics)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ReportSpec.
@@ -238,6 +889,11 @@ func (in *ReportSpec) DeepCopy() *ReportSpec {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ReportStatus) DeepCopyInto(out *ReportStatus) {
 	*out = *in
+	if in.Exports != nil {
+		in, out := &in.Exports, &out.Exports
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ReportStatus.
@@ -250,6 +906,105 @@ func (in *ReportStatus) DeepCopy() *ReportStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ReportingSpec) DeepCopyInto(out *ReportingSpec) {
+	*out = *in
+	if in.Formats != nil {
+		in, out := &in.Formats, &out.Formats
+		*out = make([]ReportFormat, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ReportingSpec.
+func (in *ReportingSpec) DeepCopy() *ReportingSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ReportingSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RequestStats) DeepCopyInto(out *RequestStats) {
+	*out = *in
+	out.ClientThrottledTime = in.ClientThrottledTime
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RequestStats.
+func (in *RequestStats) DeepCopy() *RequestStats {
+	if in == nil {
+		return nil
+	}
+	out := new(RequestStats)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RetryPolicy) DeepCopyInto(out *RetryPolicy) {
+	*out = *in
+	if in.Backoff != nil {
+		in, out := &in.Backoff, &out.Backoff
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.MaxBackoff != nil {
+		in, out := &in.MaxBackoff, &out.MaxBackoff
+		*out = new(v1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RetryPolicy.
+func (in *RetryPolicy) DeepCopy() *RetryPolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(RetryPolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RunInfo) DeepCopyInto(out *RunInfo) {
+	*out = *in
+	if in.StartTime != nil {
+		in, out := &in.StartTime, &out.StartTime
+		*out = (*in).DeepCopy()
+	}
+	if in.CompletionTime != nil {
+		in, out := &in.CompletionTime, &out.CompletionTime
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RunInfo.
+func (in *RunInfo) DeepCopy() *RunInfo {
+	if in == nil {
+		return nil
+	}
+	out := new(RunInfo)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *StoredObjects) DeepCopyInto(out *StoredObjects) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new StoredObjects.
+func (in *StoredObjects) DeepCopy() *StoredObjects {
+	if in == nil {
+		return nil
+	}
+	out := new(StoredObjects)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *TestCase) DeepCopyInto(out *TestCase) {
 	*out = *in
@@ -325,6 +1080,73 @@ func (in *TestCaseSpec) DeepCopyInto(out *TestCaseSpec) {
 		*out = make([]MetricTarget, len(*in))
 		copy(*out, *in)
 	}
+	if in.Reporting != nil {
+		in, out := &in.Reporting, &out.Reporting
+		*out = new(ReportingSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.TeardownOnAbort != nil {
+		in, out := &in.TeardownOnAbort, &out.TeardownOnAbort
+		*out = new(bool)
+		**out = **in
+	}
+	if in.CleanupDelay != nil {
+		in, out := &in.CleanupDelay, &out.CleanupDelay
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.NamespaceFanOut != nil {
+		in, out := &in.NamespaceFanOut, &out.NamespaceFanOut
+		*out = new(NamespaceFanOut)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ClientRateLimit != nil {
+		in, out := &in.ClientRateLimit, &out.ClientRateLimit
+		*out = new(ClientRateLimit)
+		**out = **in
+	}
+	if in.Retry != nil {
+		in, out := &in.Retry, &out.Retry
+		*out = new(RetryPolicy)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Verification != nil {
+		in, out := &in.Verification, &out.Verification
+		*out = new(Verification)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Assertions != nil {
+		in, out := &in.Assertions, &out.Assertions
+		*out = make([]ObjectAssertion, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.AssertionsTimeout != nil {
+		in, out := &in.AssertionsTimeout, &out.AssertionsTimeout
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.ExpectedFailure != nil {
+		in, out := &in.ExpectedFailure, &out.ExpectedFailure
+		*out = new(ExpectedFailure)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Updates != nil {
+		in, out := &in.Updates, &out.Updates
+		*out = new(Updates)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Drift != nil {
+		in, out := &in.Drift, &out.Drift
+		*out = new(Drift)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Events != nil {
+		in, out := &in.Events, &out.Events
+		*out = new(EventExpectation)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TestCaseSpec.
@@ -347,6 +1169,23 @@ func (in *TestCaseStatus) DeepCopyInto(out *TestCaseStatus) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.Errors != nil {
+		in, out := &in.Errors, &out.Errors
+		*out = make([]ErrorCount, len(*in))
+		copy(*out, *in)
+	}
+	if in.StartTime != nil {
+		in, out := &in.StartTime, &out.StartTime
+		*out = (*in).DeepCopy()
+	}
+	if in.CompletionTime != nil {
+		in, out := &in.CompletionTime, &out.CompletionTime
+		*out = (*in).DeepCopy()
+	}
+	if in.CleanupTime != nil {
+		in, out := &in.CleanupTime, &out.CleanupTime
+		*out = (*in).DeepCopy()
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TestCaseStatus.
@@ -358,3 +1197,100 @@ func (in *TestCaseStatus) DeepCopy() *TestCaseStatus {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TimelinePoint) DeepCopyInto(out *TimelinePoint) {
+	*out = *in
+	out.Offset = in.Offset
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TimelinePoint.
+func (in *TimelinePoint) DeepCopy() *TimelinePoint {
+	if in == nil {
+		return nil
+	}
+	out := new(TimelinePoint)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Updates) DeepCopyInto(out *Updates) {
+	*out = *in
+	if in.Values != nil {
+		in, out := &in.Values, &out.Values
+		*out = make([]apiextensionsv1.JSON, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Timeout != nil {
+		in, out := &in.Timeout, &out.Timeout
+		*out = new(v1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Updates.
+func (in *Updates) DeepCopy() *Updates {
+	if in == nil {
+		return nil
+	}
+	out := new(Updates)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Verification) DeepCopyInto(out *Verification) {
+	*out = *in
+	if in.Children != nil {
+		in, out := &in.Children, &out.Children
+		*out = make([]ChildExpectation, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Timeout != nil {
+		in, out := &in.Timeout, &out.Timeout
+		*out = new(v1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Verification.
+func (in *Verification) DeepCopy() *Verification {
+	if in == nil {
+		return nil
+	}
+	out := new(Verification)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WriteStats) DeepCopyInto(out *WriteStats) {
+	*out = *in
+	if in.Managers != nil {
+		in, out := &in.Managers, &out.Managers
+		*out = make([]ManagerWrites, len(*in))
+		copy(*out, *in)
+	}
+	if in.HotObjects != nil {
+		in, out := &in.HotObjects, &out.HotObjects
+		*out = make([]ObjectWrites, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WriteStats.
+func (in *WriteStats) DeepCopy() *WriteStats {
+	if in == nil {
+		return nil
+	}
+	out := new(WriteStats)
+	in.DeepCopyInto(out)
+	return out
+}
@@ -17,11 +17,15 @@ limitations under the License.
 package main
 
 import (
+	"context"
 	"flag"
 	"github.com/invioteq/tofan/internal/common"
 	"github.com/invioteq/tofan/internal/objecttemplate"
+	"github.com/invioteq/tofan/internal/sweeper"
 	"github.com/invioteq/tofan/internal/testcase"
+	"github.com/invioteq/tofan/internal/tracing"
 	"os"
+	"time"
 
 	// Import all Kubernetes client auth plugins (e.g. Azure, GCP, OIDC, etc.)
 	// to ensure that exec-entrypoint and run can make use of them.
@@ -29,6 +33,7 @@ import (
 
 	"k8s.io/apimachinery/pkg/runtime"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/client-go/dynamic"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/healthz"
@@ -54,11 +59,32 @@ func main() {
 	var metricsAddr string
 	var enableLeaderElection bool
 	var probeAddr string
+	var reportDir string
+	var prometheusURL string
+	var orphanSweepInterval time.Duration
+	var otlpEndpoint string
+	var loadQPS float64
+	var loadBurst int
 	flag.StringVar(&metricsAddr, "metrics-bind-address", ":8080", "The address the metric endpoint binds to.")
 	flag.StringVar(&probeAddr, "health-probe-bind-address", ":8081", "The address the probe endpoint binds to.")
 	flag.BoolVar(&enableLeaderElection, "leader-elect", false,
 		"Enable leader election for controller manager. "+
 			"Enabling this will ensure there is only one active controller manager.")
+	flag.StringVar(&reportDir, "report-dir", "",
+		"The directory a volume for TestCase reports is mounted at. "+
+			"TestCase report paths are resolved relative to it.")
+	flag.StringVar(&prometheusURL, "prometheus-url", "",
+		"The address of the Prometheus server the TestCase targetMetrics are queried from, e.g. http://prometheus:9090. "+
+			"Reports list the metrics without samples when empty.")
+	flag.DurationVar(&orphanSweepInterval, "orphan-sweep-interval", 10*time.Minute,
+		"How often objects left behind by deleted TestCases are garbage collected. Zero disables the sweeper.")
+	flag.StringVar(&otlpEndpoint, "otlp-endpoint", os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT"),
+		"The OTLP/HTTP endpoint TestCase runs are exported to as traces, e.g. http://otel-collector:4318. "+
+			"Tracing is disabled when empty.")
+	flag.Float64Var(&loadQPS, "load-qps", 100,
+		"The client-side rate limit, in requests per second, of the runs without a clientRateLimit. Zero removes the limit.")
+	flag.IntVar(&loadBurst, "load-burst", 200,
+		"The burst of the client-side rate limiter of the runs without a clientRateLimit.")
 	opts := zap.Options{
 		Development: true,
 	}
@@ -67,6 +93,12 @@ func main() {
 
 	ctrl.SetLogger(zap.New(zap.UseFlagOptions(&opts)))
 
+	shutdownTracing, err := tracing.Setup(context.Background(), otlpEndpoint, "tofan")
+	if err != nil {
+		setupLog.Error(err, "unable to set up tracing")
+		os.Exit(1)
+	}
+
 	mgr, err := ctrl.NewManager(ctrl.GetConfigOrDie(), ctrl.Options{
 		Scheme:                 scheme,
 		MetricsBindAddress:     metricsAddr,
@@ -91,6 +123,12 @@ func main() {
 		os.Exit(1)
 	}
 
+	dynamicClient, err := dynamic.NewForConfig(mgr.GetConfig())
+	if err != nil {
+		setupLog.Error(err, "unable to create dynamic client")
+		os.Exit(1)
+	}
+
 	if err = (&objecttemplate.Reconciler{
 		Reconciler: common.Reconciler{
 			Client:   mgr.GetClient(),
@@ -103,6 +141,20 @@ func main() {
 		os.Exit(1)
 	}
 
+	if err = (&objecttemplate.ClusterReconciler{
+		Reconciler: objecttemplate.Reconciler{
+			Reconciler: common.Reconciler{
+				Client:   mgr.GetClient(),
+				Log:      ctrl.Log.WithName("ClusterObjectTemplate"),
+				Scheme:   mgr.GetScheme(),
+				Recorder: mgr.GetEventRecorderFor("cluster-object-template"),
+			},
+		},
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "ClusterObjectTemplate")
+		os.Exit(1)
+	}
+
 	if err = (&testcase.Reconciler{
 		Reconciler: common.Reconciler{
 			Client:   mgr.GetClient(),
@@ -110,6 +162,12 @@ func main() {
 			Scheme:   mgr.GetScheme(),
 			Recorder: mgr.GetEventRecorderFor("test-case"),
 		},
+		ReportDir:     reportDir,
+		PrometheusURL: prometheusURL,
+		Dynamic:       dynamicClient,
+		Config:        mgr.GetConfig(),
+		LoadQPS:       float32(loadQPS),
+		LoadBurst:     loadBurst,
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "TestCase")
 		os.Exit(1)
@@ -117,6 +175,20 @@ func main() {
 
 	//+kubebuilder:scaffold:builder
 
+	if orphanSweepInterval > 0 {
+		if err := mgr.Add(&sweeper.Sweeper{
+			Reader:   mgr.GetAPIReader(),
+			Dynamic:  dynamicClient,
+			Mapper:   mgr.GetRESTMapper(),
+			Recorder: mgr.GetEventRecorderFor("orphan-sweeper"),
+			Log:      ctrl.Log.WithName("OrphanSweeper"),
+			Interval: orphanSweepInterval,
+		}); err != nil {
+			setupLog.Error(err, "unable to set up orphan sweeper")
+			os.Exit(1)
+		}
+	}
+
 	if err := mgr.AddHealthzCheck("healthz", healthz.Ping); err != nil {
 		setupLog.Error(err, "unable to set up health check")
 		os.Exit(1)
@@ -131,4 +203,10 @@ func main() {
 		setupLog.Error(err, "problem running manager")
 		os.Exit(1)
 	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := shutdownTracing(ctx); err != nil {
+		setupLog.Error(err, "problem flushing traces")
+	}
 }
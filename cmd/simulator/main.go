@@ -0,0 +1,161 @@
+/*
+Copyright 2024 invioteq llc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Command simulator runs the simulated controller, which makes the objects of a type ready after a
+// simulated latency so tofan can be tried out on a cluster without the controller under test.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/invioteq/tofan/internal/simulator"
+
+	// Import all Kubernetes client auth plugins (e.g. Azure, GCP, OIDC, etc.)
+	// to ensure that exec-entrypoint and run can make use of them.
+	_ "k8s.io/client-go/plugin/pkg/client/auth"
+
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/healthz"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+)
+
+var (
+	scheme   = runtime.NewScheme()
+	setupLog = ctrl.Log.WithName("setup")
+)
+
+func init() {
+	utilruntime.Must(clientgoscheme.AddToScheme(scheme))
+	utilruntime.Must(apiextensionsv1.AddToScheme(scheme))
+}
+
+func main() {
+	var metricsAddr string
+	var probeAddr string
+	var kind string
+	var installCRD bool
+	var distribution string
+	var latency simulator.Latency
+	var errorRate float64
+	var maxThroughput float64
+	var workers int
+	flag.StringVar(&metricsAddr, "metrics-bind-address", ":8082", "The address the metric endpoint binds to.")
+	flag.StringVar(&probeAddr, "health-probe-bind-address", ":8083", "The address the probe endpoint binds to.")
+	flag.StringVar(&kind, "kind", "Widget.v1alpha1.simulator.tofan.io",
+		"The type of the objects reconciled, as Kind.version.group.")
+	flag.BoolVar(&installCRD, "install-crd", false,
+		"Create a CRD serving the type of the objects reconciled unless it exists, e.g. on a bare envtest API server.")
+	flag.StringVar(&distribution, "latency-distribution", string(simulator.LatencyNormal),
+		"The distribution the latency of the objects is drawn from: constant, uniform, normal or exponential.")
+	flag.DurationVar(&latency.Mean, "latency-mean", 2*time.Second, "The mean time an object takes to become ready.")
+	flag.DurationVar(&latency.StdDev, "latency-stddev", 500*time.Millisecond,
+		"The standard deviation of a normal latency, and the half width of a uniform one.")
+	flag.Float64Var(&errorRate, "error-rate", 0, "The fraction of objects, between 0 and 1, set a False Ready condition.")
+	flag.Float64Var(&maxThroughput, "max-throughput", 0,
+		"The most objects made ready or failed per second. Zero removes the cap.")
+	flag.IntVar(&workers, "workers", 10, "The number of objects reconciled concurrently.")
+	opts := zap.Options{
+		Development: true,
+	}
+	opts.BindFlags(flag.CommandLine)
+	flag.Parse()
+
+	ctrl.SetLogger(zap.New(zap.UseFlagOptions(&opts)))
+
+	gvk, err := parseKind(kind)
+	if err != nil {
+		setupLog.Error(err, "invalid --kind")
+		os.Exit(1)
+	}
+	if latency.Distribution, err = simulator.ParseLatencyDistribution(distribution); err != nil {
+		setupLog.Error(err, "invalid --latency-distribution")
+		os.Exit(1)
+	}
+	if errorRate < 0 || errorRate > 1 {
+		setupLog.Error(fmt.Errorf("%v is not between 0 and 1", errorRate), "invalid --error-rate")
+		os.Exit(1)
+	}
+
+	cfg := ctrl.GetConfigOrDie()
+	if installCRD {
+		c, err := client.New(cfg, client.Options{Scheme: scheme})
+		if err != nil {
+			setupLog.Error(err, "unable to create client")
+			os.Exit(1)
+		}
+		if err := simulator.InstallCRD(context.Background(), c, simulator.CRD(gvk)); err != nil {
+			setupLog.Error(err, "unable to install CRD")
+			os.Exit(1)
+		}
+	}
+
+	mgr, err := ctrl.NewManager(cfg, ctrl.Options{
+		Scheme:                 scheme,
+		MetricsBindAddress:     metricsAddr,
+		HealthProbeBindAddress: probeAddr,
+	})
+	if err != nil {
+		setupLog.Error(err, "unable to start manager")
+		os.Exit(1)
+	}
+
+	if err = (&simulator.Reconciler{
+		Client:        mgr.GetClient(),
+		Log:           ctrl.Log.WithName("Simulator"),
+		GVK:           gvk,
+		Latency:       latency,
+		ErrorRate:     errorRate,
+		MaxThroughput: maxThroughput,
+		Workers:       workers,
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "Simulator")
+		os.Exit(1)
+	}
+
+	if err := mgr.AddHealthzCheck("healthz", healthz.Ping); err != nil {
+		setupLog.Error(err, "unable to set up health check")
+		os.Exit(1)
+	}
+	if err := mgr.AddReadyzCheck("readyz", healthz.Ping); err != nil {
+		setupLog.Error(err, "unable to set up ready check")
+		os.Exit(1)
+	}
+
+	setupLog.Info("starting simulator", "GVK", gvk, "Latency", latency, "ErrorRate", errorRate, "MaxThroughput", maxThroughput)
+	if err := mgr.Start(ctrl.SetupSignalHandler()); err != nil {
+		setupLog.Error(err, "problem running manager")
+		os.Exit(1)
+	}
+}
+
+// parseKind parses a type given as Kind.version.group.
+func parseKind(kind string) (schema.GroupVersionKind, error) {
+	gvk, _ := schema.ParseKindArg(kind)
+	if gvk == nil || gvk.Kind == "" {
+		return schema.GroupVersionKind{}, fmt.Errorf("%q is not of the form Kind.version.group", kind)
+	}
+	return *gvk, nil
+}
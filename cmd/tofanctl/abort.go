@@ -0,0 +1,140 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"time"
+
+	tofaniov1alpha1 "github.com/invioteq/tofan/api/v1alpha1"
+	"github.com/invioteq/tofan/internal/testcase"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// runAbort stops a TestCase. The operator records a Report of the objects created so far and tears
+// them down unless --keep is set. With --delete the TestCase is deleted instead.
+func runAbort(args []string) error {
+	fs := flag.NewFlagSet("abort", flag.ExitOnError)
+	namespace := namespaceFlag(fs)
+	keep := fs.Bool("keep", false, "Keep the objects created so far.")
+	del := fs.Bool("delete", false, "Delete the TestCase, the operator tears down its objects before it goes away.")
+	wait := fs.Bool("wait", false, "Wait until the TestCase is aborted, or gone with --delete.")
+	names := parseArgs(fs, args)
+	if len(names) == 0 {
+		return fmt.Errorf("expected the name of a TestCase")
+	}
+
+	ctx := context.Background()
+	c, err := newClient()
+	if err != nil {
+		return err
+	}
+
+	for _, name := range names {
+		tc := &tofaniov1alpha1.TestCase{}
+		if err := c.Get(ctx, client.ObjectKey{Namespace: *namespace, Name: name}, tc); err != nil {
+			return err
+		}
+
+		if *del {
+			if err := c.Delete(ctx, tc); err != nil {
+				return err
+			}
+			fmt.Printf("TestCase/%s deleted\n", name)
+			if *wait {
+				if err := waitForDeletion(ctx, c, tc); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+
+		if testcase.IsFinished(tc.Status.Phase) {
+			fmt.Printf("TestCase/%s already finished (%s)\n", name, tc.Status.Phase)
+			continue
+		}
+		patch := client.MergeFrom(tc.DeepCopy())
+		tc.Spec.Abort = true
+		if *keep {
+			teardown := false
+			tc.Spec.TeardownOnAbort = &teardown
+		}
+		if err := c.Patch(ctx, tc, patch); err != nil {
+			return err
+		}
+		fmt.Printf("TestCase/%s aborted\n", name)
+		if *wait {
+			if err := waitForPhase(ctx, c, client.ObjectKeyFromObject(tc), testcase.StatusAborted); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// runSuspend pauses the creation of objects of running TestCases.
+func runSuspend(args []string) error {
+	return setSuspend("suspend", args, true)
+}
+
+// runResume resumes the creation of objects of suspended TestCases where it stopped.
+func runResume(args []string) error {
+	return setSuspend("resume", args, false)
+}
+
+func setSuspend(name string, args []string, suspend bool) error {
+	fs := flag.NewFlagSet(name, flag.ExitOnError)
+	namespace := namespaceFlag(fs)
+	names := parseArgs(fs, args)
+	if len(names) == 0 {
+		return fmt.Errorf("expected the name of a TestCase")
+	}
+
+	ctx := context.Background()
+	c, err := newClient()
+	if err != nil {
+		return err
+	}
+
+	for _, name := range names {
+		tc := &tofaniov1alpha1.TestCase{}
+		if err := c.Get(ctx, client.ObjectKey{Namespace: *namespace, Name: name}, tc); err != nil {
+			return err
+		}
+		if testcase.IsFinished(tc.Status.Phase) {
+			return fmt.Errorf("TestCase %s already finished (%s)", name, tc.Status.Phase)
+		}
+
+		patch := client.MergeFrom(tc.DeepCopy())
+		tc.Spec.Suspend = suspend
+		if err := c.Patch(ctx, tc, patch); err != nil {
+			return err
+		}
+		if suspend {
+			fmt.Printf("TestCase/%s suspended\n", name)
+		} else {
+			fmt.Printf("TestCase/%s resumed\n", name)
+		}
+	}
+	return nil
+}
+
+// waitForPhase polls a TestCase until it reaches the given phase.
+func waitForPhase(ctx context.Context, c client.Client, key client.ObjectKey, phase string) error {
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+	for {
+		tc := &tofaniov1alpha1.TestCase{}
+		if err := c.Get(ctx, key, tc); err != nil {
+			return err
+		}
+		if tc.Status.Phase == phase {
+			return nil
+		}
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
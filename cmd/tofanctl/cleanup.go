@@ -0,0 +1,169 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"strings"
+
+	tofaniov1alpha1 "github.com/invioteq/tofan/api/v1alpha1"
+	"github.com/invioteq/tofan/internal/testcase"
+	"github.com/invioteq/tofan/pkg/constants"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/dynamic"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/config"
+)
+
+// runCleanup deletes objects labelled with tofan.io/testcase-name across every listable resource
+// type. Objects of TestCases that are still in progress are kept unless --testcase selects them.
+func runCleanup(args []string) error {
+	fs := flag.NewFlagSet("cleanup", flag.ExitOnError)
+	namespace := namespaceFlag(fs)
+	allNamespaces := fs.Bool("all-namespaces", false, "Clean up objects in every namespace.")
+	fs.BoolVar(allNamespaces, "A", false, "Shorthand for --all-namespaces.")
+	testCaseName := fs.String("testcase", "", "Only delete the objects created for this TestCase.")
+	dryRun := fs.Bool("dry-run", false, "Only print the objects that would be deleted.")
+	parseArgs(fs, args)
+
+	ctx := context.Background()
+	cfg, err := config.GetConfig()
+	if err != nil {
+		return err
+	}
+	c, err := newClient()
+	if err != nil {
+		return err
+	}
+	dynamicClient, err := dynamic.NewForConfig(cfg)
+	if err != nil {
+		return err
+	}
+	discoveryClient, err := discovery.NewDiscoveryClientForConfig(cfg)
+	if err != nil {
+		return err
+	}
+
+	resources, err := deletableResources(discoveryClient)
+	if err != nil {
+		return err
+	}
+
+	selector := constants.TofanTestCaseNameLabel
+	if *testCaseName != "" {
+		selector = fmt.Sprintf("%s=%s", constants.TofanTestCaseNameLabel, *testCaseName)
+	}
+	listNamespace := *namespace
+	if *allNamespaces {
+		listNamespace = metav1.NamespaceAll
+	}
+
+	inProgress := map[client.ObjectKey]bool{}
+	if *testCaseName == "" {
+		testCases := &tofaniov1alpha1.TestCaseList{}
+		// Objects may live outside the namespace of their TestCase, look at the TestCases of every namespace
+		if err := c.List(ctx, testCases); err != nil {
+			return err
+		}
+		for _, tc := range testCases.Items {
+			if tc.Status.Phase != "" && !testcase.IsFinished(tc.Status.Phase) {
+				inProgress[client.ObjectKeyFromObject(&tc)] = true
+			}
+		}
+	}
+
+	deleted := 0
+	for _, resource := range resources {
+		var ri dynamic.ResourceInterface = dynamicClient.Resource(resource.gvr)
+		if resource.namespaced {
+			ri = dynamicClient.Resource(resource.gvr).Namespace(listNamespace)
+		}
+		list, err := ri.List(ctx, metav1.ListOptions{LabelSelector: selector})
+		if err != nil {
+			if apierrors.IsForbidden(err) || apierrors.IsNotFound(err) || apierrors.IsMethodNotSupported(err) {
+				continue
+			}
+			return fmt.Errorf("failed to list %s: %w", resource.gvr, err)
+		}
+
+		for _, obj := range list.Items {
+			owner := client.ObjectKey{Namespace: obj.GetNamespace(), Name: obj.GetLabels()[constants.TofanTestCaseNameLabel]}
+			if ref, ok := obj.GetAnnotations()[constants.TofanTestCaseAnnotation]; ok {
+				owner.Namespace, owner.Name, _ = strings.Cut(ref, "/")
+			}
+			if inProgress[owner] {
+				continue
+			}
+			ref := resource.gvr.Resource + "/" + obj.GetName()
+			if obj.GetNamespace() != "" {
+				ref = obj.GetNamespace() + "/" + ref
+			}
+			if *dryRun {
+				fmt.Printf("%s would be deleted\n", ref)
+				continue
+			}
+
+			var target dynamic.ResourceInterface = dynamicClient.Resource(resource.gvr)
+			if resource.namespaced {
+				target = dynamicClient.Resource(resource.gvr).Namespace(obj.GetNamespace())
+			}
+			if err := target.Delete(ctx, obj.GetName(), metav1.DeleteOptions{}); err != nil && !apierrors.IsNotFound(err) {
+				return fmt.Errorf("failed to delete %s: %w", ref, err)
+			}
+			fmt.Printf("%s deleted\n", ref)
+			deleted++
+		}
+	}
+
+	if !*dryRun {
+		fmt.Printf("%d objects deleted\n", deleted)
+	}
+	return nil
+}
+
+type apiResource struct {
+	gvr        schema.GroupVersionResource
+	namespaced bool
+}
+
+// deletableResources returns every resource type the API server can list and delete.
+func deletableResources(discoveryClient discovery.DiscoveryInterface) ([]apiResource, error) {
+	lists, err := discoveryClient.ServerPreferredResources()
+	if err != nil && !discovery.IsGroupDiscoveryFailedError(err) {
+		return nil, err
+	}
+
+	var resources []apiResource
+	for _, list := range lists {
+		gv, err := schema.ParseGroupVersion(list.GroupVersion)
+		if err != nil {
+			continue
+		}
+		for _, r := range list.APIResources {
+			if strings.Contains(r.Name, "/") || !hasVerbs(r.Verbs, "list", "delete") {
+				continue
+			}
+			resources = append(resources, apiResource{gvr: gv.WithResource(r.Name), namespaced: r.Namespaced})
+		}
+	}
+	return resources, nil
+}
+
+func hasVerbs(verbs metav1.Verbs, required ...string) bool {
+	for _, verb := range required {
+		found := false
+		for _, v := range verbs {
+			if v == verb {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
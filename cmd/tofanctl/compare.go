@@ -0,0 +1,48 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/invioteq/tofan/pkg/report"
+)
+
+// runCompare prints the differences between two Reports, the first one being the baseline.
+func runCompare(args []string) error {
+	fs := flag.NewFlagSet("compare", flag.ExitOnError)
+	var files stringList
+	namespace := namespaceFlag(fs)
+	fs.Var(&files, "file", "Read a Report from a YAML or JSON file instead of the cluster. Can be repeated.")
+	format := fs.String("format", "text", "Output format: text or html.")
+	output := fs.String("output", "", "File to write the comparison to. Defaults to stdout.")
+	names := parseArgs(fs, args)
+
+	reports, err := loadReports(context.Background(), *namespace, names, files)
+	if err != nil {
+		return err
+	}
+	if len(reports) != 2 {
+		return fmt.Errorf("expected exactly two reports, got %d", len(reports))
+	}
+
+	w := os.Stdout
+	if *output != "" {
+		f, err := os.Create(*output)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		w = f
+	}
+
+	switch *format {
+	case "text":
+		return report.WriteComparison(w, reports[0], reports[1])
+	case "html":
+		return report.WriteHTML(w, reports)
+	default:
+		return fmt.Errorf("unsupported comparison format %q, expected text or html", *format)
+	}
+}
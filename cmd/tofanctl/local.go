@@ -0,0 +1,161 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/go-logr/logr"
+	tofaniov1alpha1 "github.com/invioteq/tofan/api/v1alpha1"
+	"github.com/invioteq/tofan/internal/testcase"
+	"github.com/invioteq/tofan/internal/tracing"
+	"github.com/invioteq/tofan/pkg/report"
+	"go.uber.org/zap/zapcore"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/config"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+)
+
+type localOptions struct {
+	namespace   string
+	interval    time.Duration
+	timeout     time.Duration
+	format      string
+	keep        bool
+	verbose     bool
+	prometheus  string
+	otlp        string
+	qps         float32
+	burst       int
+	diagnostics string
+}
+
+// runLocal runs the TestCases of a file from this machine and prints their Reports to stdout.
+func runLocal(objects []unstructured.Unstructured, opts localOptions) error {
+	var format tofaniov1alpha1.ReportFormat
+	if opts.format != "text" {
+		var err error
+		if format, err = report.ParseFormat(opts.format); err != nil {
+			return err
+		}
+	}
+
+	// Templates are keyed by kind and name, as TestCases reference them
+	templates := map[string]*tofaniov1alpha1.ObjectTemplate{}
+	var testCases []*tofaniov1alpha1.TestCase
+	for i := range objects {
+		switch objects[i].GroupVersionKind() {
+		case tofaniov1alpha1.GroupVersion.WithKind(tofaniov1alpha1.ObjectTemplateKind):
+			objTpl := &tofaniov1alpha1.ObjectTemplate{}
+			if err := runtime.DefaultUnstructuredConverter.FromUnstructured(objects[i].Object, objTpl); err != nil {
+				return err
+			}
+			templates[tofaniov1alpha1.ObjectTemplateKind+"/"+objTpl.Name] = objTpl
+		case tofaniov1alpha1.GroupVersion.WithKind(tofaniov1alpha1.ClusterObjectTemplateKind):
+			objTpl := &tofaniov1alpha1.ClusterObjectTemplate{}
+			if err := runtime.DefaultUnstructuredConverter.FromUnstructured(objects[i].Object, objTpl); err != nil {
+				return err
+			}
+			templates[tofaniov1alpha1.ClusterObjectTemplateKind+"/"+objTpl.Name] = objTpl.AsObjectTemplate()
+		case tofaniov1alpha1.GroupVersion.WithKind("TestCase"):
+			tc := &tofaniov1alpha1.TestCase{}
+			if err := runtime.DefaultUnstructuredConverter.FromUnstructured(objects[i].Object, tc); err != nil {
+				return err
+			}
+			if tc.Namespace == "" {
+				tc.Namespace = opts.namespace
+			}
+			testCases = append(testCases, tc)
+		default:
+			return fmt.Errorf("unexpected %s %s, a local run only accepts ObjectTemplates, ClusterObjectTemplates and TestCases", objects[i].GetKind(), objects[i].GetName())
+		}
+	}
+	if len(testCases) == 0 {
+		return fmt.Errorf("no TestCase found")
+	}
+
+	cfg, err := config.GetConfig()
+	if err != nil {
+		return err
+	}
+	runner, err := testcase.NewLocalRunner(cfg, localLogger(opts.verbose))
+	if err != nil {
+		return err
+	}
+	runner.Interval = opts.interval
+	runner.Timeout = opts.timeout
+	runner.SkipTeardown = opts.keep
+	runner.Reconciler.PrometheusURL = opts.prometheus
+	runner.DiagnosticsDir = opts.diagnostics
+	runner.Reconciler.LoadQPS = opts.qps
+	runner.Reconciler.LoadBurst = opts.burst
+
+	shutdownTracing, err := tracing.Setup(context.Background(), opts.otlp, "tofanctl")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		if err := shutdownTracing(ctx); err != nil {
+			fmt.Fprintf(os.Stderr, "failed to flush traces: %v\n", err)
+		}
+	}()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	failed := false
+	for _, tc := range testCases {
+		kind := tc.Spec.ObjectTemplateRef.Kind
+		if kind == "" {
+			kind = tofaniov1alpha1.ObjectTemplateKind
+		}
+		objTpl, ok := templates[kind+"/"+tc.Spec.ObjectTemplateRef.Name]
+		if !ok {
+			return fmt.Errorf("%s %q referenced by TestCase %s not found in the file", kind, tc.Spec.ObjectTemplateRef.Name, tc.Name)
+		}
+
+		fmt.Fprintf(os.Stderr, "running TestCase %s\n", tc.Name)
+		rep, err := runner.Run(ctx, tc, objTpl)
+		if rep != nil {
+			if tc.Status.Phase == testcase.StatusError {
+				failed = true
+			}
+			if printErr := printReport(rep, format); printErr != nil {
+				return printErr
+			}
+		}
+		if err != nil {
+			return err
+		}
+		if tc.Status.Phase == testcase.StatusAborted {
+			return fmt.Errorf("test run aborted")
+		}
+	}
+
+	if failed {
+		return fmt.Errorf("test run failed")
+	}
+	return nil
+}
+
+func printReport(rep *tofaniov1alpha1.Report, format tofaniov1alpha1.ReportFormat) error {
+	if format == "" {
+		return report.WriteSummary(os.Stdout, rep)
+	}
+	return report.Export(os.Stdout, rep, format)
+}
+
+// localLogger logs errors to stderr, and the full progress of the run when verbose is set.
+func localLogger(verbose bool) logr.Logger {
+	level := zapcore.ErrorLevel
+	if verbose {
+		level = zapcore.InfoLevel
+	}
+	return zap.New(zap.WriteTo(os.Stderr), zap.Level(level))
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"text/tabwriter"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// runLogs prints the Kubernetes Events recorded for a TestCase.
+func runLogs(args []string) error {
+	fs := flag.NewFlagSet("logs", flag.ExitOnError)
+	namespace := namespaceFlag(fs)
+	follow := fs.Bool("follow", false, "Keep polling for new events.")
+	fs.BoolVar(follow, "f", false, "Shorthand for --follow.")
+	interval := fs.Duration("interval", 2*time.Second, "How often new events are polled with --follow.")
+	names := parseArgs(fs, args)
+	if len(names) != 1 {
+		return fmt.Errorf("expected the name of a TestCase")
+	}
+
+	ctx := context.Background()
+	c, err := newClient()
+	if err != nil {
+		return err
+	}
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "TIME\tTYPE\tREASON\tMESSAGE")
+	seen := map[string]int32{}
+	for {
+		events := &corev1.EventList{}
+		err := c.List(ctx, events, client.InNamespace(*namespace), client.MatchingFields{
+			"involvedObject.kind": "TestCase",
+			"involvedObject.name": names[0],
+		})
+		if err != nil {
+			return err
+		}
+
+		sort.Slice(events.Items, func(i, j int) bool {
+			return eventTime(&events.Items[i]).Before(eventTime(&events.Items[j]))
+		})
+		for i := range events.Items {
+			event := &events.Items[i]
+			if count, ok := seen[string(event.UID)]; ok && count == event.Count {
+				continue
+			}
+			seen[string(event.UID)] = event.Count
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", eventTime(event).UTC().Format(time.RFC3339), event.Type, event.Reason, event.Message)
+		}
+		if err := tw.Flush(); err != nil {
+			return err
+		}
+
+		if !*follow {
+			return nil
+		}
+		time.Sleep(*interval)
+	}
+}
+
+// eventTime returns the most recent time an event was observed.
+func eventTime(event *corev1.Event) time.Time {
+	switch {
+	case !event.LastTimestamp.IsZero():
+		return event.LastTimestamp.Time
+	case !event.EventTime.IsZero():
+		return event.EventTime.Time
+	default:
+		return event.CreationTimestamp.Time
+	}
+}
+
+func formatTime(t *metav1.Time) string {
+	if t == nil {
+		return "-"
+	}
+	return t.UTC().Format(time.RFC3339)
+}
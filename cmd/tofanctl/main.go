@@ -0,0 +1,126 @@
+/*
+Copyright 2024 invioteq llc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+
+	// Import all Kubernetes client auth plugins (e.g. Azure, GCP, OIDC, etc.)
+	_ "k8s.io/client-go/plugin/pkg/client/auth"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/config"
+
+	tofaniov1alpha1 "github.com/invioteq/tofan/api/v1alpha1"
+)
+
+var scheme = runtime.NewScheme()
+
+func init() {
+	utilruntime.Must(clientgoscheme.AddToScheme(scheme))
+	utilruntime.Must(tofaniov1alpha1.AddToScheme(scheme))
+}
+
+// command is a tofanctl subcommand.
+type command struct {
+	// usage is the one-line description printed by the help output
+	usage string
+	// run executes the subcommand with the remaining command line arguments
+	run func(args []string) error
+}
+
+var commands = map[string]command{
+	"run":     {usage: "Apply a TestCase from a file and stream its progress until it finishes", run: runRun},
+	"status":  {usage: "Show the status of TestCases", run: runStatus},
+	"logs":    {usage: "Print the events of a TestCase", run: runLogs},
+	"report":  {usage: "Print or export a Report", run: runReport},
+	"compare": {usage: "Compare two Reports", run: runCompare},
+	"abort":   {usage: "Abort a running TestCase", run: runAbort},
+	"suspend": {usage: "Pause the object creation of a running TestCase", run: runSuspend},
+	"resume":  {usage: "Resume the object creation of a suspended TestCase", run: runResume},
+	"cleanup": {usage: "Delete objects left behind by TestCases", run: runCleanup},
+}
+
+func main() {
+	if len(os.Args) < 2 || os.Args[1] == "-h" || os.Args[1] == "--help" || os.Args[1] == "help" {
+		printUsage()
+		os.Exit(0)
+	}
+
+	cmd, ok := commands[os.Args[1]]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "unknown command %q\n", os.Args[1])
+		printUsage()
+		os.Exit(2)
+	}
+
+	if err := cmd.run(os.Args[2:]); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func printUsage() {
+	fmt.Fprintln(os.Stderr, "tofanctl controls tofan test runs.")
+	fmt.Fprintln(os.Stderr, "\nUsage:\n  tofanctl <command> [flags]\n\nCommands:")
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(os.Stderr, "  %-10s %s\n", name, commands[name].usage)
+	}
+	fmt.Fprintln(os.Stderr, "\nRun 'tofanctl <command> -h' for the flags of a command.")
+}
+
+// newClient builds a client for the cluster selected by the kubeconfig.
+func newClient() (client.Client, error) {
+	cfg, err := config.GetConfig()
+	if err != nil {
+		return nil, err
+	}
+	return client.New(cfg, client.Options{Scheme: scheme})
+}
+
+// namespaceFlag registers the --namespace flag and its -n shorthand.
+func namespaceFlag(fs *flag.FlagSet) *string {
+	namespace := fs.String("namespace", "default", "Namespace of the TestCase.")
+	fs.StringVar(namespace, "n", "default", "Shorthand for --namespace.")
+	return namespace
+}
+
+// parseArgs parses flags that may appear before or after positional arguments and returns the
+// positional arguments.
+func parseArgs(fs *flag.FlagSet, args []string) []string {
+	var positional []string
+	for {
+		_ = fs.Parse(args)
+		args = fs.Args()
+		if len(args) == 0 {
+			return positional
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+}
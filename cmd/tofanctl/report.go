@@ -0,0 +1,135 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	tofaniov1alpha1 "github.com/invioteq/tofan/api/v1alpha1"
+	"github.com/invioteq/tofan/pkg/report"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/yaml"
+)
+
+// stringList is a flag that can be given several times.
+type stringList []string
+
+func (s *stringList) String() string { return strings.Join(*s, ",") }
+
+func (s *stringList) Set(v string) error {
+	*s = append(*s, v)
+	return nil
+}
+
+// runReport renders Reports, read from the cluster or from files, in one of the export formats.
+// Several reports can be rendered together in the html format to compare them.
+func runReport(args []string) error {
+	fs := flag.NewFlagSet("report", flag.ExitOnError)
+	var names, files stringList
+	namespace := namespaceFlag(fs)
+	fs.Var(&names, "name", "Name of a Report to export. Can be repeated for the html format.")
+	fs.Var(&files, "file", "Read a Report from a YAML or JSON file instead of the cluster. Can be repeated for the html format.")
+	testCase := fs.String("testcase", "", "Print the latest Report of this TestCase.")
+	format := fs.String("format", "text", "Output format: text, junit, json, csv or html.")
+	output := fs.String("output", "", "File to write the rendered report to. Defaults to stdout.")
+	names = append(names, parseArgs(fs, args)...)
+
+	var outputFormat tofaniov1alpha1.ReportFormat
+	if *format != "text" {
+		var err error
+		if outputFormat, err = report.ParseFormat(*format); err != nil {
+			return err
+		}
+	}
+
+	ctx := context.Background()
+	if *testCase != "" {
+		name, err := testCaseReport(ctx, *namespace, *testCase)
+		if err != nil {
+			return err
+		}
+		names = append(names, name)
+	}
+
+	reports, err := loadReports(ctx, *namespace, names, files)
+	if err != nil {
+		return err
+	}
+	if len(reports) > 1 && outputFormat != tofaniov1alpha1.ReportFormatHTML {
+		return fmt.Errorf("only the html format can render several reports")
+	}
+
+	var w io.Writer = os.Stdout
+	if *output != "" {
+		f, err := os.Create(*output)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		w = f
+	}
+
+	switch outputFormat {
+	case "":
+		return report.WriteSummary(w, reports[0])
+	case tofaniov1alpha1.ReportFormatHTML:
+		return report.WriteHTML(w, reports)
+	default:
+		return report.Export(w, reports[0], outputFormat)
+	}
+}
+
+// testCaseReport returns the name of the Report produced by the latest run of a TestCase.
+func testCaseReport(ctx context.Context, namespace, name string) (string, error) {
+	c, err := newClient()
+	if err != nil {
+		return "", err
+	}
+	tc := &tofaniov1alpha1.TestCase{}
+	if err := c.Get(ctx, client.ObjectKey{Namespace: namespace, Name: name}, tc); err != nil {
+		return "", err
+	}
+	if tc.Status.ReportRef == "" {
+		return "", fmt.Errorf("TestCase %s has no report yet", name)
+	}
+	return tc.Status.ReportRef, nil
+}
+
+// loadReports reads the Reports given as files and fetches the ones given by name from the cluster.
+func loadReports(ctx context.Context, namespace string, names, files []string) ([]*tofaniov1alpha1.Report, error) {
+	if len(names) == 0 && len(files) == 0 {
+		return nil, fmt.Errorf("either --name or --file is required")
+	}
+
+	var reports []*tofaniov1alpha1.Report
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		rep := &tofaniov1alpha1.Report{}
+		if err := yaml.Unmarshal(data, rep); err != nil {
+			return nil, fmt.Errorf("failed to decode report %s: %w", file, err)
+		}
+		reports = append(reports, rep)
+	}
+
+	if len(names) == 0 {
+		return reports, nil
+	}
+	c, err := newClient()
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range names {
+		rep := &tofaniov1alpha1.Report{}
+		if err := c.Get(ctx, client.ObjectKey{Namespace: namespace, Name: name}, rep); err != nil {
+			return nil, err
+		}
+		reports = append(reports, rep)
+	}
+	return reports, nil
+}
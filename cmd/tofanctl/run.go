@@ -0,0 +1,283 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	tofaniov1alpha1 "github.com/invioteq/tofan/api/v1alpha1"
+	"github.com/invioteq/tofan/internal/testcase"
+	"github.com/invioteq/tofan/pkg/constants"
+	"github.com/invioteq/tofan/pkg/report"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// runRun applies the objects of a file, waits for its TestCases to finish and prints their Reports.
+func runRun(args []string) error {
+	fs := flag.NewFlagSet("run", flag.ExitOnError)
+	namespace := namespaceFlag(fs)
+	file := fs.String("file", "", "YAML file holding the TestCase and, optionally, its ObjectTemplates.")
+	fs.StringVar(file, "f", "", "Shorthand for --file.")
+	replace := fs.Bool("replace", false, "Delete and recreate TestCases that already exist.")
+	interval := fs.Duration("interval", 5*time.Second, "How often the progress of the run is polled.")
+	timeout := fs.Duration("timeout", 0, "Stop waiting for the run after this long. Zero waits until it finishes.")
+	local := fs.Bool("local", false, "Run the TestCase from this machine instead of the operator. The tofan CRDs need not be installed.")
+	format := fs.String("format", "text", "Output format of the Report printed by --local: text, junit, json, csv or html.")
+	keep := fs.Bool("keep", false, "Leave the created objects in the cluster after a --local run.")
+	diagnostics := fs.String("diagnostics-dir", "", "Directory the objects, Events and children of a failed or interrupted --local run are captured to before teardown.")
+	verbose := fs.Bool("v", false, "Log the progress of a --local run to stderr.")
+	prometheusURL := fs.String("prometheus-url", "", "Prometheus server the targetMetrics of a --local run are queried from.")
+	loadQPS := fs.Float64("load-qps", 100, "Client-side rate limit, in requests per second, of a --local run without a clientRateLimit. Zero removes the limit.")
+	loadBurst := fs.Int("load-burst", 200, "Burst of the client-side rate limiter of a --local run without a clientRateLimit.")
+	otlpEndpoint := fs.String("otlp-endpoint", os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT"), "OTLP/HTTP endpoint a --local run is exported to as a trace.")
+	parseArgs(fs, args)
+
+	if *file == "" {
+		return fmt.Errorf("--file is required")
+	}
+	objects, err := readObjects(*file)
+	if err != nil {
+		return err
+	}
+
+	if *local {
+		return runLocal(objects, localOptions{
+			namespace:   *namespace,
+			interval:    *interval,
+			timeout:     *timeout,
+			format:      *format,
+			keep:        *keep,
+			verbose:     *verbose,
+			prometheus:  *prometheusURL,
+			otlp:        *otlpEndpoint,
+			qps:         float32(*loadQPS),
+			burst:       *loadBurst,
+			diagnostics: *diagnostics,
+		})
+	}
+
+	ctx := context.Background()
+	if *timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, *timeout)
+		defer cancel()
+	}
+
+	c, err := newClient()
+	if err != nil {
+		return err
+	}
+
+	var testCases []client.ObjectKey
+	for i := range objects {
+		obj := &objects[i]
+		if obj.GetNamespace() == "" && obj.GroupVersionKind() != tofaniov1alpha1.GroupVersion.WithKind(tofaniov1alpha1.ClusterObjectTemplateKind) {
+			obj.SetNamespace(*namespace)
+		}
+		if obj.GroupVersionKind() == tofaniov1alpha1.GroupVersion.WithKind("TestCase") {
+			if err := createTestCase(ctx, c, obj, *replace); err != nil {
+				return err
+			}
+			testCases = append(testCases, client.ObjectKeyFromObject(obj))
+			continue
+		}
+		if err := applyObject(ctx, c, obj); err != nil {
+			return err
+		}
+	}
+	if len(testCases) == 0 {
+		return fmt.Errorf("no TestCase found in %s", *file)
+	}
+
+	failed := false
+	for _, key := range testCases {
+		tc, err := waitForTestCase(ctx, c, key, *interval)
+		if err != nil {
+			return err
+		}
+		if tc.Status.Phase == testcase.StatusError || tc.Status.Phase == testcase.StatusAborted {
+			failed = true
+		}
+		if tc.Status.ReportRef == "" {
+			continue
+		}
+		rep := &tofaniov1alpha1.Report{}
+		if err := c.Get(ctx, client.ObjectKey{Namespace: key.Namespace, Name: tc.Status.ReportRef}, rep); err != nil {
+			return err
+		}
+		fmt.Println()
+		if err := report.WriteSummary(os.Stdout, rep); err != nil {
+			return err
+		}
+	}
+
+	if failed {
+		return fmt.Errorf("test run failed")
+	}
+	return nil
+}
+
+// readObjects decodes every YAML or JSON document of a file.
+func readObjects(file string) ([]unstructured.Unstructured, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	var objects []unstructured.Unstructured
+	decoder := utilyaml.NewYAMLOrJSONDecoder(bytes.NewReader(data), 4096)
+	for {
+		obj := unstructured.Unstructured{}
+		if err := decoder.Decode(&obj.Object); err != nil {
+			if errors.Is(err, io.EOF) {
+				return objects, nil
+			}
+			return nil, fmt.Errorf("failed to decode %s: %w", file, err)
+		}
+		if len(obj.Object) == 0 {
+			continue
+		}
+		objects = append(objects, obj)
+	}
+}
+
+// applyObject creates the object or updates it when it already exists.
+func applyObject(ctx context.Context, c client.Client, obj *unstructured.Unstructured) error {
+	existing := &unstructured.Unstructured{}
+	existing.SetGroupVersionKind(obj.GroupVersionKind())
+	err := c.Get(ctx, client.ObjectKeyFromObject(obj), existing)
+	if apierrors.IsNotFound(err) {
+		if err := c.Create(ctx, obj); err != nil {
+			return err
+		}
+		fmt.Printf("%s/%s created\n", obj.GetKind(), obj.GetName())
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	obj.SetResourceVersion(existing.GetResourceVersion())
+	if err := c.Update(ctx, obj); err != nil {
+		return err
+	}
+	fmt.Printf("%s/%s configured\n", obj.GetKind(), obj.GetName())
+	return nil
+}
+
+// createTestCase creates a TestCase, deleting a previous one of the same name first when replace is set.
+func createTestCase(ctx context.Context, c client.Client, obj *unstructured.Unstructured, replace bool) error {
+	existing := &tofaniov1alpha1.TestCase{}
+	err := c.Get(ctx, client.ObjectKeyFromObject(obj), existing)
+	if err == nil {
+		if !replace {
+			return fmt.Errorf("TestCase %s already exists, delete it or use --replace", obj.GetName())
+		}
+		if err := c.Delete(ctx, existing); err != nil && !apierrors.IsNotFound(err) {
+			return err
+		}
+		fmt.Printf("TestCase/%s deleted, waiting for its teardown\n", obj.GetName())
+		if err := waitForDeletion(ctx, c, existing); err != nil {
+			return err
+		}
+	} else if !apierrors.IsNotFound(err) {
+		return err
+	}
+
+	if err := c.Create(ctx, obj); err != nil {
+		return err
+	}
+	fmt.Printf("TestCase/%s created\n", obj.GetName())
+	return nil
+}
+
+func waitForDeletion(ctx context.Context, c client.Client, obj client.Object) error {
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+	for {
+		if err := c.Get(ctx, client.ObjectKeyFromObject(obj), obj); apierrors.IsNotFound(err) {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// waitForTestCase polls a TestCase and prints its progress until it completes or fails.
+func waitForTestCase(ctx context.Context, c client.Client, key client.ObjectKey, interval time.Duration) (*tofaniov1alpha1.TestCase, error) {
+	start := time.Now()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	last := ""
+	for {
+		tc := &tofaniov1alpha1.TestCase{}
+		if err := c.Get(ctx, key, tc); err != nil {
+			return nil, err
+		}
+
+		line := fmt.Sprintf("phase=%s", tc.Status.Phase)
+		if created, ready, err := countObjects(ctx, c, tc); err == nil {
+			line += fmt.Sprintf(" created=%d ready=%d", created, ready)
+		}
+		if line != last {
+			fmt.Printf("[%s] %s: %s\n", time.Since(start).Round(time.Second), key.Name, line)
+			last = line
+		}
+
+		if testcase.IsFinished(tc.Status.Phase) {
+			return tc, nil
+		}
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// countObjects counts the objects created for a TestCase and how many of them are ready.
+func countObjects(ctx context.Context, c client.Client, tc *tofaniov1alpha1.TestCase) (int, int, error) {
+	objTpl := &tofaniov1alpha1.ObjectTemplate{}
+	if tc.Spec.ObjectTemplateRef.Kind == tofaniov1alpha1.ClusterObjectTemplateKind {
+		clusterTpl := &tofaniov1alpha1.ClusterObjectTemplate{}
+		if err := c.Get(ctx, client.ObjectKey{Name: tc.Spec.ObjectTemplateRef.Name}, clusterTpl); err != nil {
+			return 0, 0, err
+		}
+		objTpl = clusterTpl.AsObjectTemplate()
+	} else if err := c.Get(ctx, client.ObjectKey{Namespace: tc.Namespace, Name: tc.Spec.ObjectTemplateRef.Name}, objTpl); err != nil {
+		return 0, 0, err
+	}
+	if objTpl.Status.Kind == "" {
+		return 0, 0, fmt.Errorf("template %s has not been synced yet", objTpl.Name)
+	}
+
+	list := &unstructured.UnstructuredList{}
+	list.SetGroupVersionKind(schema.GroupVersionKind{Group: objTpl.Status.Group, Version: objTpl.Status.Version, Kind: objTpl.Status.Kind + "List"})
+	labels := client.MatchingLabels{constants.TofanTestCaseNameLabel: tc.Name, constants.TofanTestCaseUIDLabel: string(tc.UID)}
+	if err := c.List(ctx, list, labels); err != nil {
+		return 0, 0, err
+	}
+
+	ready := 0
+	for i := range list.Items {
+		if testcase.IsResourceReady(&list.Items[i]) {
+			ready++
+		}
+	}
+	return len(list.Items), ready, nil
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	tofaniov1alpha1 "github.com/invioteq/tofan/api/v1alpha1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// runStatus prints a table of the TestCases in a namespace, or the details of the named ones.
+func runStatus(args []string) error {
+	fs := flag.NewFlagSet("status", flag.ExitOnError)
+	namespace := namespaceFlag(fs)
+	names := parseArgs(fs, args)
+
+	ctx := context.Background()
+	c, err := newClient()
+	if err != nil {
+		return err
+	}
+
+	if len(names) == 0 {
+		list := &tofaniov1alpha1.TestCaseList{}
+		if err := c.List(ctx, list, client.InNamespace(*namespace)); err != nil {
+			return err
+		}
+		tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(tw, "NAME\tPHASE\tCREATED\tSTARTED\tDURATION\tREPORT")
+		for _, tc := range list.Items {
+			fmt.Fprintf(tw, "%s\t%s\t%d/%d\t%s\t%s\t%s\n", tc.Name, tc.Status.Phase, tc.Status.Created, tc.Spec.Count,
+				formatTime(tc.Status.StartTime), runTime(&tc), tc.Status.ReportRef)
+		}
+		return tw.Flush()
+	}
+
+	for i, name := range names {
+		tc := &tofaniov1alpha1.TestCase{}
+		if err := c.Get(ctx, client.ObjectKey{Namespace: *namespace, Name: name}, tc); err != nil {
+			return err
+		}
+		if i > 0 {
+			fmt.Println()
+		}
+		printTestCase(tc)
+	}
+	return nil
+}
+
+func printTestCase(tc *tofaniov1alpha1.TestCase) {
+	tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintf(tw, "Name:\t%s\n", tc.Name)
+	fmt.Fprintf(tw, "Namespace:\t%s\n", tc.Namespace)
+	fmt.Fprintf(tw, "ObjectTemplate:\t%s\n", tc.Spec.ObjectTemplateRef.Name)
+	fmt.Fprintf(tw, "Count:\t%d\n", tc.Spec.Count)
+	fmt.Fprintf(tw, "Concurrency:\t%d\n", tc.Spec.Concurrency)
+	fmt.Fprintf(tw, "Phase:\t%s\n", tc.Status.Phase)
+	fmt.Fprintf(tw, "Created:\t%d\n", tc.Status.Created)
+	fmt.Fprintf(tw, "Failed:\t%d (error budget %d)\n", tc.Status.Failed, tc.Spec.ErrorBudget)
+	if tc.Spec.ExpectedFailure != nil && tc.Spec.ExpectedFailure.Admission != nil {
+		fmt.Fprintf(tw, "Rejected:\t%d (expected)\n", tc.Status.Rejected)
+	}
+	fmt.Fprintf(tw, "Retries:\t%d\n", tc.Status.Retries)
+	fmt.Fprintf(tw, "Suspended:\t%t\n", tc.Spec.Suspend)
+	fmt.Fprintf(tw, "Started:\t%s\n", formatTime(tc.Status.StartTime))
+	fmt.Fprintf(tw, "Completed:\t%s\n", formatTime(tc.Status.CompletionTime))
+	fmt.Fprintf(tw, "Cleaned up:\t%s\n", formatTime(tc.Status.CleanupTime))
+	fmt.Fprintf(tw, "Duration:\t%s\n", runTime(tc))
+	fmt.Fprintf(tw, "Report:\t%s\n", tc.Status.ReportRef)
+	if len(tc.Status.Errors) > 0 {
+		fmt.Fprintln(tw, "\nREASON\tCOUNT\tLAST MESSAGE")
+		for _, e := range tc.Status.Errors {
+			fmt.Fprintf(tw, "%s\t%d\t%s\n", e.Reason, e.Count, e.Message)
+		}
+	}
+	if len(tc.Status.Conditions) > 0 {
+		fmt.Fprintln(tw, "\nTYPE\tSTATUS\tREASON\tLAST TRANSITION\tMESSAGE")
+		for _, cond := range tc.Status.Conditions {
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", cond.Type, cond.Status, cond.Reason,
+				cond.LastTransitionTime.UTC().Format(time.RFC3339), cond.Message)
+		}
+	}
+	_ = tw.Flush()
+}
+
+// runTime returns how long the TestCase has been running, or ran for if it finished.
+func runTime(tc *tofaniov1alpha1.TestCase) string {
+	if tc.Status.StartTime == nil {
+		return "-"
+	}
+	end := time.Now()
+	if tc.Status.CompletionTime != nil {
+		end = tc.Status.CompletionTime.Time
+	}
+	return end.Sub(tc.Status.StartTime.Time).Round(time.Second).String()
+}
@@ -2,6 +2,7 @@ package common
 
 import (
 	"context"
+	"fmt"
 	"github.com/go-logr/logr"
 	tofaniov1alpha1 "github.com/invioteq/tofan/api/v1alpha1"
 	corev1 "k8s.io/api/core/v1"
@@ -48,6 +49,39 @@ func (r *Reconciler) FetchObjectTemplate(ctx context.Context, namespace, name st
 	return objectTemplate, nil
 }
 
+// FetchClusterObjectTemplate retrieves a ClusterObjectTemplate by name.
+func (r *Reconciler) FetchClusterObjectTemplate(ctx context.Context, name string) (*tofaniov1alpha1.ClusterObjectTemplate, error) {
+	objectTemplate := &tofaniov1alpha1.ClusterObjectTemplate{}
+	if err := r.Get(ctx, client.ObjectKey{Name: name}, objectTemplate); err != nil {
+		r.Log.Info("Failed to get ClusterObjectTemplate", "Name", name)
+		return nil, err
+	}
+
+	return objectTemplate, nil
+}
+
+// FetchTestCaseTemplate retrieves the template referenced by the TestCase, an ObjectTemplate in its
+// namespace or a ClusterObjectTemplate, which is returned as an ObjectTemplate without namespace.
+func (r *Reconciler) FetchTestCaseTemplate(ctx context.Context, testCase *tofaniov1alpha1.TestCase) (*tofaniov1alpha1.ObjectTemplate, error) {
+	ref := testCase.Spec.ObjectTemplateRef
+	if ref.Group != "" && ref.Group != tofaniov1alpha1.GroupVersion.Group && ref.Group != tofaniov1alpha1.GroupVersion.String() {
+		return nil, fmt.Errorf("unsupported group %q of the template of TestCase %s", ref.Group, testCase.Name)
+	}
+
+	switch ref.Kind {
+	case "", tofaniov1alpha1.ObjectTemplateKind:
+		return r.FetchObjectTemplate(ctx, testCase.Namespace, ref.Name)
+	case tofaniov1alpha1.ClusterObjectTemplateKind:
+		objectTemplate, err := r.FetchClusterObjectTemplate(ctx, ref.Name)
+		if err != nil {
+			return nil, err
+		}
+		return objectTemplate.AsObjectTemplate(), nil
+	default:
+		return nil, fmt.Errorf("unsupported kind %q of the template of TestCase %s", ref.Kind, testCase.Name)
+	}
+}
+
 // EmitEvent emits a Kubernetes event for the given object.
 func (r *Reconciler) EmitEvent(object runtime.Object, name string, res controllerutil.OperationResult, msg string, err error) {
 	eventType := corev1.EventTypeNormal
@@ -120,3 +154,8 @@ func (r *Reconciler) setCondition(object ConditionedObject, observedGeneration i
 	object.SetConditions(append(existingConditions, newCondition))
 	r.Log.WithName(object.GetName()).Info("Setting lastTransitionTime condition for ", "name", object.GetName(), "time", newCondition.LastTransitionTime.Time)
 }
+
+// SetCondition sets a condition on the object without updating its status.
+func (r *Reconciler) SetCondition(object ConditionedObject, conditionType string, status metav1.ConditionStatus, reason, message string) {
+	r.setCondition(object, object.GetGeneration(), conditionType, status, reason, message)
+}
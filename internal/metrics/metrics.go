@@ -0,0 +1,132 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+var (
+	// ObjectsCreated counts the instances created, by TestCase.
+	ObjectsCreated = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "tofan_objects_created_total",
+		Help: "Number of objects created by TestCase runs.",
+	}, []string{"namespace", "testcase"})
+
+	// ObjectsFailed counts the instances that could not be created, by TestCase and error reason.
+	ObjectsFailed = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "tofan_objects_failed_total",
+		Help: "Number of objects TestCase runs failed to create, by error reason.",
+	}, []string{"namespace", "testcase", "reason"})
+
+	// RequestRetries counts the requests for instances retried after a retriable error, by TestCase.
+	RequestRetries = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "tofan_api_request_retries_total",
+		Help: "Number of requests for objects of TestCase runs retried after a retriable error.",
+	}, []string{"namespace", "testcase"})
+
+	// RequestDuration observes the latency of the write requests sent to the API server for instances.
+	RequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "tofan_api_request_duration_seconds",
+		Help:    "Latency of the write requests sent to the API server for objects of TestCase runs, by verb.",
+		Buckets: prometheus.ExponentialBuckets(0.005, 2, 14),
+	}, []string{"verb", "group", "version", "kind"})
+
+	// TimeToReady observes the time from creation to readiness of instances, by TestCase.
+	TimeToReady = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "tofan_object_time_to_ready_seconds",
+		Help:    "Time from creation until objects of TestCase runs became ready.",
+		Buckets: prometheus.ExponentialBuckets(0.1, 2, 14),
+	}, []string{"namespace", "testcase"})
+
+	// TimeToConverge observes the time from an update of instances until their new generation was observed, by TestCase.
+	TimeToConverge = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "tofan_object_time_to_converge_seconds",
+		Help:    "Time from an update of objects of TestCase runs until the target controller observed their new generation.",
+		Buckets: prometheus.ExponentialBuckets(0.1, 2, 14),
+	}, []string{"namespace", "testcase"})
+
+	// TimeToCorrect observes the time from the drift applied to instances until it was corrected, by TestCase.
+	TimeToCorrect = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "tofan_object_time_to_correct_seconds",
+		Help:    "Time from the drift applied to objects of TestCase runs until the target controller corrected it.",
+		Buckets: prometheus.ExponentialBuckets(0.1, 2, 14),
+	}, []string{"namespace", "testcase"})
+
+	// ActiveRuns is the number of TestCase runs executing.
+	ActiveRuns = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "tofan_active_runs",
+		Help: "Number of TestCase runs executing.",
+	})
+
+	// TeardownDuration observes how long teardowns take until every object is gone, by TestCase.
+	TeardownDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "tofan_teardown_duration_seconds",
+		Help:    "Time from the teardown request until every object of a TestCase run was deleted.",
+		Buckets: prometheus.ExponentialBuckets(0.1, 2, 14),
+	}, []string{"namespace", "testcase"})
+
+	// ThrottledRequests counts the requests for instances the API server rejected with 429 Too Many Requests.
+	ThrottledRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "tofan_api_throttled_requests_total",
+		Help: "Number of requests for objects of TestCase runs rejected by the API server with 429 Too Many Requests.",
+	}, []string{"namespace", "testcase"})
+
+	// ClientThrottleSeconds accumulates the time requests for instances waited for the client-side rate limiter.
+	ClientThrottleSeconds = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "tofan_client_throttle_seconds_total",
+		Help: "Time requests for objects of TestCase runs spent waiting for the client-side rate limiter.",
+	}, []string{"namespace", "testcase"})
+
+	// OrphansDeleted counts the objects the orphan sweeper deleted, by type.
+	OrphansDeleted = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "tofan_orphaned_objects_deleted_total",
+		Help: "Number of objects created by TestCases that no longer exist deleted by the orphan sweeper.",
+	}, []string{"group", "version", "kind"})
+
+	// OrphanSweeps counts the sweeps of the orphan sweeper, by result.
+	OrphanSweeps = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "tofan_orphan_sweeps_total",
+		Help: "Number of orphan sweeps, by result.",
+	}, []string{"result"})
+
+	// OrphanSweepDuration observes how long the sweeps of the orphan sweeper take.
+	OrphanSweepDuration = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Name:    "tofan_orphan_sweep_duration_seconds",
+		Help:    "Duration of the orphan sweeps.",
+		Buckets: prometheus.ExponentialBuckets(0.1, 2, 12),
+	})
+)
+
+func init() {
+	metrics.Registry.MustRegister(
+		ObjectsCreated,
+		ObjectsFailed,
+		RequestRetries,
+		RequestDuration,
+		TimeToReady,
+		TimeToConverge,
+		TimeToCorrect,
+		ActiveRuns,
+		TeardownDuration,
+		ThrottledRequests,
+		ClientThrottleSeconds,
+		OrphansDeleted,
+		OrphanSweeps,
+		OrphanSweepDuration,
+	)
+}
+
+// DeleteTestCase removes the series of a deleted TestCase, so the label sets of the TestCases that
+// come and go do not pile up.
+func DeleteTestCase(namespace, name string) {
+	labels := prometheus.Labels{"namespace": namespace, "testcase": name}
+	ObjectsCreated.DeletePartialMatch(labels)
+	ObjectsFailed.DeletePartialMatch(labels)
+	RequestRetries.DeletePartialMatch(labels)
+	TimeToReady.DeletePartialMatch(labels)
+	TimeToConverge.DeletePartialMatch(labels)
+	TimeToCorrect.DeletePartialMatch(labels)
+	TeardownDuration.DeletePartialMatch(labels)
+	ThrottledRequests.DeletePartialMatch(labels)
+	ClientThrottleSeconds.DeletePartialMatch(labels)
+}
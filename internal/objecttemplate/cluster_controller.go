@@ -0,0 +1,65 @@
+/*
+Copyright 2024 invioteq llc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package objecttemplate
+
+import (
+	"context"
+
+	tofaniov1alpha1 "github.com/invioteq/tofan/api/v1alpha1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+// ClusterReconciler reconciles a ClusterObjectTemplate object
+type ClusterReconciler struct {
+	Reconciler
+}
+
+//+kubebuilder:rbac:groups=tofan.io,resources=clusterobjecttemplates,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=tofan.io,resources=clusterobjecttemplates/status,verbs=get;update;patch
+//+kubebuilder:rbac:groups=tofan.io,resources=clusterobjecttemplates/finalizers,verbs=update
+
+func (r *ClusterReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	log := r.Log.WithValues("ClusterObjectTemplate", req.Name)
+
+	// Fetch the ClusterObjectTemplate resource
+	objectTpl := &tofaniov1alpha1.ClusterObjectTemplate{}
+
+	err := r.Get(ctx, req.NamespacedName, objectTpl)
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			// ClusterObjectTemplate not found, return
+			log.Info("ClusterObjectTemplate not found.")
+
+			return ctrl.Result{}, nil
+		}
+
+		// Error reading the object - requeue the request.
+		return ctrl.Result{}, err
+	}
+	if !objectTpl.ObjectMeta.DeletionTimestamp.IsZero() {
+		return r.syncDeleteObjectTemplate(ctx, objectTpl)
+	}
+	return r.syncObjectTemplate(ctx, objectTpl, objectTpl.Spec, &objectTpl.Status)
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *ClusterReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&tofaniov1alpha1.ClusterObjectTemplate{}).
+		Complete(r)
+}
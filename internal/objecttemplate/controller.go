@@ -55,7 +55,7 @@ func (r *Reconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Resu
 	if !objectTpl.ObjectMeta.DeletionTimestamp.IsZero() {
 		return r.syncDeleteObjectTemplate(ctx, objectTpl)
 	}
-	return r.syncObjectTemplate(ctx, objectTpl)
+	return r.syncObjectTemplate(ctx, objectTpl, objectTpl.Spec, &objectTpl.Status)
 }
 
 // SetupWithManager sets up the controller with the Manager.
@@ -3,6 +3,7 @@ package objecttemplate
 import (
 	"context"
 	tofaniov1alpha1 "github.com/invioteq/tofan/api/v1alpha1"
+	"github.com/invioteq/tofan/internal/common"
 	"github.com/invioteq/tofan/pkg/constants"
 	"github.com/invioteq/tofan/pkg/utils"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -11,7 +12,8 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 )
 
-func (r *Reconciler) syncObjectTemplate(ctx context.Context, objecTpl *tofaniov1alpha1.ObjectTemplate) (result reconcile.Result, err error) {
+// syncObjectTemplate syncs an ObjectTemplate or a ClusterObjectTemplate, spec and status are those of objecTpl.
+func (r *Reconciler) syncObjectTemplate(ctx context.Context, objecTpl common.ConditionedObject, spec tofaniov1alpha1.ObjectTemplateSpec, status *tofaniov1alpha1.ObjectTemplateStatus) (result reconcile.Result, err error) {
 	if !controllerutil.ContainsFinalizer(objecTpl, constants.TofanFinalizer) {
 		controllerutil.AddFinalizer(objecTpl, constants.TofanFinalizer)
 
@@ -24,11 +26,11 @@ func (r *Reconciler) syncObjectTemplate(ctx context.Context, objecTpl *tofaniov1
 
 	r.ProcessCondition(ctx, objecTpl, constants.ObjConditionReady, metav1.ConditionTrue, "ObjectTemplateSyncSuccess", "ObjectTemplate synced successfully")
 	// Update the ObjectTpl status with kind & Group
-	ObjKind, ObjGroup, ObjVersion, err := utils.ExtractKindAndAPIVersion(objecTpl)
+	ObjKind, ObjGroup, ObjVersion, err := utils.ExtractKindAndAPIVersion(&tofaniov1alpha1.ObjectTemplate{Spec: spec})
 
-	objecTpl.Status.Group = ObjGroup
-	objecTpl.Status.Kind = ObjKind
-	objecTpl.Status.Version = ObjVersion
+	status.Group = ObjGroup
+	status.Kind = ObjKind
+	status.Version = ObjVersion
 	err = r.UpdateStatus(ctx, objecTpl)
 	if err != nil {
 		r.Log.Info("error updating the status")
@@ -41,7 +43,7 @@ func (r *Reconciler) syncObjectTemplate(ctx context.Context, objecTpl *tofaniov1
 
 }
 
-func (r *Reconciler) syncDeleteObjectTemplate(ctx context.Context, objecTpl *tofaniov1alpha1.ObjectTemplate) (result reconcile.Result, err error) {
+func (r *Reconciler) syncDeleteObjectTemplate(ctx context.Context, objecTpl common.ConditionedObject) (result reconcile.Result, err error) {
 	if controllerutil.ContainsFinalizer(objecTpl, constants.TofanFinalizer) {
 		controllerutil.RemoveFinalizer(objecTpl, constants.TofanFinalizer)
 
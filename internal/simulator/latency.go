@@ -0,0 +1,59 @@
+package simulator
+
+import (
+	"fmt"
+	"math/rand"
+	"time"
+)
+
+// LatencyDistribution is the distribution the latency of the simulated controller is drawn from.
+type LatencyDistribution string
+
+const (
+	// LatencyConstant makes every object ready after Mean.
+	LatencyConstant LatencyDistribution = "constant"
+	// LatencyUniform draws the latency between Mean-StdDev and Mean+StdDev.
+	LatencyUniform LatencyDistribution = "uniform"
+	// LatencyNormal draws the latency from a normal distribution.
+	LatencyNormal LatencyDistribution = "normal"
+	// LatencyExponential draws the latency from an exponential distribution, with a long tail.
+	LatencyExponential LatencyDistribution = "exponential"
+)
+
+// Latency is the time an object takes to become ready once the simulated controller saw it.
+type Latency struct {
+	Distribution LatencyDistribution
+	Mean         time.Duration
+	// StdDev is the standard deviation of a normal distribution and the half width of a uniform one
+	StdDev time.Duration
+}
+
+// ParseLatencyDistribution returns the distribution with the given name.
+func ParseLatencyDistribution(name string) (LatencyDistribution, error) {
+	switch d := LatencyDistribution(name); d {
+	case LatencyConstant, LatencyUniform, LatencyNormal, LatencyExponential:
+		return d, nil
+	default:
+		return "", fmt.Errorf("unknown latency distribution %q, expected constant, uniform, normal or exponential", name)
+	}
+}
+
+// Sample draws a latency. A negative draw is clamped to zero.
+func (l Latency) Sample() time.Duration {
+	latency := l.Mean
+	switch l.Distribution {
+	case LatencyUniform:
+		if l.StdDev > 0 {
+			latency = l.Mean - l.StdDev + time.Duration(rand.Int63n(int64(2*l.StdDev)+1))
+		}
+	case LatencyNormal:
+		latency = l.Mean + time.Duration(rand.NormFloat64()*float64(l.StdDev))
+	case LatencyExponential:
+		latency = time.Duration(rand.ExpFloat64() * float64(l.Mean))
+	}
+
+	if latency < 0 {
+		return 0
+	}
+	return latency
+}
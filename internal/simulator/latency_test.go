@@ -0,0 +1,42 @@
+package simulator
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLatencySampleBounds(t *testing.T) {
+	tests := []struct {
+		name     string
+		latency  Latency
+		min, max time.Duration
+	}{
+		{"constant", Latency{Distribution: LatencyConstant, Mean: time.Second, StdDev: time.Second}, time.Second, time.Second},
+		{"uniform", Latency{Distribution: LatencyUniform, Mean: time.Second, StdDev: 200 * time.Millisecond}, 800 * time.Millisecond, 1200 * time.Millisecond},
+		{"uniform without width", Latency{Distribution: LatencyUniform, Mean: time.Second}, time.Second, time.Second},
+		{"uniform clamped", Latency{Distribution: LatencyUniform, Mean: 100 * time.Millisecond, StdDev: time.Second}, 0, 1100 * time.Millisecond},
+		{"normal clamped", Latency{Distribution: LatencyNormal, Mean: 0, StdDev: time.Second}, 0, time.Duration(1<<63 - 1)},
+		{"exponential", Latency{Distribution: LatencyExponential, Mean: time.Second}, 0, time.Duration(1<<63 - 1)},
+		{"negative mean", Latency{Distribution: LatencyConstant, Mean: -time.Second}, 0, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for i := 0; i < 1000; i++ {
+				if got := tt.latency.Sample(); got < tt.min || got > tt.max {
+					t.Fatalf("Sample() = %s, want between %s and %s", got, tt.min, tt.max)
+				}
+			}
+		})
+	}
+}
+
+func TestParseLatencyDistribution(t *testing.T) {
+	for _, name := range []string{"constant", "uniform", "normal", "exponential"} {
+		if d, err := ParseLatencyDistribution(name); err != nil || string(d) != name {
+			t.Errorf("ParseLatencyDistribution(%q) = %q, %v", name, d, err)
+		}
+	}
+	if _, err := ParseLatencyDistribution("poisson"); err == nil {
+		t.Error("ParseLatencyDistribution(\"poisson\") returned no error")
+	}
+}
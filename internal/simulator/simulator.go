@@ -0,0 +1,220 @@
+// Package simulator is a stand-in for the controller of the objects TestCases create. It watches a
+// type, usually the sample Widget, and sets a Ready condition on its objects after a simulated
+// latency, failing some of them, so tofan can be exercised end to end on an API server that runs
+// no controllers, like the one of envtest.
+package simulator
+
+import (
+	"context"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/prometheus/client_golang/prometheus"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/flowcontrol"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+const (
+	// ConditionReady is the type of the condition set on the objects.
+	ConditionReady = "Ready"
+	// ReasonReady is the reason of the condition of the objects made ready.
+	ReasonReady = "SimulatedReady"
+	// ReasonFailed is the reason of the condition of the objects failed.
+	ReasonFailed = "SimulatedFailure"
+)
+
+var (
+	// ObjectsReconciled counts the objects the simulator made ready or failed, by result.
+	ObjectsReconciled = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "tofan_simulator_objects_total",
+		Help: "Number of objects the simulated controller made ready or failed, by result.",
+	}, []string{"result"})
+
+	// LatencySeconds observes the latency of the objects.
+	LatencySeconds = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Name:    "tofan_simulator_latency_seconds",
+		Help:    "Simulated latency of the objects, from the time they were seen until they were made ready or failed.",
+		Buckets: prometheus.ExponentialBuckets(0.01, 2, 14),
+	})
+)
+
+func init() {
+	metrics.Registry.MustRegister(ObjectsReconciled, LatencySeconds)
+}
+
+// Reconciler is the simulated controller. It sets the Ready condition and the observedGeneration
+// in the status of the objects of GVK, once per generation.
+type Reconciler struct {
+	client.Client
+	Log logr.Logger
+	// GVK is the type of the objects reconciled
+	GVK schema.GroupVersionKind
+	// Latency is the time from an object being seen until its condition is set
+	Latency Latency
+	// ErrorRate is the fraction of objects, between 0 and 1, set a False Ready condition
+	ErrorRate float64
+	// MaxThroughput caps the objects made ready or failed per second. Zero removes the cap
+	MaxThroughput float64
+	// Workers is the number of objects reconciled concurrently
+	Workers int
+
+	limiter flowcontrol.RateLimiter
+
+	mu sync.Mutex
+	// pending holds the outcome drawn for the objects not reconciled yet
+	pending map[types.NamespacedName]outcome
+}
+
+// outcome is what becomes of a generation of an object.
+type outcome struct {
+	uid        types.UID
+	generation int64
+	seen       time.Time
+	due        time.Time
+	fail       bool
+}
+
+func (r *Reconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	obj := &unstructured.Unstructured{}
+	obj.SetGroupVersionKind(r.GVK)
+	if err := r.Get(ctx, req.NamespacedName, obj); err != nil {
+		if apierrors.IsNotFound(err) {
+			r.forget(req.NamespacedName)
+		}
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+	if !obj.GetDeletionTimestamp().IsZero() {
+		r.forget(req.NamespacedName)
+		return ctrl.Result{}, nil
+	}
+	if observedGeneration(obj) == obj.GetGeneration() {
+		return ctrl.Result{}, nil
+	}
+
+	out := r.outcomeFor(req.NamespacedName, obj)
+	if wait := time.Until(out.due); wait > 0 {
+		return ctrl.Result{RequeueAfter: wait}, nil
+	}
+	if r.limiter != nil && !r.limiter.TryAccept() {
+		return ctrl.Result{RequeueAfter: time.Duration(float64(time.Second) / r.MaxThroughput)}, nil
+	}
+
+	condition := metav1.Condition{
+		Type:               ConditionReady,
+		Status:             metav1.ConditionTrue,
+		Reason:             ReasonReady,
+		Message:            "Object made ready by the simulated controller",
+		ObservedGeneration: obj.GetGeneration(),
+	}
+	result := "ready"
+	if out.fail {
+		condition.Status, condition.Reason, condition.Message = metav1.ConditionFalse, ReasonFailed, "Object failed by the simulated controller"
+		result = "failed"
+	}
+	if err := setCondition(obj, condition); err != nil {
+		return ctrl.Result{}, err
+	}
+	if err := r.Status().Update(ctx, obj); err != nil {
+		// A conflict is retried with the outcome drawn, the latency is not drawn again
+		return ctrl.Result{}, err
+	}
+
+	r.forget(req.NamespacedName)
+	ObjectsReconciled.WithLabelValues(result).Inc()
+	LatencySeconds.Observe(time.Since(out.seen).Seconds())
+	r.Log.V(1).Info("Set condition", "Namespace", obj.GetNamespace(), "Name", obj.GetName(), "Status", condition.Status)
+	return ctrl.Result{}, nil
+}
+
+// outcomeFor returns the outcome of the current generation of the object, drawing it the first
+// time the generation is seen.
+func (r *Reconciler) outcomeFor(key types.NamespacedName, obj *unstructured.Unstructured) outcome {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if out, ok := r.pending[key]; ok && out.uid == obj.GetUID() && out.generation == obj.GetGeneration() {
+		return out
+	}
+	now := time.Now()
+	out := outcome{
+		uid:        obj.GetUID(),
+		generation: obj.GetGeneration(),
+		seen:       now,
+		due:        now.Add(r.Latency.Sample()),
+		fail:       rand.Float64() < r.ErrorRate,
+	}
+	if r.pending == nil {
+		r.pending = make(map[types.NamespacedName]outcome)
+	}
+	r.pending[key] = out
+	return out
+}
+
+func (r *Reconciler) forget(key types.NamespacedName) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.pending, key)
+}
+
+// observedGeneration returns the generation the object was last reconciled at, or 0.
+func observedGeneration(obj *unstructured.Unstructured) int64 {
+	generation, _, _ := unstructured.NestedInt64(obj.Object, "status", "observedGeneration")
+	return generation
+}
+
+// setCondition sets the condition and the observedGeneration in the status of the object.
+func setCondition(obj *unstructured.Unstructured, condition metav1.Condition) error {
+	var status struct {
+		Conditions []metav1.Condition `json:"conditions,omitempty"`
+	}
+	if raw, ok := obj.Object["status"].(map[string]interface{}); ok {
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(raw, &status); err != nil {
+			return err
+		}
+	}
+	meta.SetStatusCondition(&status.Conditions, condition)
+
+	conditions, err := runtime.DefaultUnstructuredConverter.ToUnstructured(&status)
+	if err != nil {
+		return err
+	}
+	if err := unstructured.SetNestedField(obj.Object, conditions["conditions"], "status", "conditions"); err != nil {
+		return err
+	}
+	return unstructured.SetNestedField(obj.Object, obj.GetGeneration(), "status", "observedGeneration")
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *Reconciler) SetupWithManager(mgr ctrl.Manager) error {
+	if r.MaxThroughput > 0 {
+		burst := int(r.MaxThroughput)
+		if burst < 1 {
+			burst = 1
+		}
+		r.limiter = flowcontrol.NewTokenBucketRateLimiter(float32(r.MaxThroughput), burst)
+	}
+	workers := r.Workers
+	if workers < 1 {
+		workers = 1
+	}
+
+	obj := &unstructured.Unstructured{}
+	obj.SetGroupVersionKind(r.GVK)
+	return ctrl.NewControllerManagedBy(mgr).
+		Named("simulator").
+		For(obj).
+		WithOptions(controller.Options{MaxConcurrentReconciles: workers}).
+		Complete(r)
+}
@@ -0,0 +1,51 @@
+package simulator
+
+import (
+	"testing"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+func TestSetCondition(t *testing.T) {
+	obj := &unstructured.Unstructured{Object: map[string]interface{}{
+		"status": map[string]interface{}{"phase": "Pending"},
+	}}
+	obj.SetGeneration(1)
+
+	failed := metav1.Condition{Type: ConditionReady, Status: metav1.ConditionFalse, Reason: ReasonFailed, ObservedGeneration: 1}
+	if err := setCondition(obj, failed); err != nil {
+		t.Fatalf("setCondition() error = %v", err)
+	}
+	obj.SetGeneration(2)
+	ready := metav1.Condition{Type: ConditionReady, Status: metav1.ConditionTrue, Reason: ReasonReady, ObservedGeneration: 2}
+	if err := setCondition(obj, ready); err != nil {
+		t.Fatalf("setCondition() error = %v", err)
+	}
+
+	if got := observedGeneration(obj); got != 2 {
+		t.Errorf("observedGeneration = %d, want 2", got)
+	}
+	if phase, _, _ := unstructured.NestedString(obj.Object, "status", "phase"); phase != "Pending" {
+		t.Errorf("status.phase = %q, want it kept", phase)
+	}
+
+	var status struct {
+		Conditions []metav1.Condition `json:"conditions"`
+	}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(obj.Object["status"].(map[string]interface{}), &status); err != nil {
+		t.Fatalf("status not decodable: %v", err)
+	}
+	if len(status.Conditions) != 1 {
+		t.Fatalf("got %d conditions, want the Ready condition replaced", len(status.Conditions))
+	}
+	condition := meta.FindStatusCondition(status.Conditions, ConditionReady)
+	if condition.Status != metav1.ConditionTrue || condition.Reason != ReasonReady || condition.ObservedGeneration != 2 {
+		t.Errorf("Ready condition = %+v, want the ready one", condition)
+	}
+	if condition.LastTransitionTime.IsZero() {
+		t.Error("Ready condition has no transition time")
+	}
+}
@@ -0,0 +1,213 @@
+package sweeper
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/go-logr/logr"
+	tofaniov1alpha1 "github.com/invioteq/tofan/api/v1alpha1"
+	"github.com/invioteq/tofan/internal/metrics"
+	"github.com/invioteq/tofan/pkg/constants"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// OrphanDeletedReason is the reason of the event emitted for every orphan deleted.
+	OrphanDeletedReason = "OrphanDeleted"
+	// OrphanDeleteFailedReason is the reason of the event emitted when an orphan could not be deleted.
+	OrphanDeleteFailedReason = "OrphanDeleteFailed"
+)
+
+//+kubebuilder:rbac:groups=tofan.io,resources=reports,verbs=get;list;watch
+
+// Sweeper periodically deletes the objects labelled with the name of a TestCase that no longer
+// exists. Objects are left behind when the operator crashes mid-run or a TestCase goes away before
+// it could tear its objects down.
+type Sweeper struct {
+	// Reader reads the TestCases, ObjectTemplates and Reports. It must not be cached, a TestCase
+	// missing from a stale cache would have its objects deleted
+	Reader client.Reader
+	// Dynamic lists and deletes the labelled objects. Only the types the target-role of the manager
+	// grants access to are swept
+	Dynamic dynamic.Interface
+	// Mapper resolves the resources of the object types used by TestCases
+	Mapper   meta.RESTMapper
+	Recorder record.EventRecorder
+	Log      logr.Logger
+	// Interval is the time between two sweeps
+	Interval time.Duration
+}
+
+// Start sweeps every Interval until ctx is done. It implements manager.Runnable.
+func (s *Sweeper) Start(ctx context.Context) error {
+	ticker := time.NewTicker(s.Interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := s.Sweep(ctx); err != nil {
+			s.Log.Error(err, "Orphan sweep failed")
+		}
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+// Sweep deletes the orphaned objects of every type an ObjectTemplate or a recorded run used and
+// returns how many were deleted.
+func (s *Sweeper) Sweep(ctx context.Context) (int, error) {
+	start := time.Now()
+	deleted, err := s.sweep(ctx)
+	metrics.OrphanSweepDuration.Observe(time.Since(start).Seconds())
+
+	result := "success"
+	if err != nil {
+		result = "error"
+	}
+	metrics.OrphanSweeps.WithLabelValues(result).Inc()
+
+	if deleted > 0 {
+		s.Log.Info("Orphan sweep completed", "Deleted", deleted, "Duration", time.Since(start))
+	}
+	return deleted, err
+}
+
+func (s *Sweeper) sweep(ctx context.Context) (int, error) {
+	gvks, err := s.usedKinds(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	// List the objects before the TestCases, an object listed is never newer than its TestCase
+	candidates := map[schema.GroupVersionResource][]unstructured.Unstructured{}
+	for gvk := range gvks {
+		mapping, err := s.Mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+		if err != nil {
+			// The type is no longer served, there is nothing left to delete
+			s.Log.V(1).Info("Skipping unknown type", "GVK", gvk, "Error", err.Error())
+			continue
+		}
+
+		list, err := s.Dynamic.Resource(mapping.Resource).List(ctx, metav1.ListOptions{LabelSelector: constants.TofanTestCaseNameLabel})
+		if apierrors.IsForbidden(err) {
+			s.Log.V(1).Info("Skipping type the manager has no access to", "GVR", mapping.Resource)
+			continue
+		}
+		if err != nil {
+			s.Log.Error(err, "Failed to list labelled objects", "GVR", mapping.Resource)
+			continue
+		}
+		candidates[mapping.Resource] = list.Items
+	}
+
+	testCases := &tofaniov1alpha1.TestCaseList{}
+	if err := s.Reader.List(ctx, testCases); err != nil {
+		return 0, err
+	}
+	uids := make(map[string]bool, len(testCases.Items))
+	names := make(map[string]bool, len(testCases.Items))
+	for _, tc := range testCases.Items {
+		uids[string(tc.UID)] = true
+		names[tc.Name] = true
+	}
+
+	deleted := 0
+	for gvr, items := range candidates {
+		for i := range items {
+			obj := &items[i]
+			labels := obj.GetLabels()
+			if labels[constants.TofanStandaloneLabel] == "true" || obj.GetDeletionTimestamp() != nil {
+				continue
+			}
+			if uid, ok := labels[constants.TofanTestCaseUIDLabel]; ok && uids[uid] {
+				continue
+			}
+			// Objects created before the UID label was introduced are owned by any TestCase with the name
+			if _, ok := labels[constants.TofanTestCaseUIDLabel]; !ok && names[labels[constants.TofanTestCaseNameLabel]] {
+				continue
+			}
+			if s.deleteOrphan(ctx, gvr, obj) {
+				deleted++
+			}
+		}
+	}
+	return deleted, nil
+}
+
+// deleteOrphan deletes an orphaned object and records the outcome.
+func (s *Sweeper) deleteOrphan(ctx context.Context, gvr schema.GroupVersionResource, obj *unstructured.Unstructured) bool {
+	testCase := obj.GetLabels()[constants.TofanTestCaseNameLabel]
+	propagation := metav1.DeletePropagationBackground
+	uid := obj.GetUID()
+	err := s.Dynamic.Resource(gvr).Namespace(obj.GetNamespace()).Delete(ctx, obj.GetName(), metav1.DeleteOptions{
+		PropagationPolicy: &propagation,
+		// Guard against a recreated object with the same name
+		Preconditions: &metav1.Preconditions{UID: &uid},
+	})
+	if apierrors.IsNotFound(err) || apierrors.IsConflict(err) {
+		return false
+	}
+	if err != nil {
+		s.Log.Error(err, "Failed to delete orphaned object", "GVR", gvr, "Namespace", obj.GetNamespace(), "Name", obj.GetName())
+		s.Recorder.Eventf(obj, corev1.EventTypeWarning, OrphanDeleteFailedReason, "Failed to delete object of deleted TestCase %s: %v", testCase, err)
+		return false
+	}
+
+	gvk := obj.GroupVersionKind()
+	metrics.OrphansDeleted.WithLabelValues(gvk.Group, gvk.Version, gvk.Kind).Inc()
+	s.Recorder.Eventf(obj, corev1.EventTypeNormal, OrphanDeletedReason, "Deleted object of deleted TestCase %s", testCase)
+	s.Log.Info("Deleted orphaned object", "GVK", gvk, "Namespace", obj.GetNamespace(), "Name", obj.GetName(), "TestCase", testCase)
+	return true
+}
+
+// usedKinds returns the types of the ObjectTemplates and ClusterObjectTemplates in the cluster and of
+// the runs recorded in Reports, along with namespaces.
+func (s *Sweeper) usedKinds(ctx context.Context) (map[schema.GroupVersionKind]bool, error) {
+	// Namespaces are generated by runs that fan out, whatever their ObjectTemplate
+	gvks := map[schema.GroupVersionKind]bool{{Version: "v1", Kind: "Namespace"}: true}
+
+	templates := &tofaniov1alpha1.ObjectTemplateList{}
+	if err := s.Reader.List(ctx, templates); err != nil {
+		return nil, fmt.Errorf("failed to list ObjectTemplates: %w", err)
+	}
+	for _, tpl := range templates.Items {
+		if tpl.Status.Kind != "" {
+			gvks[schema.GroupVersionKind{Group: tpl.Status.Group, Version: tpl.Status.Version, Kind: tpl.Status.Kind}] = true
+		}
+	}
+
+	clusterTemplates := &tofaniov1alpha1.ClusterObjectTemplateList{}
+	if err := s.Reader.List(ctx, clusterTemplates); err != nil {
+		return nil, fmt.Errorf("failed to list ClusterObjectTemplates: %w", err)
+	}
+	for _, tpl := range clusterTemplates.Items {
+		if tpl.Status.Kind != "" {
+			gvks[schema.GroupVersionKind{Group: tpl.Status.Group, Version: tpl.Status.Version, Kind: tpl.Status.Kind}] = true
+		}
+	}
+
+	// Reports outlive their ObjectTemplates and remember the types earlier runs created
+	reports := &tofaniov1alpha1.ReportList{}
+	if err := s.Reader.List(ctx, reports); err != nil {
+		return nil, fmt.Errorf("failed to list Reports: %w", err)
+	}
+	for _, rep := range reports.Items {
+		if rep.Spec.Run.Kind != "" {
+			gvks[schema.GroupVersionKind{Group: rep.Spec.Run.Group, Version: rep.Spec.Run.Version, Kind: rep.Spec.Run.Kind}] = true
+		}
+	}
+
+	return gvks, nil
+}
@@ -0,0 +1,192 @@
+package sweeper
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	tofaniov1alpha1 "github.com/invioteq/tofan/api/v1alpha1"
+	"github.com/invioteq/tofan/pkg/constants"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	clienttesting "k8s.io/client-go/testing"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+var (
+	widgetGVK = schema.GroupVersionKind{Group: "simulator.tofan.io", Version: "v1alpha1", Kind: "Widget"}
+	widgetGVR = schema.GroupVersionResource{Group: "simulator.tofan.io", Version: "v1alpha1", Resource: "widgets"}
+)
+
+func widget(name string, labels map[string]string) *unstructured.Unstructured {
+	obj := &unstructured.Unstructured{}
+	obj.SetGroupVersionKind(widgetGVK)
+	obj.SetNamespace("default")
+	obj.SetName(name)
+	obj.SetLabels(labels)
+	return obj
+}
+
+func TestSweep(t *testing.T) {
+	testCase := &tofaniov1alpha1.TestCase{ObjectMeta: metav1.ObjectMeta{Name: "widgets", Namespace: "default", UID: "uid-1"}}
+	template := &tofaniov1alpha1.ObjectTemplate{
+		ObjectMeta: metav1.ObjectMeta{Name: "widget", Namespace: "default"},
+		Status:     tofaniov1alpha1.ObjectTemplateStatus{Group: widgetGVK.Group, Version: widgetGVK.Version, Kind: widgetGVK.Kind},
+	}
+	report := &tofaniov1alpha1.Report{
+		ObjectMeta: metav1.ObjectMeta{Name: "widgets-abcde", Namespace: "default"},
+		Spec: tofaniov1alpha1.ReportSpec{Run: tofaniov1alpha1.RunInfo{
+			Group: widgetGVK.Group, Version: widgetGVK.Version, Kind: widgetGVK.Kind,
+		}},
+	}
+	deleting := widget("deleting", map[string]string{constants.TofanTestCaseNameLabel: "gone"})
+	deleting.SetDeletionTimestamp(&metav1.Time{Time: time.Now()})
+
+	tests := []struct {
+		name    string
+		tofan   []client.Object
+		objects []runtime.Object
+		deleted int
+		kept    []string
+	}{
+		{
+			name:    "objects of a live TestCase are kept",
+			tofan:   []client.Object{testCase, template},
+			objects: []runtime.Object{widget("live", map[string]string{constants.TofanTestCaseNameLabel: "widgets"})},
+			kept:    []string{"live"},
+		},
+		{
+			name:  "objects of a deleted TestCase are deleted",
+			tofan: []client.Object{testCase, template},
+			objects: []runtime.Object{
+				widget("live", map[string]string{constants.TofanTestCaseNameLabel: "widgets"}),
+				widget("orphan", map[string]string{constants.TofanTestCaseNameLabel: "gone"}),
+			},
+			deleted: 1,
+			kept:    []string{"live"},
+		},
+		{
+			name:  "objects are owned by the UID of their TestCase",
+			tofan: []client.Object{testCase, template},
+			objects: []runtime.Object{
+				widget("live", map[string]string{constants.TofanTestCaseNameLabel: "widgets", constants.TofanTestCaseUIDLabel: "uid-1"}),
+				widget("recreated", map[string]string{constants.TofanTestCaseNameLabel: "widgets", constants.TofanTestCaseUIDLabel: "uid-0"}),
+			},
+			deleted: 1,
+			kept:    []string{"live"},
+		},
+		{
+			name:    "standalone objects are kept",
+			tofan:   []client.Object{template},
+			objects: []runtime.Object{widget("local", map[string]string{constants.TofanTestCaseNameLabel: "gone", constants.TofanStandaloneLabel: "true"})},
+			kept:    []string{"local"},
+		},
+		{
+			name:    "unlabelled objects are kept",
+			tofan:   []client.Object{template},
+			objects: []runtime.Object{widget("unrelated", nil)},
+			kept:    []string{"unrelated"},
+		},
+		{
+			name:    "objects already being deleted are skipped",
+			tofan:   []client.Object{template},
+			objects: []runtime.Object{deleting},
+			kept:    []string{"deleting"},
+		},
+		{
+			name:    "types are remembered by Reports",
+			tofan:   []client.Object{report},
+			objects: []runtime.Object{widget("orphan", map[string]string{constants.TofanTestCaseNameLabel: "gone"})},
+			deleted: 1,
+		},
+		{
+			name:    "types no template uses are left alone",
+			objects: []runtime.Object{widget("orphan", map[string]string{constants.TofanTestCaseNameLabel: "gone"})},
+			kept:    []string{"orphan"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scheme := runtime.NewScheme()
+			if err := tofaniov1alpha1.AddToScheme(scheme); err != nil {
+				t.Fatal(err)
+			}
+			mapper := meta.NewDefaultRESTMapper(nil)
+			mapper.Add(widgetGVK, meta.RESTScopeNamespace)
+			dynamic := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+				map[schema.GroupVersionResource]string{widgetGVR: "WidgetList"}, tt.objects...)
+
+			s := &Sweeper{
+				Reader:   fake.NewClientBuilder().WithScheme(scheme).WithObjects(tt.tofan...).Build(),
+				Dynamic:  dynamic,
+				Mapper:   mapper,
+				Recorder: record.NewFakeRecorder(10),
+				Log:      logr.Discard(),
+			}
+			deleted, err := s.Sweep(context.Background())
+			if err != nil {
+				t.Fatalf("Sweep() error = %v", err)
+			}
+			if deleted != tt.deleted {
+				t.Errorf("Sweep() deleted %d objects, want %d", deleted, tt.deleted)
+			}
+
+			list, err := dynamic.Resource(widgetGVR).Namespace("default").List(context.Background(), metav1.ListOptions{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			var kept []string
+			for _, item := range list.Items {
+				kept = append(kept, item.GetName())
+			}
+			sort.Strings(kept)
+			if len(kept) != len(tt.kept) {
+				t.Fatalf("objects left = %v, want %v", kept, tt.kept)
+			}
+			for i := range kept {
+				if kept[i] != tt.kept[i] {
+					t.Errorf("objects left = %v, want %v", kept, tt.kept)
+				}
+			}
+		})
+	}
+}
+
+func TestSweepSkipsForbiddenTypes(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := tofaniov1alpha1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	template := &tofaniov1alpha1.ObjectTemplate{
+		ObjectMeta: metav1.ObjectMeta{Name: "widget", Namespace: "default"},
+		Status:     tofaniov1alpha1.ObjectTemplateStatus{Group: widgetGVK.Group, Version: widgetGVK.Version, Kind: widgetGVK.Kind},
+	}
+	mapper := meta.NewDefaultRESTMapper(nil)
+	mapper.Add(widgetGVK, meta.RESTScopeNamespace)
+	dynamic := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+		map[schema.GroupVersionResource]string{widgetGVR: "WidgetList"})
+	dynamic.PrependReactor("list", "widgets", func(clienttesting.Action) (bool, runtime.Object, error) {
+		return true, nil, apierrors.NewForbidden(widgetGVR.GroupResource(), "", errors.New("no aggregated role"))
+	})
+
+	s := &Sweeper{
+		Reader:   fake.NewClientBuilder().WithScheme(scheme).WithObjects(template).Build(),
+		Dynamic:  dynamic,
+		Mapper:   mapper,
+		Recorder: record.NewFakeRecorder(10),
+		Log:      logr.Discard(),
+	}
+	if deleted, err := s.Sweep(context.Background()); deleted != 0 || err != nil {
+		t.Errorf("Sweep() = %d, %v, want nothing deleted and no error", deleted, err)
+	}
+}
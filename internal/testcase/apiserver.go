@@ -0,0 +1,379 @@
+package testcase
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	tofaniov1alpha1 "github.com/invioteq/tofan/api/v1alpha1"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/expfmt"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/discovery"
+	"math"
+	"sort"
+	"time"
+)
+
+const (
+	apiServerRequestsMetric        = "apiserver_request_total"
+	apiServerRequestDurationMetric = "apiserver_request_duration_seconds"
+	apiServerStorageObjectsMetric  = "apiserver_storage_objects"
+	// apiServerResourceObjectsMetric replaces apiServerStorageObjectsMetric in recent API servers
+	apiServerResourceObjectsMetric = "apiserver_resource_objects"
+	apiServerRejectedMetric        = "apiserver_flowcontrol_rejected_requests_total"
+	etcdRequestDurationMetric      = "etcd_request_duration_seconds"
+
+	// topAPIServerRows is the number of request counts and latencies of the API server listed in a report.
+	topAPIServerRows = 15
+)
+
+// apiServerMetrics are the metric families of the API server kept from a scrape.
+var apiServerMetrics = []string{
+	apiServerRequestsMetric,
+	apiServerRequestDurationMetric,
+	apiServerStorageObjectsMetric,
+	apiServerResourceObjectsMetric,
+	apiServerRejectedMetric,
+	etcdRequestDurationMetric,
+}
+
+// apiServerScrape holds the metric families of the API server read by a scrape.
+type apiServerScrape struct {
+	at       time.Time
+	families map[string]*dto.MetricFamily
+}
+
+// scrapeAPIServer reads the metrics of the API server the REST config of the Reconciler points to.
+func (r *Reconciler) scrapeAPIServer(ctx context.Context) (*apiServerScrape, error) {
+	if r.Config == nil {
+		return nil, errors.New("no REST config to reach the API server with")
+	}
+	discoveryClient, err := discovery.NewDiscoveryClientForConfig(r.Config)
+	if err != nil {
+		return nil, err
+	}
+	data, err := discoveryClient.RESTClient().Get().AbsPath("/metrics").DoRaw(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var parser expfmt.TextParser
+	families, err := parser.TextToMetricFamilies(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	scrape := &apiServerScrape{at: time.Now(), families: map[string]*dto.MetricFamily{}}
+	for _, name := range apiServerMetrics {
+		if family, ok := families[name]; ok {
+			scrape.families[name] = family
+		}
+	}
+	return scrape, nil
+}
+
+// scrapeAPIServerBaseline scrapes the metrics of the API server the run of the TestCase is compared
+// against, unless the run has a baseline already.
+func (r *Reconciler) scrapeAPIServerBaseline(ctx context.Context, testCase *tofaniov1alpha1.TestCase) {
+	if !testCase.Spec.APIServerMetrics || r.measurements.apiServerBaseline(testCase) != nil {
+		return
+	}
+	scrape, err := r.scrapeAPIServer(ctx)
+	if err != nil {
+		r.Log.Error(err, "Failed to scrape API server metrics", "TestCase", testCase.Name)
+		return
+	}
+	r.measurements.setAPIServerBaseline(testCase, scrape)
+}
+
+// addAPIServer scrapes the metrics of the API server again and adds their change since the baseline of
+// the run to its report.
+func (r *Reconciler) addAPIServer(ctx context.Context, rep *tofaniov1alpha1.Report, testCase *tofaniov1alpha1.TestCase, objTpl *tofaniov1alpha1.ObjectTemplate) {
+	if !testCase.Spec.APIServerMetrics {
+		return
+	}
+	before := r.measurements.apiServerBaseline(testCase)
+	if before == nil {
+		return
+	}
+	after, err := r.scrapeAPIServer(ctx)
+	if err != nil {
+		r.Log.Error(err, "Failed to scrape API server metrics", "TestCase", testCase.Name)
+		return
+	}
+
+	var resource string
+	if objTpl != nil {
+		if mapping, err := r.templateMapping(objTpl); err == nil {
+			resource = qualifiedResource(mapping.Resource.Group, mapping.Resource.Resource)
+		}
+	}
+	rep.Spec.APIServer = diffAPIServer(before, after, resource)
+}
+
+// apiServerBaseline returns the metrics of the API server scraped when the current run of the TestCase
+// started, or nil.
+func (g *measurementRegistry) apiServerBaseline(testCase *tofaniov1alpha1.TestCase) *apiServerScrape {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.forRun(testCase).apiServer
+}
+
+// setAPIServerBaseline records the metrics of the API server the current run of the TestCase is compared against.
+func (g *measurementRegistry) setAPIServerBaseline(testCase *tofaniov1alpha1.TestCase, scrape *apiServerScrape) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.forRun(testCase).apiServer = scrape
+}
+
+// diffAPIServer computes the change of the metrics of the API server between two scrapes, resource is the
+// resource of the run the stored objects are counted for.
+func diffAPIServer(before, after *apiServerScrape, resource string) *tofaniov1alpha1.APIServerStats {
+	stats := &tofaniov1alpha1.APIServerStats{Interval: metav1.Duration{Duration: after.at.Sub(before.at)}}
+
+	beforeRequests := requestCounts(before.families[apiServerRequestsMetric])
+	for key, value := range requestCounts(after.families[apiServerRequestsMetric]) {
+		if delta := int64(value - beforeRequests[key]); delta > 0 {
+			key.Count = delta
+			stats.Requests += delta
+			stats.RequestCounts = append(stats.RequestCounts, key)
+		}
+	}
+	sort.Slice(stats.RequestCounts, func(i, j int) bool {
+		a, b := stats.RequestCounts[i], stats.RequestCounts[j]
+		if a.Count != b.Count {
+			return a.Count > b.Count
+		}
+		return a.Verb+"/"+a.Resource+"/"+a.Subresource+"/"+a.Code+"/"+a.Component < b.Verb+"/"+b.Resource+"/"+b.Subresource+"/"+b.Code+"/"+b.Component
+	})
+	if len(stats.RequestCounts) > topAPIServerRows {
+		stats.RequestCounts = stats.RequestCounts[:topAPIServerRows]
+	}
+
+	stats.Latencies = diffLatencies(before.families[apiServerRequestDurationMetric], after.families[apiServerRequestDurationMetric], requestLatencyKey)
+	if len(stats.Latencies) > topAPIServerRows {
+		stats.Latencies = stats.Latencies[:topAPIServerRows]
+	}
+	stats.StorageLatencies = diffLatencies(before.families[etcdRequestDurationMetric], after.families[etcdRequestDurationMetric], storageLatencyKey)
+	if len(stats.StorageLatencies) > topAPIServerRows {
+		stats.StorageLatencies = stats.StorageLatencies[:topAPIServerRows]
+	}
+
+	if resource != "" {
+		if objects, ok := storedObjects(after, resource); ok {
+			stats.StoredObjects = &tofaniov1alpha1.StoredObjects{Resource: resource, After: objects}
+			stats.StoredObjects.Before, _ = storedObjects(before, resource)
+		}
+	}
+
+	beforeRejected := rejectionCounts(before.families[apiServerRejectedMetric])
+	for key, value := range rejectionCounts(after.families[apiServerRejectedMetric]) {
+		if delta := int64(value - beforeRejected[key]); delta > 0 {
+			key.Count = delta
+			stats.Rejected += delta
+			stats.Rejections = append(stats.Rejections, key)
+		}
+	}
+	sort.Slice(stats.Rejections, func(i, j int) bool {
+		a, b := stats.Rejections[i], stats.Rejections[j]
+		if a.Count != b.Count {
+			return a.Count > b.Count
+		}
+		return a.PriorityLevel+"/"+a.FlowSchema+"/"+a.Reason < b.PriorityLevel+"/"+b.FlowSchema+"/"+b.Reason
+	})
+	return stats
+}
+
+// latencyKey groups the request latencies of the API server.
+type latencyKey struct {
+	verb     string
+	resource string
+}
+
+// requestLatencyKey groups the requests served by the API server by verb and resource.
+func requestLatencyKey(m *dto.Metric) latencyKey {
+	return latencyKey{verb: label(m, "verb"), resource: qualifiedResource(label(m, "group"), label(m, "resource"))}
+}
+
+// storageLatencyKey groups the requests of the API server to etcd by operation and object type.
+func storageLatencyKey(m *dto.Metric) latencyKey {
+	return latencyKey{verb: label(m, "operation"), resource: label(m, "type")}
+}
+
+// histogram is the sum of the histograms of the series of a latencyKey.
+type histogram struct {
+	count   float64
+	sum     float64
+	buckets map[float64]float64
+}
+
+// diffLatencies summarizes the requests made between two scrapes of a request duration histogram,
+// grouped by keyOf, the most frequent first.
+func diffLatencies(before, after *dto.MetricFamily, keyOf func(*dto.Metric) latencyKey) []tofaniov1alpha1.APIServerLatency {
+	previous := histogramsBy(before, keyOf)
+	var latencies []tofaniov1alpha1.APIServerLatency
+	for key, current := range histogramsBy(after, keyOf) {
+		delta := histogram{buckets: map[float64]float64{}}
+		base := previous[key]
+		if base == nil {
+			base = &histogram{}
+		}
+		delta.count, delta.sum = current.count-base.count, current.sum-base.sum
+		if delta.count <= 0 {
+			continue
+		}
+		valid := true
+		for bound, count := range current.buckets {
+			if delta.buckets[bound] = count - base.buckets[bound]; delta.buckets[bound] < 0 {
+				valid = false
+			}
+		}
+		if !valid {
+			continue
+		}
+
+		latencies = append(latencies, tofaniov1alpha1.APIServerLatency{
+			Verb:     key.verb,
+			Resource: key.resource,
+			Count:    int64(delta.count),
+			Mean:     seconds(delta.sum / delta.count),
+			P50:      seconds(delta.quantile(0.5)),
+			P90:      seconds(delta.quantile(0.9)),
+			P99:      seconds(delta.quantile(0.99)),
+		})
+	}
+	sort.Slice(latencies, func(i, j int) bool {
+		if latencies[i].Count != latencies[j].Count {
+			return latencies[i].Count > latencies[j].Count
+		}
+		return latencies[i].Verb+"/"+latencies[i].Resource < latencies[j].Verb+"/"+latencies[j].Resource
+	})
+	return latencies
+}
+
+// histogramsBy sums the histograms of the series of the family by their key.
+func histogramsBy(family *dto.MetricFamily, keyOf func(*dto.Metric) latencyKey) map[latencyKey]*histogram {
+	histograms := map[latencyKey]*histogram{}
+	if family == nil {
+		return histograms
+	}
+	for _, m := range family.GetMetric() {
+		key := keyOf(m)
+		h, ok := histograms[key]
+		if !ok {
+			h = &histogram{buckets: map[float64]float64{}}
+			histograms[key] = h
+		}
+		h.count += float64(m.GetHistogram().GetSampleCount())
+		h.sum += m.GetHistogram().GetSampleSum()
+		for _, bucket := range m.GetHistogram().GetBucket() {
+			h.buckets[bucket.GetUpperBound()] += float64(bucket.GetCumulativeCount())
+		}
+	}
+	return histograms
+}
+
+// quantile estimates the q-quantile of the histogram the way Prometheus does, interpolating linearly
+// within the bucket it falls in. A quantile above the highest finite bucket is that bucket's bound.
+func (h *histogram) quantile(q float64) float64 {
+	bounds := make([]float64, 0, len(h.buckets))
+	for bound := range h.buckets {
+		if !math.IsInf(bound, 1) {
+			bounds = append(bounds, bound)
+		}
+	}
+	sort.Float64s(bounds)
+
+	rank := q * h.count
+	lower, lowerCount := 0.0, 0.0
+	for _, bound := range bounds {
+		count := h.buckets[bound]
+		if count >= rank {
+			if count == lowerCount {
+				return bound
+			}
+			return lower + (bound-lower)*(rank-lowerCount)/(count-lowerCount)
+		}
+		lower, lowerCount = bound, count
+	}
+	return lower
+}
+
+// storedObjects returns the number of objects of the resource the API server stores.
+func storedObjects(scrape *apiServerScrape, resource string) (int64, bool) {
+	for _, m := range scrape.families[apiServerResourceObjectsMetric].GetMetric() {
+		if qualifiedResource(label(m, "group"), label(m, "resource")) == resource {
+			return int64(value(m)), true
+		}
+	}
+	for _, m := range scrape.families[apiServerStorageObjectsMetric].GetMetric() {
+		if label(m, "resource") == resource {
+			return int64(value(m)), true
+		}
+	}
+	return 0, false
+}
+
+// requestCounts sums the request counters of the API server by verb, resource, code and component.
+func requestCounts(family *dto.MetricFamily) map[tofaniov1alpha1.APIServerRequests]float64 {
+	counts := map[tofaniov1alpha1.APIServerRequests]float64{}
+	for _, m := range family.GetMetric() {
+		key := tofaniov1alpha1.APIServerRequests{
+			Verb:        label(m, "verb"),
+			Resource:    qualifiedResource(label(m, "group"), label(m, "resource")),
+			Subresource: label(m, "subresource"),
+			Code:        label(m, "code"),
+			Component:   label(m, "component"),
+		}
+		counts[key] += value(m)
+	}
+	return counts
+}
+
+// rejectionCounts sums the rejection counters of API Priority and Fairness by priority level, flow
+// schema and reason.
+func rejectionCounts(family *dto.MetricFamily) map[tofaniov1alpha1.APFRejections]float64 {
+	counts := map[tofaniov1alpha1.APFRejections]float64{}
+	for _, m := range family.GetMetric() {
+		key := tofaniov1alpha1.APFRejections{
+			PriorityLevel: label(m, "priority_level"),
+			FlowSchema:    label(m, "flow_schema"),
+			Reason:        label(m, "reason"),
+		}
+		counts[key] += value(m)
+	}
+	return counts
+}
+
+// value returns the value of a counter, gauge or untyped series.
+func value(m *dto.Metric) float64 {
+	switch {
+	case m.GetCounter() != nil:
+		return m.GetCounter().GetValue()
+	case m.GetGauge() != nil:
+		return m.GetGauge().GetValue()
+	default:
+		return m.GetUntyped().GetValue()
+	}
+}
+
+func label(m *dto.Metric, name string) string {
+	for _, pair := range m.GetLabel() {
+		if pair.GetName() == name {
+			return pair.GetValue()
+		}
+	}
+	return ""
+}
+
+// qualifiedResource returns the resource qualified by its group, as the API server names it in its
+// storage metrics.
+func qualifiedResource(group, resource string) string {
+	if group == "" {
+		return resource
+	}
+	return resource + "." + group
+}
+
+func seconds(s float64) metav1.Duration {
+	return metav1.Duration{Duration: time.Duration(s * float64(time.Second))}
+}
@@ -0,0 +1,172 @@
+package testcase
+
+import (
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	tofaniov1alpha1 "github.com/invioteq/tofan/api/v1alpha1"
+	"github.com/prometheus/common/expfmt"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// parseScrape parses metrics in the text exposition format into a scrape taken at the given time.
+func parseScrape(t *testing.T, at time.Time, text string) *apiServerScrape {
+	t.Helper()
+	var parser expfmt.TextParser
+	families, err := parser.TextToMetricFamilies(strings.NewReader(text))
+	if err != nil {
+		t.Fatalf("invalid metrics: %v", err)
+	}
+	return &apiServerScrape{at: at, families: families}
+}
+
+func TestHistogramQuantile(t *testing.T) {
+	h := &histogram{count: 100, buckets: map[float64]float64{
+		0.1:         50,
+		0.5:         90,
+		1:           100,
+		math.Inf(1): 100,
+	}}
+	tests := []struct {
+		q    float64
+		want float64
+	}{
+		{0, 0},
+		{0.25, 0.05},
+		{0.5, 0.1},
+		{0.7, 0.3},
+		{0.95, 0.75},
+		{1, 1},
+	}
+	for _, tt := range tests {
+		if got := h.quantile(tt.q); math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("quantile(%v) = %v, want %v", tt.q, got, tt.want)
+		}
+	}
+
+	// Requests slower than the highest finite bucket are capped at its bound
+	slow := &histogram{count: 10, buckets: map[float64]float64{1: 5, math.Inf(1): 10}}
+	if got := slow.quantile(0.99); got != 1 {
+		t.Errorf("quantile(0.99) above the buckets = %v, want 1", got)
+	}
+}
+
+func TestDiffAPIServer(t *testing.T) {
+	start := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	before := parseScrape(t, start, `
+# TYPE apiserver_request_total counter
+apiserver_request_total{verb="POST",group="simulator.tofan.io",resource="widgets",subresource="",code="201",component="apiserver"} 10
+apiserver_request_total{verb="GET",group="",resource="pods",subresource="",code="200",component="apiserver"} 100
+# TYPE apiserver_request_duration_seconds histogram
+apiserver_request_duration_seconds_bucket{verb="POST",group="simulator.tofan.io",resource="widgets",le="0.1"} 10
+apiserver_request_duration_seconds_bucket{verb="POST",group="simulator.tofan.io",resource="widgets",le="1"} 10
+apiserver_request_duration_seconds_bucket{verb="POST",group="simulator.tofan.io",resource="widgets",le="+Inf"} 10
+apiserver_request_duration_seconds_sum{verb="POST",group="simulator.tofan.io",resource="widgets"} 0.5
+apiserver_request_duration_seconds_count{verb="POST",group="simulator.tofan.io",resource="widgets"} 10
+# TYPE apiserver_resource_objects gauge
+apiserver_resource_objects{group="simulator.tofan.io",resource="widgets"} 3
+# TYPE apiserver_flowcontrol_rejected_requests_total counter
+apiserver_flowcontrol_rejected_requests_total{priority_level="workload-low",flow_schema="service-accounts",reason="queue-full"} 1
+# TYPE etcd_request_duration_seconds histogram
+etcd_request_duration_seconds_bucket{operation="create",type="/registry/simulator.tofan.io/widgets",le="0.01"} 0
+etcd_request_duration_seconds_bucket{operation="create",type="/registry/simulator.tofan.io/widgets",le="+Inf"} 0
+etcd_request_duration_seconds_sum{operation="create",type="/registry/simulator.tofan.io/widgets"} 0
+etcd_request_duration_seconds_count{operation="create",type="/registry/simulator.tofan.io/widgets"} 0
+`)
+	after := parseScrape(t, start.Add(time.Minute), `
+# TYPE apiserver_request_total counter
+apiserver_request_total{verb="POST",group="simulator.tofan.io",resource="widgets",subresource="",code="201",component="apiserver"} 100
+apiserver_request_total{verb="POST",group="simulator.tofan.io",resource="widgets",subresource="",code="429",component="apiserver"} 10
+apiserver_request_total{verb="GET",group="",resource="pods",subresource="",code="200",component="apiserver"} 100
+apiserver_request_total{verb="PUT",group="simulator.tofan.io",resource="widgets",subresource="status",code="200",component="apiserver"} 40
+# TYPE apiserver_request_duration_seconds histogram
+apiserver_request_duration_seconds_bucket{verb="POST",group="simulator.tofan.io",resource="widgets",le="0.1"} 60
+apiserver_request_duration_seconds_bucket{verb="POST",group="simulator.tofan.io",resource="widgets",le="1"} 110
+apiserver_request_duration_seconds_bucket{verb="POST",group="simulator.tofan.io",resource="widgets",le="+Inf"} 110
+apiserver_request_duration_seconds_sum{verb="POST",group="simulator.tofan.io",resource="widgets"} 20.5
+apiserver_request_duration_seconds_count{verb="POST",group="simulator.tofan.io",resource="widgets"} 110
+# TYPE apiserver_resource_objects gauge
+apiserver_resource_objects{group="simulator.tofan.io",resource="widgets"} 103
+# TYPE apiserver_flowcontrol_rejected_requests_total counter
+apiserver_flowcontrol_rejected_requests_total{priority_level="workload-low",flow_schema="service-accounts",reason="queue-full"} 6
+apiserver_flowcontrol_rejected_requests_total{priority_level="workload-low",flow_schema="service-accounts",reason="time-out"} 2
+# TYPE etcd_request_duration_seconds histogram
+etcd_request_duration_seconds_bucket{operation="create",type="/registry/simulator.tofan.io/widgets",le="0.01"} 90
+etcd_request_duration_seconds_bucket{operation="create",type="/registry/simulator.tofan.io/widgets",le="+Inf"} 90
+etcd_request_duration_seconds_sum{operation="create",type="/registry/simulator.tofan.io/widgets"} 0.45
+etcd_request_duration_seconds_count{operation="create",type="/registry/simulator.tofan.io/widgets"} 90
+`)
+
+	got := diffAPIServer(before, after, "widgets.simulator.tofan.io")
+	want := &tofaniov1alpha1.APIServerStats{
+		Interval: metav1.Duration{Duration: time.Minute},
+		Requests: 140,
+		RequestCounts: []tofaniov1alpha1.APIServerRequests{
+			{Verb: "POST", Resource: "widgets.simulator.tofan.io", Code: "201", Component: "apiserver", Count: 90},
+			{Verb: "PUT", Resource: "widgets.simulator.tofan.io", Subresource: "status", Code: "200", Component: "apiserver", Count: 40},
+			{Verb: "POST", Resource: "widgets.simulator.tofan.io", Code: "429", Component: "apiserver", Count: 10},
+		},
+		Latencies: []tofaniov1alpha1.APIServerLatency{{
+			Verb:     "POST",
+			Resource: "widgets.simulator.tofan.io",
+			Count:    100,
+			Mean:     seconds(0.2),
+			P50:      seconds(0.1),
+			P90:      seconds(0.82),
+			P99:      seconds(0.982),
+		}},
+		StorageLatencies: []tofaniov1alpha1.APIServerLatency{{
+			Verb:     "create",
+			Resource: "/registry/simulator.tofan.io/widgets",
+			Count:    90,
+			Mean:     seconds(0.005),
+			P50:      seconds(0.005),
+			P90:      seconds(0.009),
+			P99:      seconds(0.0099),
+		}},
+		StoredObjects: &tofaniov1alpha1.StoredObjects{Resource: "widgets.simulator.tofan.io", Before: 3, After: 103},
+		Rejected:      7,
+		Rejections: []tofaniov1alpha1.APFRejections{
+			{PriorityLevel: "workload-low", FlowSchema: "service-accounts", Reason: "queue-full", Count: 5},
+			{PriorityLevel: "workload-low", FlowSchema: "service-accounts", Reason: "time-out", Count: 2},
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("diffAPIServer() = %+v, want %+v", got, want)
+	}
+}
+
+func TestStoredObjects(t *testing.T) {
+	tests := []struct {
+		name    string
+		metrics string
+		want    int64
+		found   bool
+	}{
+		{
+			name:    "resource objects",
+			metrics: "# TYPE apiserver_resource_objects gauge\napiserver_resource_objects{group=\"apps\",resource=\"deployments\"} 7\n",
+			want:    7, found: true,
+		},
+		{
+			name:    "storage objects of older API servers",
+			metrics: "# TYPE apiserver_storage_objects gauge\napiserver_storage_objects{resource=\"deployments.apps\"} 5\n",
+			want:    5, found: true,
+		},
+		{
+			name:    "other resource",
+			metrics: "# TYPE apiserver_resource_objects gauge\napiserver_resource_objects{group=\"\",resource=\"pods\"} 7\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, found := storedObjects(parseScrape(t, time.Now(), tt.metrics), "deployments.apps")
+			if got != tt.want || found != tt.found {
+				t.Errorf("storedObjects() = %d, %t, want %d, %t", got, found, tt.want, tt.found)
+			}
+		})
+	}
+}
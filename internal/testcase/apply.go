@@ -0,0 +1,135 @@
+package testcase
+
+import (
+	"context"
+	"encoding/json"
+	tofaniov1alpha1 "github.com/invioteq/tofan/api/v1alpha1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sort"
+	"strings"
+	"time"
+)
+
+// FieldManager is the field manager tofan writes objects as.
+const FieldManager = "tofan"
+
+// writeObject writes the object to the API server with the given strategy, Create when it is empty.
+func (r *Reconciler) writeObject(ctx context.Context, c client.Client, obj *unstructured.Unstructured, strategy tofaniov1alpha1.ApplyStrategy) error {
+	switch strategy {
+	case tofaniov1alpha1.ApplyCreateOrUpdate:
+		return r.createOrUpdate(ctx, c, obj)
+	case tofaniov1alpha1.ApplyServerSideApply:
+		return r.sendRequest("apply", obj, func() error {
+			return c.Patch(ctx, obj, client.Apply, client.FieldOwner(FieldManager), client.ForceOwnership)
+		})
+	case tofaniov1alpha1.ApplyMergePatch:
+		data, err := json.Marshal(obj.Object)
+		if err != nil {
+			return err
+		}
+		return r.createOrPatch(ctx, c, obj, client.RawPatch(types.MergePatchType, data))
+	case tofaniov1alpha1.ApplyJSONPatch:
+		data, err := json.Marshal(jsonPatchOf(obj))
+		if err != nil {
+			return err
+		}
+		return r.createOrPatch(ctx, c, obj, client.RawPatch(types.JSONPatchType, data))
+	default:
+		return r.create(ctx, c, obj)
+	}
+}
+
+// create creates the object.
+func (r *Reconciler) create(ctx context.Context, c client.Client, obj *unstructured.Unstructured) error {
+	return r.sendRequest("create", obj, func() error {
+		return c.Create(ctx, obj, client.FieldOwner(FieldManager))
+	})
+}
+
+// createOrUpdate gets the object, then creates it or updates the existing one.
+func (r *Reconciler) createOrUpdate(ctx context.Context, c client.Client, obj *unstructured.Unstructured) error {
+	existing := &unstructured.Unstructured{}
+	existing.SetGroupVersionKind(obj.GroupVersionKind())
+	err := c.Get(ctx, client.ObjectKeyFromObject(obj), existing)
+	if apierrors.IsNotFound(err) {
+		return r.create(ctx, c, obj)
+	}
+	if err != nil {
+		r.Log.Error(err, "Failed to get existing resource")
+		return err
+	}
+
+	obj.SetResourceVersion(existing.GetResourceVersion())
+	return r.sendRequest("update", obj, func() error {
+		return c.Update(ctx, obj, client.FieldOwner(FieldManager))
+	})
+}
+
+// createOrPatch creates the object, and patches it when it exists already. The names of the instances
+// are unique to their run, so only instances created before the run was interrupted, or by an attempt
+// whose response was lost, are patched. Every other instance takes a single request.
+func (r *Reconciler) createOrPatch(ctx context.Context, c client.Client, obj *unstructured.Unstructured, patch client.Patch) error {
+	// The create fills the object in with the response of the API server, keep the rendered one for the patch
+	rendered := obj.DeepCopy()
+	err := r.create(ctx, c, obj)
+	if !apierrors.IsAlreadyExists(err) {
+		return err
+	}
+
+	rendered.DeepCopyInto(obj)
+	return r.sendRequest("patch", obj, func() error {
+		return c.Patch(ctx, obj, patch, client.FieldOwner(FieldManager))
+	})
+}
+
+// sendRequest sends a write request for the object and records its latency.
+func (r *Reconciler) sendRequest(verb string, obj *unstructured.Unstructured, request func() error) error {
+	start := time.Now()
+	err := request()
+	r.observeRequest(verb, obj.GroupVersionKind(), start)
+	if err != nil {
+		r.Log.Error(err, "Failed to "+verb+" resource", "GVK", obj.GroupVersionKind(), "Name", obj.GetName())
+		return err
+	}
+	r.Log.Info("Successfully sent "+verb+" request", "GVK", obj.GroupVersionKind(), "Name", obj.GetName())
+	return nil
+}
+
+// jsonPatchOperation is an operation of a JSON patch.
+type jsonPatchOperation struct {
+	Op    string      `json:"op"`
+	Path  string      `json:"path"`
+	Value interface{} `json:"value"`
+}
+
+// jsonPatchOf returns a JSON patch setting the labels, annotations and owner references of the
+// object along with its top level fields. An add operation replaces the value found at its path.
+func jsonPatchOf(obj *unstructured.Unstructured) []jsonPatchOperation {
+	var ops []jsonPatchOperation
+	metadata, _ := obj.Object["metadata"].(map[string]interface{})
+	for _, field := range []string{"labels", "annotations", "ownerReferences"} {
+		if value, ok := metadata[field]; ok {
+			ops = append(ops, jsonPatchOperation{Op: "add", Path: "/metadata/" + field, Value: value})
+		}
+	}
+
+	fields := make([]string, 0, len(obj.Object))
+	for field := range obj.Object {
+		if field != "apiVersion" && field != "kind" && field != "metadata" && field != "status" {
+			fields = append(fields, field)
+		}
+	}
+	sort.Strings(fields)
+	for _, field := range fields {
+		ops = append(ops, jsonPatchOperation{Op: "add", Path: "/" + escapeJSONPointer(field), Value: obj.Object[field]})
+	}
+	return ops
+}
+
+// escapeJSONPointer escapes a reference token of a JSON pointer.
+func escapeJSONPointer(token string) string {
+	return strings.NewReplacer("~", "~0", "/", "~1").Replace(token)
+}
@@ -0,0 +1,172 @@
+package testcase
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/go-logr/logr"
+	tofaniov1alpha1 "github.com/invioteq/tofan/api/v1alpha1"
+	"github.com/invioteq/tofan/internal/common"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestJSONPatchOf(t *testing.T) {
+	tests := []struct {
+		name string
+		obj  map[string]interface{}
+		want []jsonPatchOperation
+	}{
+		{
+			name: "identity and status are left out",
+			obj: map[string]interface{}{
+				"apiVersion": "v1",
+				"kind":       "ConfigMap",
+				"metadata":   map[string]interface{}{"name": "cm-1", "namespace": "default"},
+				"status":     map[string]interface{}{"ready": true},
+			},
+		},
+		{
+			name: "metadata fields come first, then the sorted top level fields",
+			obj: map[string]interface{}{
+				"apiVersion": "v1",
+				"kind":       "ConfigMap",
+				"metadata": map[string]interface{}{
+					"name":        "cm-1",
+					"labels":      map[string]interface{}{"app": "tofan"},
+					"annotations": map[string]interface{}{"note": "x"},
+				},
+				"data":       map[string]interface{}{"key": "value"},
+				"binaryData": map[string]interface{}{},
+			},
+			want: []jsonPatchOperation{
+				{Op: "add", Path: "/metadata/labels", Value: map[string]interface{}{"app": "tofan"}},
+				{Op: "add", Path: "/metadata/annotations", Value: map[string]interface{}{"note": "x"}},
+				{Op: "add", Path: "/binaryData", Value: map[string]interface{}{}},
+				{Op: "add", Path: "/data", Value: map[string]interface{}{"key": "value"}},
+			},
+		},
+		{
+			name: "field names are escaped",
+			obj: map[string]interface{}{
+				"metadata": map[string]interface{}{"name": "w-1"},
+				"a/b~c":    "value",
+			},
+			want: []jsonPatchOperation{{Op: "add", Path: "/a~1b~0c", Value: "value"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := jsonPatchOf(&unstructured.Unstructured{Object: tt.obj})
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("jsonPatchOf() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEscapeJSONPointer(t *testing.T) {
+	tests := []struct {
+		token string
+		want  string
+	}{
+		{"spec", "spec"},
+		{"a/b", "a~1b"},
+		{"a~b", "a~0b"},
+		{"~/", "~0~1"},
+		{"~1", "~01"},
+	}
+	for _, tt := range tests {
+		if got := escapeJSONPointer(tt.token); got != tt.want {
+			t.Errorf("escapeJSONPointer(%q) = %q, want %q", tt.token, got, tt.want)
+		}
+	}
+}
+
+// countingClient counts the requests sent through it by verb.
+type countingClient struct {
+	client.Client
+	requests map[string]int
+}
+
+func (c *countingClient) Get(ctx context.Context, key client.ObjectKey, obj client.Object, opts ...client.GetOption) error {
+	c.requests["get"]++
+	return c.Client.Get(ctx, key, obj, opts...)
+}
+
+func (c *countingClient) Create(ctx context.Context, obj client.Object, opts ...client.CreateOption) error {
+	c.requests["create"]++
+	return c.Client.Create(ctx, obj, opts...)
+}
+
+func (c *countingClient) Update(ctx context.Context, obj client.Object, opts ...client.UpdateOption) error {
+	c.requests["update"]++
+	return c.Client.Update(ctx, obj, opts...)
+}
+
+func (c *countingClient) Patch(ctx context.Context, obj client.Object, patch client.Patch, opts ...client.PatchOption) error {
+	c.requests["patch"]++
+	return c.Client.Patch(ctx, obj, patch, opts...)
+}
+
+func TestWriteObject(t *testing.T) {
+	configMap := func(value string) *unstructured.Unstructured {
+		obj := &unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": "v1",
+			"kind":       "ConfigMap",
+			"metadata":   map[string]interface{}{"name": "cm-run-0", "namespace": "default"},
+			"data":       map[string]interface{}{"key": value},
+		}}
+		return obj
+	}
+
+	tests := []struct {
+		strategy tofaniov1alpha1.ApplyStrategy
+		exists   bool
+		want     map[string]int
+	}{
+		{strategy: "", want: map[string]int{"create": 1}},
+		{strategy: tofaniov1alpha1.ApplyCreate, want: map[string]int{"create": 1}},
+		{strategy: tofaniov1alpha1.ApplyCreateOrUpdate, want: map[string]int{"get": 1, "create": 1}},
+		{strategy: tofaniov1alpha1.ApplyCreateOrUpdate, exists: true, want: map[string]int{"get": 1, "update": 1}},
+		{strategy: tofaniov1alpha1.ApplyMergePatch, want: map[string]int{"create": 1}},
+		{strategy: tofaniov1alpha1.ApplyMergePatch, exists: true, want: map[string]int{"create": 1, "patch": 1}},
+		{strategy: tofaniov1alpha1.ApplyJSONPatch, want: map[string]int{"create": 1}},
+		{strategy: tofaniov1alpha1.ApplyJSONPatch, exists: true, want: map[string]int{"create": 1, "patch": 1}},
+	}
+	for _, tt := range tests {
+		name := string(tt.strategy)
+		if name == "" {
+			name = "default"
+		}
+		if tt.exists {
+			name += " existing"
+		}
+		t.Run(name, func(t *testing.T) {
+			builder := fake.NewClientBuilder().WithScheme(clientgoscheme.Scheme)
+			if tt.exists {
+				builder = builder.WithObjects(configMap("old"))
+			}
+			c := &countingClient{Client: builder.Build(), requests: map[string]int{}}
+			r := &Reconciler{Reconciler: common.Reconciler{Log: logr.Discard()}}
+
+			if err := r.writeObject(context.Background(), c, configMap("new"), tt.strategy); err != nil {
+				t.Fatalf("writeObject() error = %v", err)
+			}
+			if !reflect.DeepEqual(c.requests, tt.want) {
+				t.Errorf("writeObject() sent %v, want %v", c.requests, tt.want)
+			}
+
+			written := configMap("")
+			if err := c.Client.Get(context.Background(), client.ObjectKeyFromObject(written), written); err != nil {
+				t.Fatal(err)
+			}
+			if value, _, _ := unstructured.NestedString(written.Object, "data", "key"); value != "new" {
+				t.Errorf("data.key = %q, want new", value)
+			}
+		})
+	}
+}
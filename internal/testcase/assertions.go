@@ -0,0 +1,215 @@
+package testcase
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"github.com/google/cel-go/cel"
+	tofaniov1alpha1 "github.com/invioteq/tofan/api/v1alpha1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/util/jsonpath"
+	"strings"
+	"time"
+)
+
+const (
+	// AssertionObjects prefixes the name of the check that an ObjectAssertion held for every object.
+	AssertionObjects = "assertion/"
+
+	// maxAssertionFailures bounds the failing objects kept for an ObjectAssertion.
+	maxAssertionFailures = 5
+	// assertionsCheckInterval is how often the assertions are evaluated until they hold.
+	assertionsCheckInterval = 5 * time.Second
+	// defaultAssertionsTimeout is how long the assertions may take to hold when the TestCase sets no timeout.
+	defaultAssertionsTimeout = 5 * time.Minute
+)
+
+// objectCheck evaluates an ObjectAssertion against an object. It returns why the assertion does not
+// hold, or "" when it does.
+type objectCheck func(obj *unstructured.Unstructured) string
+
+// compileAssertion compiles the JSONPath or CEL expression of the assertion.
+func compileAssertion(assertion tofaniov1alpha1.ObjectAssertion) (objectCheck, error) {
+	switch {
+	case assertion.JSONPath != "" && assertion.CEL != "":
+		return nil, fmt.Errorf("assertion %s sets both jsonPath and cel", assertion.Name)
+	case assertion.JSONPath != "":
+		return compileJSONPath(assertion.JSONPath, assertion.Value)
+	case assertion.CEL != "":
+		return compileCEL(assertion.CEL)
+	default:
+		return nil, fmt.Errorf("assertion %s sets neither jsonPath nor cel", assertion.Name)
+	}
+}
+
+func compileJSONPath(expr string, value *string) (objectCheck, error) {
+	// Like kubectl, the braces of a single expression may be left out
+	if !strings.Contains(expr, "{") {
+		expr = "{" + expr + "}"
+	}
+	path := jsonpath.New("assertion")
+	if err := path.Parse(expr); err != nil {
+		return nil, fmt.Errorf("invalid jsonPath %s: %w", expr, err)
+	}
+
+	return func(obj *unstructured.Unstructured) string {
+		var buf bytes.Buffer
+		if err := path.Execute(&buf, obj.Object); err != nil {
+			return err.Error()
+		}
+		found := buf.String()
+		switch {
+		case value == nil && strings.TrimSpace(found) == "":
+			return fmt.Sprintf("%s is empty", expr)
+		case value != nil && found != *value:
+			return fmt.Sprintf("%s is %q, expected %q", expr, found, *value)
+		}
+		return ""
+	}, nil
+}
+
+func compileCEL(expr string) (objectCheck, error) {
+	env, err := cel.NewEnv(cel.Variable("object", cel.DynType))
+	if err != nil {
+		return nil, err
+	}
+	ast, issues := env.Compile(expr)
+	if issues.Err() != nil {
+		return nil, fmt.Errorf("invalid cel %s: %w", expr, issues.Err())
+	}
+	program, err := env.Program(ast)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cel %s: %w", expr, err)
+	}
+
+	return func(obj *unstructured.Unstructured) string {
+		out, _, err := program.Eval(map[string]interface{}{"object": obj.Object})
+		if err != nil {
+			return err.Error()
+		}
+		if result, ok := out.Value().(bool); !ok || !result {
+			return fmt.Sprintf("%s evaluated to %v", expr, out.Value())
+		}
+		return ""
+	}, nil
+}
+
+// EvaluateAssertions evaluates the assertions of the TestCase against every resource.
+func EvaluateAssertions(testCase *tofaniov1alpha1.TestCase, resources []unstructured.Unstructured) ([]tofaniov1alpha1.ObjectAssertionResult, error) {
+	var results []tofaniov1alpha1.ObjectAssertionResult
+	for _, assertion := range testCase.Spec.Assertions {
+		check, err := compileAssertion(assertion)
+		if err != nil {
+			return nil, err
+		}
+
+		result := tofaniov1alpha1.ObjectAssertionResult{Name: assertion.Name}
+		for i := range resources {
+			msg := check(&resources[i])
+			if msg == "" {
+				result.Passed++
+				continue
+			}
+			result.Failed++
+			if len(result.Failures) < maxAssertionFailures {
+				result.Failures = append(result.Failures, tofaniov1alpha1.ObjectFailure{
+					Namespace: resources[i].GetNamespace(),
+					Name:      resources[i].GetName(),
+					Message:   msg,
+				})
+			}
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// assertionsError returns the error of the assertions that did not hold for every object, or nil.
+func assertionsError(results []tofaniov1alpha1.ObjectAssertionResult) error {
+	var failed []string
+	for _, result := range results {
+		if result.Failed > 0 {
+			failed = append(failed, fmt.Sprintf("%s failed for %d of %d objects", result.Name, result.Failed, result.Passed+result.Failed))
+		}
+	}
+	if len(failed) == 0 {
+		return nil
+	}
+	return fmt.Errorf("assertions failed: %s", strings.Join(failed, "; "))
+}
+
+// CheckAssertions evaluates the assertions of the TestCase against its resources found in the cluster
+// every interval, until they hold for every resource. The results of the last evaluation are kept for
+// the report. It fails once the assertions timeout elapsed, and returns the context error when ctx is
+// done first. A TestCase without assertions passes at once.
+func (r *Reconciler) CheckAssertions(ctx context.Context, testCase *tofaniov1alpha1.TestCase, objTpl *tofaniov1alpha1.ObjectTemplate, interval time.Duration) error {
+	if len(testCase.Spec.Assertions) == 0 {
+		return nil
+	}
+	timeout := defaultAssertionsTimeout
+	if testCase.Spec.AssertionsTimeout != nil {
+		timeout = testCase.Spec.AssertionsTimeout.Duration
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	expired := false
+	for {
+		resources, err := r.listTestCaseResources(ctx, testCase, objTpl)
+		if err != nil {
+			r.Log.Error(err, "Error listing resources for assertions", "TestCase", testCase.Name)
+		} else {
+			results, evalErr := EvaluateAssertions(testCase, resources)
+			if evalErr != nil {
+				return evalErr
+			}
+			r.measurements.assertObjects(testCase, results)
+			if err = assertionsError(results); err == nil {
+				return nil
+			}
+		}
+		if expired {
+			return fmt.Errorf("%w within %s", err, timeout)
+		}
+		r.Log.Info("Assertions check result", "TestCase", testCase.Name, "Error", err.Error())
+
+		select {
+		case <-ticker.C:
+		case <-timer.C:
+			expired = true
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// addAssertions adds the results of the last evaluation of the assertions of the TestCase to its
+// report. Assertions of a run that ended before they were evaluated are reported failed.
+func addAssertions(rep *tofaniov1alpha1.Report, testCase *tofaniov1alpha1.TestCase, results []tofaniov1alpha1.ObjectAssertionResult) {
+	if results == nil {
+		for _, assertion := range testCase.Spec.Assertions {
+			rep.Spec.Assertions = append(rep.Spec.Assertions, tofaniov1alpha1.Assertion{
+				Name:    AssertionObjects + assertion.Name,
+				Message: "not evaluated, the run ended before the instances were checked",
+			})
+		}
+		return
+	}
+
+	rep.Spec.ObjectAssertions = results
+	for _, result := range results {
+		msg := fmt.Sprintf("%d of %d objects passed", result.Passed, result.Passed+result.Failed)
+		if len(result.Failures) > 0 {
+			first := result.Failures[0]
+			msg += fmt.Sprintf(", %s: %s", first.Name, first.Message)
+		}
+		rep.Spec.Assertions = append(rep.Spec.Assertions, tofaniov1alpha1.Assertion{
+			Name:    AssertionObjects + result.Name,
+			Passed:  result.Failed == 0,
+			Message: msg,
+		})
+	}
+}
@@ -0,0 +1,250 @@
+package testcase
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	tofaniov1alpha1 "github.com/invioteq/tofan/api/v1alpha1"
+	"github.com/invioteq/tofan/internal/common"
+	"github.com/invioteq/tofan/pkg/constants"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	clienttesting "k8s.io/client-go/testing"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func assertionObject(name, phase string, replicas int64) unstructured.Unstructured {
+	return unstructured.Unstructured{Object: map[string]interface{}{
+		"metadata": map[string]interface{}{"name": name, "namespace": "default"},
+		"spec":     map[string]interface{}{"replicas": replicas},
+		"status":   map[string]interface{}{"phase": phase},
+	}}
+}
+
+func TestEvaluateAssertions(t *testing.T) {
+	running := "Running"
+	objects := []unstructured.Unstructured{
+		assertionObject("w-0", "Running", 1),
+		assertionObject("w-1", "Pending", 3),
+		assertionObject("w-2", "", 1),
+	}
+
+	tests := []struct {
+		name      string
+		assertion tofaniov1alpha1.ObjectAssertion
+		want      tofaniov1alpha1.ObjectAssertionResult
+		wantErr   bool
+	}{
+		{
+			name:      "jsonPath is set",
+			assertion: tofaniov1alpha1.ObjectAssertion{Name: "phase", JSONPath: ".status.phase"},
+			want: tofaniov1alpha1.ObjectAssertionResult{Name: "phase", Passed: 2, Failed: 1, Failures: []tofaniov1alpha1.ObjectFailure{
+				{Namespace: "default", Name: "w-2", Message: "{.status.phase} is empty"},
+			}},
+		},
+		{
+			name:      "jsonPath equals a value",
+			assertion: tofaniov1alpha1.ObjectAssertion{Name: "running", JSONPath: "{.status.phase}", Value: &running},
+			want: tofaniov1alpha1.ObjectAssertionResult{Name: "running", Passed: 1, Failed: 2, Failures: []tofaniov1alpha1.ObjectFailure{
+				{Namespace: "default", Name: "w-1", Message: `{.status.phase} is "Pending", expected "Running"`},
+				{Namespace: "default", Name: "w-2", Message: `{.status.phase} is "", expected "Running"`},
+			}},
+		},
+		{
+			name:      "cel holds",
+			assertion: tofaniov1alpha1.ObjectAssertion{Name: "replicas", CEL: "object.spec.replicas < 3"},
+			want: tofaniov1alpha1.ObjectAssertionResult{Name: "replicas", Passed: 2, Failed: 1, Failures: []tofaniov1alpha1.ObjectFailure{
+				{Namespace: "default", Name: "w-1", Message: "object.spec.replicas < 3 evaluated to false"},
+			}},
+		},
+		{
+			name:      "cel on a missing field fails the object",
+			assertion: tofaniov1alpha1.ObjectAssertion{Name: "missing", CEL: "object.spec.missing == 1"},
+			want:      tofaniov1alpha1.ObjectAssertionResult{Name: "missing", Failed: 3},
+		},
+		{
+			name:      "both expressions",
+			assertion: tofaniov1alpha1.ObjectAssertion{Name: "both", JSONPath: ".status.phase", CEL: "true"},
+			wantErr:   true,
+		},
+		{
+			name:      "no expression",
+			assertion: tofaniov1alpha1.ObjectAssertion{Name: "none"},
+			wantErr:   true,
+		},
+		{
+			name:      "invalid jsonPath",
+			assertion: tofaniov1alpha1.ObjectAssertion{Name: "bad", JSONPath: "{.status[}"},
+			wantErr:   true,
+		},
+		{
+			name:      "invalid cel",
+			assertion: tofaniov1alpha1.ObjectAssertion{Name: "bad", CEL: "object.spec.replicas <"},
+			wantErr:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testCase := &tofaniov1alpha1.TestCase{Spec: tofaniov1alpha1.TestCaseSpec{
+				Assertions: []tofaniov1alpha1.ObjectAssertion{tt.assertion},
+			}}
+			got, err := EvaluateAssertions(testCase, objects)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("EvaluateAssertions() = %+v, want an error", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("EvaluateAssertions() error = %v", err)
+			}
+			if len(got) != 1 {
+				t.Fatalf("EvaluateAssertions() returned %d results, want 1", len(got))
+			}
+			// The messages of CEL evaluation errors are left to cel-go
+			if tt.want.Failures == nil {
+				got[0].Failures = nil
+			}
+			if !reflect.DeepEqual(got[0], tt.want) {
+				t.Errorf("EvaluateAssertions() = %+v, want %+v", got[0], tt.want)
+			}
+		})
+	}
+}
+
+func TestEvaluateAssertionsBoundsFailures(t *testing.T) {
+	var objects []unstructured.Unstructured
+	for i := 0; i < maxAssertionFailures+3; i++ {
+		objects = append(objects, assertionObject("w", "", 1))
+	}
+	testCase := &tofaniov1alpha1.TestCase{Spec: tofaniov1alpha1.TestCaseSpec{
+		Assertions: []tofaniov1alpha1.ObjectAssertion{{Name: "phase", JSONPath: ".status.phase"}},
+	}}
+	got, err := EvaluateAssertions(testCase, objects)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got[0].Failed != len(objects) || len(got[0].Failures) != maxAssertionFailures {
+		t.Errorf("EvaluateAssertions() = %d failed with %d kept, want %d with %d kept",
+			got[0].Failed, len(got[0].Failures), len(objects), maxAssertionFailures)
+	}
+}
+
+func TestAssertionsError(t *testing.T) {
+	tests := []struct {
+		name    string
+		results []tofaniov1alpha1.ObjectAssertionResult
+		want    string
+	}{
+		{name: "no assertions"},
+		{name: "all passed", results: []tofaniov1alpha1.ObjectAssertionResult{{Name: "phase", Passed: 3}}},
+		{
+			name: "some failed",
+			results: []tofaniov1alpha1.ObjectAssertionResult{
+				{Name: "phase", Passed: 2, Failed: 1},
+				{Name: "ready", Passed: 3},
+				{Name: "replicas", Failed: 3},
+			},
+			want: "assertions failed: phase failed for 1 of 3 objects; replicas failed for 3 of 3 objects",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := assertionsError(tt.results)
+			if tt.want == "" {
+				if err != nil {
+					t.Errorf("assertionsError() = %v, want nil", err)
+				}
+				return
+			}
+			if err == nil || err.Error() != tt.want {
+				t.Errorf("assertionsError() = %v, want %s", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckAssertions(t *testing.T) {
+	gvk := schema.GroupVersionKind{Group: "simulator.tofan.io", Version: "v1alpha1", Kind: "Widget"}
+	gvr := gvk.GroupVersion().WithResource("widgets")
+	objTpl := &tofaniov1alpha1.ObjectTemplate{Status: tofaniov1alpha1.ObjectTemplateStatus{Group: gvk.Group, Version: gvk.Version, Kind: gvk.Kind}}
+
+	tests := []struct {
+		name string
+		// phases are the phases of the instance listed by the successive evaluations, the last one is kept
+		phases     []string
+		wantErr    bool
+		wantPassed int
+	}{
+		{name: "held at once", phases: []string{"Running"}, wantPassed: 1},
+		{name: "held once the instance settled", phases: []string{"Pending", "Pending", "Running"}, wantPassed: 1},
+		{name: "not held within the timeout", phases: []string{"Pending"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mapper := meta.NewDefaultRESTMapper(nil)
+			mapper.Add(gvk, meta.RESTScopeNamespace)
+			dynamic := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), map[schema.GroupVersionResource]string{gvr: "WidgetList"})
+			phases := tt.phases
+			dynamic.PrependReactor("list", "widgets", func(clienttesting.Action) (bool, runtime.Object, error) {
+				phase := phases[0]
+				if len(phases) > 1 {
+					phases = phases[1:]
+				}
+				obj := assertionObject("w-0", phase, 1)
+				obj.SetLabels(map[string]string{constants.TofanTestCaseNameLabel: "widgets", constants.TofanTestCaseUIDLabel: "tc-uid"})
+				return true, &unstructured.UnstructuredList{Items: []unstructured.Unstructured{obj}}, nil
+			})
+			r := &Reconciler{
+				Reconciler: common.Reconciler{
+					Client: fake.NewClientBuilder().WithScheme(clientgoscheme.Scheme).WithRESTMapper(mapper).Build(),
+					Log:    logr.Discard(),
+				},
+				Dynamic: dynamic,
+			}
+			running := "Running"
+			testCase := &tofaniov1alpha1.TestCase{
+				ObjectMeta: metav1.ObjectMeta{Name: "widgets", UID: "tc-uid"},
+				Spec: tofaniov1alpha1.TestCaseSpec{
+					Assertions:        []tofaniov1alpha1.ObjectAssertion{{Name: "phase", JSONPath: ".status.phase", Value: &running}},
+					AssertionsTimeout: &metav1.Duration{Duration: 200 * time.Millisecond},
+				},
+			}
+
+			err := r.CheckAssertions(context.Background(), testCase, objTpl, 10*time.Millisecond)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("CheckAssertions() error = %v, want error %t", err, tt.wantErr)
+			}
+			if tt.wantErr && !strings.Contains(err.Error(), "phase failed for 1 of 1 objects within 200ms") {
+				t.Errorf("CheckAssertions() error = %v, want the failed assertion and the timeout", err)
+			}
+
+			// The report has the results of the last evaluation, not of one of its own
+			rep := &tofaniov1alpha1.Report{}
+			r.measurements.addTo(rep, testCase)
+			if len(rep.Spec.ObjectAssertions) != 1 || rep.Spec.ObjectAssertions[0].Passed != tt.wantPassed {
+				t.Errorf("ObjectAssertions = %+v, want %d passed", rep.Spec.ObjectAssertions, tt.wantPassed)
+			}
+		})
+	}
+}
+
+func TestAddAssertionsNotEvaluated(t *testing.T) {
+	testCase := &tofaniov1alpha1.TestCase{Spec: tofaniov1alpha1.TestCaseSpec{
+		Assertions: []tofaniov1alpha1.ObjectAssertion{{Name: "phase", JSONPath: ".status.phase"}},
+	}}
+	rep := &tofaniov1alpha1.Report{}
+	addAssertions(rep, testCase, nil)
+	if len(rep.Spec.Assertions) != 1 || rep.Spec.Assertions[0].Name != AssertionObjects+"phase" || rep.Spec.Assertions[0].Passed {
+		t.Errorf("Assertions = %+v, want phase failed as not evaluated", rep.Spec.Assertions)
+	}
+}
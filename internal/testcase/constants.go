@@ -3,16 +3,23 @@ package testcase
 const (
 	StatusPending    string = "Pending"
 	StatusInProgress string = "InProgress"
+	StatusSuspended  string = "Suspended"
 	StatusCompleted  string = "Completed"
+	StatusAborted    string = "Aborted"
 	StatusError      string = "Error"
 
 	StatusPendingReason    string = "AwaitingExecution"
 	StatusInProgressReason string = "ExecutionStarted"
+	StatusSuspendedReason  string = "ExecutionSuspended"
 	StatusCompletedReason  string = "ExecutionSuccessful"
+	StatusAbortedReason    string = "ExecutionAborted"
 	StatusErrorReason      string = "ExecutionFailed"
 
 	StatusPendingMsg    string = "The TestCase is pending and has not started execution."
 	StatusInProgressMsg string = "The TestCase is currently in progress."
+	StatusSuspendedMsg  string = "The TestCase is suspended, object creation is paused."
+	StatusResumedMsg    string = "The TestCase was resumed, object creation continues."
 	StatusCompletedMsg  string = "The TestCase has completed successfully."
+	StatusAbortedMsg    string = "The TestCase was aborted."
 	StatusErrorMsg      string = "The TestCase encountered an error during execution."
 )
@@ -22,17 +22,47 @@ import (
 
 	tofaniov1alpha1 "github.com/invioteq/tofan/api/v1alpha1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/rest"
 	ctrl "sigs.k8s.io/controller-runtime"
 )
 
 // Reconciler  reconciles a TestCase object
 type Reconciler struct {
 	common.Reconciler
+	// Dynamic is used to list and delete the objects created for a TestCase, whatever their type
+	Dynamic dynamic.Interface
+	// ReportDir is the directory the report volume is mounted at, TestCase report paths are resolved against it
+	ReportDir string
+	// PrometheusURL is the address of the Prometheus server the TargetMetrics of TestCases are queried
+	// from, without it the series of a report have no samples
+	PrometheusURL string
+	// Standalone is set when the TestCases do not exist in the cluster, created objects are then not owned by them
+	Standalone bool
+	// Config is the REST config the clients runs create their objects with are derived from, without it
+	// objects are created with Client
+	Config *rest.Config
+	// LoadQPS and LoadBurst configure the client-side rate limiter of runs without a ClientRateLimit, a
+	// LoadQPS of zero removes the limit
+	LoadQPS   float32
+	LoadBurst int
+
+	runs         runRegistry
+	traces       traceRegistry
+	loadClients  loadClientRegistry
+	measurements measurementRegistry
 }
 
 //+kubebuilder:rbac:groups=tofan.io,resources=testcases,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=tofan.io,resources=testcases/status,verbs=get;update;patch
 //+kubebuilder:rbac:groups=tofan.io,resources=testcases/finalizers,verbs=update
+//+kubebuilder:rbac:groups=tofan.io,resources=reports,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=tofan.io,resources=reports/status,verbs=get;update;patch
+//+kubebuilder:rbac:groups="",resources=configmaps,verbs=get;list;watch;create;update;patch
+//+kubebuilder:rbac:groups="",resources=events,verbs=get;list;create;patch
+//+kubebuilder:rbac:groups=events.k8s.io,resources=events,verbs=get;list;watch
+//+kubebuilder:rbac:groups="",resources=namespaces,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:urls=/metrics,verbs=get
 
 func (r *Reconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
 	log := r.Log.WithValues("TestCase", req.NamespacedName)
@@ -0,0 +1,217 @@
+package testcase
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	tofaniov1alpha1 "github.com/invioteq/tofan/api/v1alpha1"
+	"github.com/invioteq/tofan/pkg/constants"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"os"
+	"path/filepath"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/yaml"
+	"sort"
+)
+
+const (
+	// maxDiagnosticObjects is the number of objects of a failed run captured for its report.
+	maxDiagnosticObjects = 5
+	// maxDiagnosticEvents is the number of Events captured per object, the latest ones are kept.
+	maxDiagnosticEvents = 20
+	// maxSnapshotBytes bounds the snapshot of an object, so the snapshots of a run fit in a ConfigMap.
+	maxSnapshotBytes = 128 * 1024
+)
+
+// coreEventsResource is the resource of the core Events of the objects captured.
+var coreEventsResource = schema.GroupVersionResource{Version: "v1", Resource: "events"}
+
+// snapshot is the captured state of an object of a failed or aborted run: its manifest, its Events and its
+// children, rendered as a multi-document YAML.
+type snapshot struct {
+	diagnostic tofaniov1alpha1.ObjectDiagnostic
+	content    string
+}
+
+// diagnosed reports whether the objects of a run that ended in phase are captured before teardown: the
+// run failed, or it was aborted, typically because its objects never became ready.
+func diagnosed(phase string) bool {
+	return phase == StatusError || phase == StatusAborted
+}
+
+// captureDiagnostics snapshots a sample of the objects of a failed or aborted run before they are torn
+// down, the objects that are not ready first.
+func (r *Reconciler) captureDiagnostics(ctx context.Context, testCase *tofaniov1alpha1.TestCase, resources []unstructured.Unstructured) []snapshot {
+	var finders []*childFinder
+	if testCase.Spec.Verification != nil {
+		for _, child := range testCase.Spec.Verification.Children {
+			finder, err := r.newChildFinder(child)
+			if err != nil {
+				r.Log.Error(err, "Failed to find children for diagnostics", "TestCase", testCase.Name, "Child", childName(child))
+				continue
+			}
+			finders = append(finders, finder)
+		}
+	}
+
+	var snapshots []snapshot
+	for _, resource := range diagnosticSample(resources) {
+		s, err := r.snapshotObject(ctx, finders, resource)
+		if err != nil {
+			r.Log.Error(err, "Failed to capture diagnostics", "TestCase", testCase.Name, "Name", resource.GetName())
+			continue
+		}
+		snapshots = append(snapshots, s)
+	}
+	return snapshots
+}
+
+// diagnosticSample returns the objects to capture: the first objects by name that are not ready, or
+// the first objects by name when all of them are.
+func diagnosticSample(resources []unstructured.Unstructured) []*unstructured.Unstructured {
+	var notReady, ready []*unstructured.Unstructured
+	for i := range resources {
+		if IsResourceReady(&resources[i]) {
+			ready = append(ready, &resources[i])
+		} else {
+			notReady = append(notReady, &resources[i])
+		}
+	}
+	sample := notReady
+	if len(sample) == 0 {
+		sample = ready
+	}
+	sort.Slice(sample, func(i, j int) bool {
+		return sample[i].GetNamespace()+"/"+sample[i].GetName() < sample[j].GetNamespace()+"/"+sample[j].GetName()
+	})
+	if len(sample) > maxDiagnosticObjects {
+		sample = sample[:maxDiagnosticObjects]
+	}
+	return sample
+}
+
+// snapshotObject renders the object, its latest Events and its children found by the finders.
+func (r *Reconciler) snapshotObject(ctx context.Context, finders []*childFinder, resource *unstructured.Unstructured) (snapshot, error) {
+	s := snapshot{diagnostic: tofaniov1alpha1.ObjectDiagnostic{
+		Namespace: resource.GetNamespace(),
+		Name:      resource.GetName(),
+		Ready:     IsResourceReady(resource),
+		Key:       snapshotKey(resource),
+	}}
+	documents := []*unstructured.Unstructured{resource}
+
+	// Events of cluster-scoped objects are recorded in the default namespace, list them in any
+	events, err := r.Dynamic.Resource(coreEventsResource).Namespace(resource.GetNamespace()).List(ctx, metav1.ListOptions{
+		FieldSelector: "involvedObject.uid=" + string(resource.GetUID()),
+	})
+	if err != nil {
+		return s, fmt.Errorf("failed to list events: %w", err)
+	}
+	sort.Slice(events.Items, func(i, j int) bool {
+		return eventTime(&events.Items[i]) < eventTime(&events.Items[j])
+	})
+	if len(events.Items) > maxDiagnosticEvents {
+		events.Items = events.Items[len(events.Items)-maxDiagnosticEvents:]
+	}
+	for i := range events.Items {
+		documents = append(documents, &events.Items[i])
+	}
+	s.diagnostic.Events = len(events.Items)
+
+	for _, finder := range finders {
+		children, err := finder.find(ctx, resource)
+		if err != nil {
+			return s, fmt.Errorf("failed to find %s children: %w", finder.child.Kind, err)
+		}
+		for i := range children {
+			documents = append(documents, &children[i])
+		}
+		s.diagnostic.Children += len(children)
+	}
+
+	var buf bytes.Buffer
+	for _, document := range documents {
+		obj := document.DeepCopy()
+		obj.SetManagedFields(nil)
+		data, err := yaml.Marshal(obj.Object)
+		if err != nil {
+			return s, err
+		}
+		if buf.Len()+len(data) > maxSnapshotBytes {
+			buf.WriteString("---\n# snapshot truncated\n")
+			break
+		}
+		buf.WriteString("---\n")
+		buf.Write(data)
+	}
+	s.content = buf.String()
+	return s, nil
+}
+
+// eventTime returns the time an Event last occurred, as a sortable RFC3339 string.
+func eventTime(event *unstructured.Unstructured) string {
+	for _, field := range []string{"lastTimestamp", "eventTime", "firstTimestamp"} {
+		if at, ok := event.Object[field].(string); ok && at != "" {
+			return at
+		}
+	}
+	return event.GetCreationTimestamp().UTC().Format(metav1.RFC3339Micro)
+}
+
+// snapshotKey returns the ConfigMap key and file name of the snapshot of the resource.
+func snapshotKey(resource *unstructured.Unstructured) string {
+	if resource.GetNamespace() == "" {
+		return resource.GetName() + ".yaml"
+	}
+	return resource.GetNamespace() + "." + resource.GetName() + ".yaml"
+}
+
+// addDiagnostics lists the snapshots in the report.
+func addDiagnostics(rep *tofaniov1alpha1.Report, snapshots []snapshot) {
+	if len(snapshots) == 0 {
+		return
+	}
+	diagnostics := &tofaniov1alpha1.Diagnostics{}
+	for _, s := range snapshots {
+		diagnostics.Objects = append(diagnostics.Objects, s.diagnostic)
+	}
+	rep.Spec.Diagnostics = diagnostics
+}
+
+// storeDiagnostics writes the snapshots to a ConfigMap owned by the stored report, so they are
+// garbage collected with it.
+func (r *Reconciler) storeDiagnostics(ctx context.Context, rep *tofaniov1alpha1.Report, snapshots []snapshot) error {
+	configMap := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      rep.Spec.Diagnostics.ConfigMapName,
+			Namespace: rep.Namespace,
+			Labels: map[string]string{
+				constants.TofanReportTestCaseLabel: rep.Spec.TestCaseRef,
+			},
+		},
+		Data: make(map[string]string, len(snapshots)),
+	}
+	for _, s := range snapshots {
+		configMap.Data[s.diagnostic.Key] = s.content
+	}
+	if err := controllerutil.SetOwnerReference(rep, configMap, r.Scheme); err != nil {
+		return err
+	}
+	return r.Create(ctx, configMap)
+}
+
+// writeDiagnostics writes the snapshots to files in dir.
+func writeDiagnostics(dir string, snapshots []snapshot) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	for _, s := range snapshots {
+		if err := os.WriteFile(filepath.Join(dir, s.diagnostic.Key), []byte(s.content), 0o644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,454 @@
+package testcase
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	tofaniov1alpha1 "github.com/invioteq/tofan/api/v1alpha1"
+	"github.com/invioteq/tofan/internal/metrics"
+	"github.com/invioteq/tofan/pkg/utils"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/selection"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/watch"
+	"reflect"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	// LatencyTimeToCorrect is the name of the latency distribution from the drift applied to an instance
+	// until the target controller corrected it.
+	LatencyTimeToCorrect = "timeToCorrect"
+	// AssertionDriftCorrected checks that the target controller corrected the drift of every instance.
+	AssertionDriftCorrected = "driftCorrected"
+
+	// defaultDriftTimeout is how long instances may take to converge when the TestCase sets no timeout.
+	defaultDriftTimeout = 5 * time.Minute
+	// maxSelectorValues is the largest number of values of a label the watch of the children selects.
+	maxSelectorValues = 100
+)
+
+// disturbance is the drift applied to an instance.
+type disturbance struct {
+	instance *unstructured.Unstructured
+	// at is the time the request applying the drift was sent, after it waited for the rate limiter
+	at time.Time
+	// target is the patched object, the instance or one of its children
+	target types.NamespacedName
+	// original is the value of the patched field before the drift, found whether it was set at all
+	original interface{}
+	found    bool
+	// patchedVersion is the resourceVersion of the patched object, patched is set once its watch event was
+	// seen. unchanged is set when the patch did not change the object
+	patchedVersion string
+	patched        bool
+	unchanged      bool
+	// deleted is the UID of the deleted child
+	deleted types.UID
+	// selector and live are the selector of the children of the instance and the children alive
+	selector  labels.Selector
+	live      map[types.UID]bool
+	converged bool
+}
+
+// driftRun is the drift of the instances of a run.
+type driftRun struct {
+	drift *tofaniov1alpha1.Drift
+	// child selects the children disturbed, it is nil with the PatchInstance action
+	child *tofaniov1alpha1.ChildExpectation
+	path  []string
+	value interface{}
+	// resource is the resource of the patched objects
+	resource schema.GroupVersionResource
+	// labelKey is the first label of the selector of the children rendered for every instance, it tells
+	// the children of the instances matched by labels apart
+	labelKey string
+}
+
+// newDriftRun validates the drift of the TestCase.
+func newDriftRun(testCase *tofaniov1alpha1.TestCase) (*driftRun, error) {
+	drift := testCase.Spec.Drift
+	run := &driftRun{drift: drift}
+
+	switch drift.Action {
+	case tofaniov1alpha1.DriftDeleteChild, tofaniov1alpha1.DriftPatchChild:
+		if testCase.Spec.Verification != nil {
+			for i := range testCase.Spec.Verification.Children {
+				if childName(testCase.Spec.Verification.Children[i]) == drift.Child {
+					run.child = &testCase.Spec.Verification.Children[i]
+				}
+			}
+		}
+		if run.child == nil {
+			return nil, fmt.Errorf("drift child %q is not a child of the verification", drift.Child)
+		}
+		if run.child.Match == tofaniov1alpha1.ChildMatchLabelSelector {
+			run.labelKey = templatedLabel(*run.child)
+		}
+	case tofaniov1alpha1.DriftPatchInstance:
+	default:
+		return nil, fmt.Errorf("unknown drift action %q", drift.Action)
+	}
+
+	if drift.Action == tofaniov1alpha1.DriftDeleteChild {
+		return run, nil
+	}
+	if drift.Path == "" || drift.Value == nil {
+		return nil, fmt.Errorf("the %s drift action requires a path and a value", drift.Action)
+	}
+	run.path = strings.Split(drift.Path, ".")
+	if err := json.Unmarshal(drift.Value.Raw, &run.value); err != nil {
+		return nil, fmt.Errorf("invalid drift value: %w", err)
+	}
+	return run, nil
+}
+
+// MeasureDrift disturbs every instance of the TestCase as its drift asks and waits until the target
+// controller corrected it, recording the correction latencies for the report. The disturbed objects are
+// watched, a correction is timed when the event showing it is received. It fails once the drift
+// timeout elapsed with instances not converged, and returns the context error when ctx is done first.
+// A TestCase without drift passes at once.
+func (r *Reconciler) MeasureDrift(ctx context.Context, testCase *tofaniov1alpha1.TestCase, objTpl *tofaniov1alpha1.ObjectTemplate) error {
+	if testCase.Spec.Drift == nil {
+		return nil
+	}
+	run, err := newDriftRun(testCase)
+	if err != nil {
+		return err
+	}
+	timeout := defaultDriftTimeout
+	if run.drift.Timeout != nil {
+		timeout = run.drift.Timeout.Duration
+	}
+
+	resources, err := r.listTestCaseResources(ctx, testCase, objTpl)
+	if err != nil {
+		return err
+	}
+	var finder *childFinder
+	if run.child != nil {
+		if finder, err = r.newChildFinder(*run.child); err != nil {
+			return err
+		}
+		run.resource = finder.mapping.Resource
+	} else {
+		mapping, err := r.templateMapping(objTpl)
+		if err != nil {
+			return err
+		}
+		run.resource = mapping.Resource
+	}
+
+	// The watch starts before the drift is applied, so no correction is missed
+	watchCtx, stopWatch := context.WithCancel(ctx)
+	defer stopWatch()
+	namespace, selector, err := run.watchScope(testCase, finder, resources)
+	if err != nil {
+		return err
+	}
+	_, events, err := r.watchObjects(watchCtx, run.resource, namespace, metav1.ListOptions{LabelSelector: selector})
+	if err != nil {
+		return fmt.Errorf("failed to watch the objects disturbed: %w", err)
+	}
+
+	c, err := r.loadClientFor(testCase)
+	if err != nil {
+		return err
+	}
+	disturbances, err := r.disturb(ctx, c, run, finder, resources)
+	if err != nil {
+		return err
+	}
+	r.Log.Info("Drift applied", "TestCase", testCase.Name, "Action", run.drift.Action, "Instances", len(disturbances))
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	index := run.index(disturbances)
+	converged := 0
+	corrected := func(d *disturbance, at time.Time) {
+		d.converged = true
+		converged++
+		sample := at.Sub(d.at)
+		r.measurements.observe(testCase, LatencyTimeToCorrect, sample)
+		metrics.TimeToCorrect.WithLabelValues(testCase.Namespace, testCase.Name).Observe(sample.Seconds())
+	}
+	// There is nothing to correct when the patch changed nothing or the instance had more children than expected
+	for _, d := range disturbances {
+		if d.unchanged || (d.live != nil && len(d.live) >= childCount(*run.child)) {
+			corrected(d, d.at)
+		}
+	}
+
+	for converged < len(disturbances) {
+		select {
+		case event, ok := <-events:
+			if !ok {
+				if ctx.Err() != nil {
+					return ctx.Err()
+				}
+				return fmt.Errorf("watch of the objects disturbed ended: %s", driftMessage(disturbances, converged))
+			}
+			obj, ok := event.Object.(*unstructured.Unstructured)
+			if !ok {
+				continue
+			}
+			for _, key := range run.eventKeys(obj) {
+				for _, d := range index[key] {
+					if !d.converged && run.corrects(d, event.Type, obj, finder) {
+						corrected(d, event.at)
+					}
+				}
+			}
+		case <-timer.C:
+			err := fmt.Errorf("drift not corrected after %s: %s", timeout, driftMessage(disturbances, converged))
+			r.measurements.assert(testCase, tofaniov1alpha1.Assertion{Name: AssertionDriftCorrected, Message: err.Error()})
+			return err
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	r.measurements.assert(testCase, tofaniov1alpha1.Assertion{
+		Name:    AssertionDriftCorrected,
+		Passed:  true,
+		Message: driftMessage(disturbances, converged),
+	})
+	return nil
+}
+
+// disturb applies the drift to every instance with the load client c of the run, finder finds the
+// children disturbed by the child actions.
+func (r *Reconciler) disturb(ctx context.Context, c client.Client, run *driftRun, finder *childFinder, resources []unstructured.Unstructured) ([]*disturbance, error) {
+	disturbances := make([]*disturbance, 0, len(resources))
+	for i := range resources {
+		d := &disturbance{instance: &resources[i]}
+		target := &resources[i]
+		if finder != nil {
+			children, err := finder.find(ctx, d.instance)
+			if err != nil {
+				return nil, err
+			}
+			if target = firstLive(children); target == nil {
+				return nil, fmt.Errorf("instance %s has no %s to disturb", d.instance.GetName(), run.child.Kind)
+			}
+			if run.drift.Action == tofaniov1alpha1.DriftDeleteChild {
+				if d.selector, err = childSelector(*run.child, d.instance); err != nil {
+					return nil, err
+				}
+				d.live = map[types.UID]bool{}
+				for j := range children {
+					if children[j].GetUID() != target.GetUID() && children[j].GetDeletionTimestamp() == nil {
+						d.live[children[j].GetUID()] = true
+					}
+				}
+			}
+		}
+		d.target = types.NamespacedName{Namespace: target.GetNamespace(), Name: target.GetName()}
+		// Corrections are timed from the request leaving the rate limiter of the client
+		d.at = time.Now()
+		sentCtx := withSentTime(ctx, &d.at)
+
+		if run.drift.Action == tofaniov1alpha1.DriftDeleteChild {
+			d.deleted = target.GetUID()
+			if err := c.Delete(sentCtx, target.DeepCopy()); err != nil && !apierrors.IsNotFound(err) {
+				return nil, fmt.Errorf("failed to delete %s of instance %s: %w", d.target.Name, d.instance.GetName(), err)
+			}
+			disturbances = append(disturbances, d)
+			continue
+		}
+
+		d.original, d.found, _ = unstructured.NestedFieldCopy(target.Object, run.path...)
+		patch := map[string]interface{}{}
+		if err := utils.NavigateAndApplyValue(&patch, run.drift.Path, run.value); err != nil {
+			return nil, err
+		}
+		data, err := json.Marshal(patch)
+		if err != nil {
+			return nil, err
+		}
+		patched := target.DeepCopy()
+		if err := c.Patch(sentCtx, patched, client.RawPatch(types.MergePatchType, data), client.FieldOwner(FieldManager)); err != nil {
+			return nil, fmt.Errorf("failed to patch %s of instance %s: %w", d.target.Name, d.instance.GetName(), err)
+		}
+		d.patchedVersion = patched.GetResourceVersion()
+		d.unchanged = d.patchedVersion == target.GetResourceVersion()
+		disturbances = append(disturbances, d)
+	}
+	return disturbances, nil
+}
+
+// corrects reports whether the watch event of obj shows the drift of the instance corrected. A patched
+// object converged once it is back to its former value after the patch, an instance whose child was
+// deleted once it has its expected children again. finder matches the children to their instance.
+func (run *driftRun) corrects(d *disturbance, eventType watch.EventType, obj *unstructured.Unstructured, finder *childFinder) bool {
+	if run.drift.Action == tofaniov1alpha1.DriftDeleteChild {
+		if obj.GetUID() == d.deleted || !finder.matches(obj, d.instance, d.selector) {
+			return false
+		}
+		if eventType == watch.Deleted || obj.GetDeletionTimestamp() != nil {
+			delete(d.live, obj.GetUID())
+		} else {
+			d.live[obj.GetUID()] = true
+		}
+		return len(d.live) >= childCount(*run.child)
+	}
+
+	if eventType == watch.Deleted || obj.GetNamespace() != d.target.Namespace || obj.GetName() != d.target.Name {
+		return false
+	}
+	// Versions older than the patch still hold the former value
+	if !d.patched {
+		d.patched = obj.GetResourceVersion() == d.patchedVersion
+		return false
+	}
+	value, found, _ := unstructured.NestedFieldNoCopy(obj.Object, run.path...)
+	return found == d.found && (!found || reflect.DeepEqual(value, d.original))
+}
+
+// watchScope returns the namespace and the label selector of the watch of the objects disturbed. The
+// instances are selected by the labels of the run. The children are watched in the namespace of the
+// instances when they share one, and selected by the labels of the ChildExpectation that are the same
+// for every instance, along with the values of labelKey rendered for the instances when there are not
+// too many of them.
+func (run *driftRun) watchScope(testCase *tofaniov1alpha1.TestCase, finder *childFinder, resources []unstructured.Unstructured) (string, string, error) {
+	if run.child == nil {
+		return "", testCaseSelector(testCase), nil
+	}
+
+	namespace := ""
+	if finder.mapping.Scope.Name() == meta.RESTScopeNameNamespace {
+		for i := range resources {
+			if i > 0 && resources[i].GetNamespace() != namespace {
+				namespace = ""
+				break
+			}
+			namespace = resources[i].GetNamespace()
+		}
+	}
+
+	selector := fixedLabels(*run.child)
+	if run.labelKey == "" || len(resources) > maxSelectorValues {
+		return namespace, selector.String(), nil
+	}
+	values := sets.New[string]()
+	for i := range resources {
+		instanceSelector, err := childSelector(*run.child, &resources[i])
+		if err != nil {
+			return "", "", err
+		}
+		value, _ := instanceSelector.RequiresExactMatch(run.labelKey)
+		values.Insert(value)
+	}
+	requirement, err := labels.NewRequirement(run.labelKey, selection.In, sets.List(values))
+	if err != nil {
+		return "", "", err
+	}
+	return namespace, selector.Add(*requirement).String(), nil
+}
+
+// index returns the disturbances by the key of the objects whose events may show them corrected.
+func (run *driftRun) index(disturbances []*disturbance) map[string][]*disturbance {
+	index := make(map[string][]*disturbance, len(disturbances))
+	for _, d := range disturbances {
+		var key string
+		switch {
+		case run.drift.Action != tofaniov1alpha1.DriftDeleteChild:
+			key = "object/" + d.target.String()
+		case run.child.Match == tofaniov1alpha1.ChildMatchLabelSelector:
+			// Without a label rendered for every instance, the children of every instance match the same selector
+			if run.labelKey != "" {
+				value, _ := d.selector.RequiresExactMatch(run.labelKey)
+				key = "label/" + value
+			}
+		default:
+			key = "owner/" + string(d.instance.GetUID())
+		}
+		index[key] = append(index[key], d)
+	}
+	return index
+}
+
+// eventKeys returns the keys of the disturbances of index an event of obj may show corrected.
+func (run *driftRun) eventKeys(obj *unstructured.Unstructured) []string {
+	switch {
+	case run.drift.Action != tofaniov1alpha1.DriftDeleteChild:
+		return []string{"object/" + types.NamespacedName{Namespace: obj.GetNamespace(), Name: obj.GetName()}.String()}
+	case run.child.Match == tofaniov1alpha1.ChildMatchLabelSelector:
+		if run.labelKey == "" {
+			return []string{""}
+		}
+		return []string{"label/" + obj.GetLabels()[run.labelKey]}
+	default:
+		keys := make([]string, 0, len(obj.GetOwnerReferences()))
+		for _, ref := range obj.GetOwnerReferences() {
+			keys = append(keys, "owner/"+string(ref.UID))
+		}
+		return keys
+	}
+}
+
+// templatedLabel returns the first label of the ChildExpectation by key that has a template.
+func templatedLabel(child tofaniov1alpha1.ChildExpectation) string {
+	keys := make([]string, 0, len(child.Selector))
+	for key, value := range child.Selector {
+		if strings.Contains(value, "{{") {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	if len(keys) == 0 {
+		return ""
+	}
+	return keys[0]
+}
+
+// fixedLabels returns the selector of the labels of the ChildExpectation that are the same for the
+// children of every instance, those without a template.
+func fixedLabels(child tofaniov1alpha1.ChildExpectation) labels.Selector {
+	set := labels.Set{}
+	for key, value := range child.Selector {
+		if !strings.Contains(value, "{{") {
+			set[key] = value
+		}
+	}
+	return labels.SelectorFromSet(set)
+}
+
+// firstLive returns the first object by name that is not being deleted, or nil.
+func firstLive(objects []unstructured.Unstructured) *unstructured.Unstructured {
+	sort.Slice(objects, func(i, j int) bool { return objects[i].GetName() < objects[j].GetName() })
+	for i := range objects {
+		if objects[i].GetDeletionTimestamp() == nil {
+			return &objects[i]
+		}
+	}
+	return nil
+}
+
+// driftMessage describes the convergence of the instances, naming the first ones not converged.
+func driftMessage(disturbances []*disturbance, converged int) string {
+	msg := fmt.Sprintf("%d of %d instances converged", converged, len(disturbances))
+	var pending []string
+	for _, d := range disturbances {
+		if !d.converged && len(pending) < maxMissingInstances {
+			pending = append(pending, d.instance.GetName())
+		}
+	}
+	if len(pending) > 0 {
+		msg += ", pending for " + strings.Join(pending, ", ")
+		if missing := len(disturbances) - converged; missing > len(pending) {
+			msg += fmt.Sprintf(" and %d more", missing-len(pending))
+		}
+	}
+	return msg
+}
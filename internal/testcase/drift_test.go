@@ -0,0 +1,109 @@
+package testcase
+
+import (
+	"testing"
+
+	tofaniov1alpha1 "github.com/invioteq/tofan/api/v1alpha1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func TestDriftWatchScope(t *testing.T) {
+	testCase := &tofaniov1alpha1.TestCase{ObjectMeta: metav1.ObjectMeta{Name: "widgets", UID: "tc-uid"}}
+	instance := func(namespace, name string) unstructured.Unstructured {
+		obj := unstructured.Unstructured{Object: map[string]interface{}{}}
+		obj.SetNamespace(namespace)
+		obj.SetName(name)
+		return obj
+	}
+	namespaced := &childFinder{mapping: &meta.RESTMapping{Scope: meta.RESTScopeNamespace}}
+	byLabels := &tofaniov1alpha1.ChildExpectation{
+		Kind:     "ConfigMap",
+		Match:    tofaniov1alpha1.ChildMatchLabelSelector,
+		Selector: map[string]string{"app": "widget", "widget": "{{ .Name }}"},
+	}
+	owned := &tofaniov1alpha1.ChildExpectation{Kind: "ConfigMap", Selector: map[string]string{"app": "widget"}}
+
+	tests := []struct {
+		name          string
+		run           *driftRun
+		resources     []unstructured.Unstructured
+		wantNamespace string
+		wantSelector  string
+	}{
+		{
+			name:         "instances by the labels of the run",
+			run:          &driftRun{},
+			resources:    []unstructured.Unstructured{instance("default", "w-0")},
+			wantSelector: testCaseSelector(testCase),
+		},
+		{
+			name:          "children matched by labels in the namespace of the instances",
+			run:           &driftRun{child: byLabels, labelKey: "widget"},
+			resources:     []unstructured.Unstructured{instance("default", "w-1"), instance("default", "w-0")},
+			wantNamespace: "default",
+			wantSelector:  "app=widget,widget in (w-0,w-1)",
+		},
+		{
+			name:          "owned children in every namespace of a fan-out",
+			run:           &driftRun{child: owned},
+			resources:     []unstructured.Unstructured{instance("ns-0", "w-0"), instance("ns-1", "w-1")},
+			wantNamespace: "",
+			wantSelector:  "app=widget",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			namespace, selector, err := tt.run.watchScope(testCase, namespaced, tt.resources)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if namespace != tt.wantNamespace || selector != tt.wantSelector {
+				t.Errorf("watchScope() = %q, %q, want %q, %q", namespace, selector, tt.wantNamespace, tt.wantSelector)
+			}
+		})
+	}
+}
+
+func TestDriftIndex(t *testing.T) {
+	instance := func(name string) *unstructured.Unstructured {
+		obj := &unstructured.Unstructured{Object: map[string]interface{}{}}
+		obj.SetNamespace("default")
+		obj.SetName(name)
+		obj.SetUID(types.UID(name + "-uid"))
+		return obj
+	}
+	child := func(name, owner string) *unstructured.Unstructured {
+		obj := &unstructured.Unstructured{Object: map[string]interface{}{}}
+		obj.SetNamespace("default")
+		obj.SetName(name)
+		obj.SetOwnerReferences([]metav1.OwnerReference{{Name: owner, UID: types.UID(owner + "-uid")}})
+		return obj
+	}
+
+	patch := &driftRun{drift: &tofaniov1alpha1.Drift{Action: tofaniov1alpha1.DriftPatchInstance}}
+	patched := []*disturbance{
+		{instance: instance("w-0"), target: types.NamespacedName{Namespace: "default", Name: "w-0"}},
+		{instance: instance("w-1"), target: types.NamespacedName{Namespace: "default", Name: "w-1"}},
+	}
+	index := patch.index(patched)
+	if got := index[patch.eventKeys(instance("w-1"))[0]]; len(got) != 1 || got[0] != patched[1] {
+		t.Errorf("patched instance w-1 indexed as %v, want its disturbance", got)
+	}
+
+	deleteChild := &driftRun{
+		drift: &tofaniov1alpha1.Drift{Action: tofaniov1alpha1.DriftDeleteChild},
+		child: &tofaniov1alpha1.ChildExpectation{Kind: "ConfigMap"},
+	}
+	deleted := []*disturbance{{instance: instance("w-0")}, {instance: instance("w-1")}}
+	index = deleteChild.index(deleted)
+	keys := deleteChild.eventKeys(child("w-0-config", "w-0"))
+	if len(keys) != 1 || len(index[keys[0]]) != 1 || index[keys[0]][0] != deleted[0] {
+		t.Errorf("child of w-0 indexed as %v, want the disturbance of w-0", keys)
+	}
+	if keys := deleteChild.eventKeys(child("other", "other")); len(index[keys[0]]) != 0 {
+		t.Errorf("child of another object indexed as %v, want no disturbance", index[keys[0]])
+	}
+}
@@ -0,0 +1,145 @@
+package testcase
+
+import (
+	"context"
+	"errors"
+	tofaniov1alpha1 "github.com/invioteq/tofan/api/v1alpha1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"net"
+	"strings"
+	"time"
+)
+
+// Reasons the errors of instances are counted by.
+const (
+	ErrorReasonTemplate        = "TemplateError"
+	ErrorReasonWebhookDenied   = "WebhookDenied"
+	ErrorReasonForbidden       = "Forbidden"
+	ErrorReasonInvalid         = "Invalid"
+	ErrorReasonAlreadyExists   = "AlreadyExists"
+	ErrorReasonNotFound        = "NotFound"
+	ErrorReasonConflict        = "Conflict"
+	ErrorReasonTooManyRequests = "TooManyRequests"
+	ErrorReasonTimeout         = "Timeout"
+	ErrorReasonServerError     = "ServerError"
+	ErrorReasonUnknown         = "Unknown"
+)
+
+const (
+	defaultRetryAttempts   = 5
+	defaultRetryBackoff    = 100 * time.Millisecond
+	defaultRetryMaxBackoff = 10 * time.Second
+)
+
+// templateError is the error of an instance the ObjectTemplate could not be rendered for.
+type templateError struct {
+	err error
+}
+
+func (e *templateError) Error() string { return "failed to render instance: " + e.err.Error() }
+func (e *templateError) Unwrap() error { return e.err }
+
+// ErrorReason classifies the error an instance could not be created with.
+func ErrorReason(err error) string {
+	var tplErr *templateError
+	if errors.As(err, &tplErr) {
+		return ErrorReasonTemplate
+	}
+	// Admission webhooks deny requests with the status code of their choice
+	if msg := err.Error(); strings.Contains(msg, "admission webhook") && strings.Contains(msg, "denied the request") {
+		return ErrorReasonWebhookDenied
+	}
+
+	switch {
+	case apierrors.IsForbidden(err):
+		return ErrorReasonForbidden
+	case apierrors.IsInvalid(err), apierrors.IsBadRequest(err):
+		return ErrorReasonInvalid
+	case apierrors.IsAlreadyExists(err):
+		return ErrorReasonAlreadyExists
+	case apierrors.IsNotFound(err):
+		return ErrorReasonNotFound
+	case apierrors.IsConflict(err):
+		return ErrorReasonConflict
+	case apierrors.IsTooManyRequests(err):
+		return ErrorReasonTooManyRequests
+	case apierrors.IsTimeout(err), apierrors.IsServerTimeout(err), errors.Is(err, context.DeadlineExceeded), isNetTimeout(err):
+		return ErrorReasonTimeout
+	case apierrors.IsInternalError(err), apierrors.IsServiceUnavailable(err), apierrors.IsUnexpectedServerError(err):
+		return ErrorReasonServerError
+	}
+
+	var status apierrors.APIStatus
+	if errors.As(err, &status) && status.Status().Code >= 500 {
+		return ErrorReasonServerError
+	}
+	if reason := apierrors.ReasonForError(err); reason != metav1.StatusReasonUnknown {
+		return string(reason)
+	}
+	return ErrorReasonUnknown
+}
+
+func isNetTimeout(err error) bool {
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
+
+// IsRetriable reports whether a request that failed with err may succeed when sent again.
+func IsRetriable(err error) bool {
+	switch ErrorReason(err) {
+	case ErrorReasonTooManyRequests, ErrorReasonServerError, ErrorReasonConflict, ErrorReasonTimeout:
+		return true
+	default:
+		return false
+	}
+}
+
+// withRetry calls request until it succeeds, fails with an error that is not retriable or the
+// attempts of the retry policy of the TestCase are used up. It returns the number of retries made
+// along with the error of the last attempt.
+func withRetry(ctx context.Context, testCase *tofaniov1alpha1.TestCase, request func() error) (int, error) {
+	attempts, backoff, maxBackoff := defaultRetryAttempts, defaultRetryBackoff, defaultRetryMaxBackoff
+	if policy := testCase.Spec.Retry; policy != nil {
+		if policy.Attempts > 0 {
+			attempts = policy.Attempts
+		}
+		if policy.Backoff != nil {
+			backoff = policy.Backoff.Duration
+		}
+		if policy.MaxBackoff != nil {
+			maxBackoff = policy.MaxBackoff.Duration
+		}
+	}
+
+	for retries := 0; ; retries++ {
+		err := request()
+		if err == nil || retries+1 >= attempts || !IsRetriable(err) {
+			return retries, err
+		}
+
+		select {
+		case <-time.After(wait.Jitter(backoff, 0.1)):
+		case <-ctx.Done():
+			return retries, err
+		}
+		if backoff *= 2; backoff > maxBackoff {
+			backoff = maxBackoff
+		}
+	}
+}
+
+// addError counts an error of an instance in the status of the TestCase.
+func addError(status *tofaniov1alpha1.TestCaseStatus, err error) {
+	status.Failed++
+	reason := ErrorReason(err)
+	for i := range status.Errors {
+		if status.Errors[i].Reason == reason {
+			status.Errors[i].Count++
+			status.Errors[i].Message = err.Error()
+			return
+		}
+	}
+	status.Errors = append(status.Errors, tofaniov1alpha1.ErrorCount{Reason: reason, Count: 1, Message: err.Error()})
+}
@@ -0,0 +1,165 @@
+package testcase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	tofaniov1alpha1 "github.com/invioteq/tofan/api/v1alpha1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+type timeoutError struct{}
+
+func (timeoutError) Error() string   { return "i/o timeout" }
+func (timeoutError) Timeout() bool   { return true }
+func (timeoutError) Temporary() bool { return true }
+
+func TestErrorReason(t *testing.T) {
+	widgets := schema.GroupResource{Group: "simulator.tofan.io", Resource: "widgets"}
+	tests := []struct {
+		name string
+		err  error
+		want string
+	}{
+		{"template", &templateError{err: errors.New("bad path")}, ErrorReasonTemplate},
+		{"wrapped template", fmt.Errorf("instance 3: %w", &templateError{err: errors.New("bad path")}), ErrorReasonTemplate},
+		{"webhook denial", apierrors.NewForbidden(widgets, "w-1", errors.New(`admission webhook "validate.tofan.io" denied the request: no`)), ErrorReasonWebhookDenied},
+		{"webhook denial with a bad request", apierrors.NewBadRequest(`admission webhook "validate.tofan.io" denied the request: no`), ErrorReasonWebhookDenied},
+		{"forbidden", apierrors.NewForbidden(widgets, "w-1", errors.New("rbac")), ErrorReasonForbidden},
+		{"invalid", apierrors.NewInvalid(schema.GroupKind{Kind: "Widget"}, "w-1", nil), ErrorReasonInvalid},
+		{"bad request", apierrors.NewBadRequest("bad"), ErrorReasonInvalid},
+		{"already exists", apierrors.NewAlreadyExists(widgets, "w-1"), ErrorReasonAlreadyExists},
+		{"not found", apierrors.NewNotFound(widgets, "w-1"), ErrorReasonNotFound},
+		{"conflict", apierrors.NewConflict(widgets, "w-1", errors.New("stale")), ErrorReasonConflict},
+		{"too many requests", apierrors.NewTooManyRequests("slow down", 1), ErrorReasonTooManyRequests},
+		{"server timeout", apierrors.NewServerTimeout(widgets, "create", 1), ErrorReasonTimeout},
+		{"deadline", context.DeadlineExceeded, ErrorReasonTimeout},
+		{"network timeout", fmt.Errorf("post: %w", timeoutError{}), ErrorReasonTimeout},
+		{"internal error", apierrors.NewInternalError(errors.New("boom")), ErrorReasonServerError},
+		{"service unavailable", apierrors.NewServiceUnavailable("down"), ErrorReasonServerError},
+		{"other 5xx", apierrors.NewGenericServerResponse(502, "create", widgets, "w-1", "", 0, false), ErrorReasonServerError},
+		{"other reason", &apierrors.StatusError{ErrStatus: metav1.Status{Status: metav1.StatusFailure, Reason: metav1.StatusReasonGone, Code: 410}}, string(metav1.StatusReasonGone)},
+		{"unknown", errors.New("connection refused"), ErrorReasonUnknown},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ErrorReason(tt.err); got != tt.want {
+				t.Errorf("ErrorReason(%v) = %s, want %s", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsRetriable(t *testing.T) {
+	widgets := schema.GroupResource{Group: "simulator.tofan.io", Resource: "widgets"}
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{apierrors.NewTooManyRequests("slow down", 1), true},
+		{apierrors.NewInternalError(errors.New("boom")), true},
+		{apierrors.NewConflict(widgets, "w-1", errors.New("stale")), true},
+		{context.DeadlineExceeded, true},
+		{apierrors.NewForbidden(widgets, "w-1", errors.New("rbac")), false},
+		{apierrors.NewAlreadyExists(widgets, "w-1"), false},
+		{&templateError{err: errors.New("bad path")}, false},
+		{errors.New("connection refused"), false},
+	}
+	for _, tt := range tests {
+		if got := IsRetriable(tt.err); got != tt.want {
+			t.Errorf("IsRetriable(%v) = %t, want %t", tt.err, got, tt.want)
+		}
+	}
+}
+
+func TestWithRetry(t *testing.T) {
+	retriable := apierrors.NewTooManyRequests("slow down", 1)
+	fatal := apierrors.NewForbidden(schema.GroupResource{Resource: "widgets"}, "w-1", errors.New("rbac"))
+	backoff := &metav1.Duration{Duration: time.Millisecond}
+
+	tests := []struct {
+		name     string
+		policy   *tofaniov1alpha1.RetryPolicy
+		failures []error
+		calls    int
+		retries  int
+		wantErr  error
+	}{
+		{name: "first attempt succeeds", calls: 1},
+		{
+			name:     "retriable errors are retried",
+			policy:   &tofaniov1alpha1.RetryPolicy{Attempts: 5, Backoff: backoff},
+			failures: []error{retriable, retriable},
+			calls:    3, retries: 2,
+		},
+		{
+			name:     "other errors are not retried",
+			policy:   &tofaniov1alpha1.RetryPolicy{Attempts: 5, Backoff: backoff},
+			failures: []error{fatal, retriable},
+			calls:    1, retries: 0, wantErr: fatal,
+		},
+		{
+			name:     "attempts are capped",
+			policy:   &tofaniov1alpha1.RetryPolicy{Attempts: 2, Backoff: backoff},
+			failures: []error{retriable, retriable, retriable},
+			calls:    2, retries: 1, wantErr: retriable,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testCase := &tofaniov1alpha1.TestCase{Spec: tofaniov1alpha1.TestCaseSpec{Retry: tt.policy}}
+			calls := 0
+			retries, err := withRetry(context.Background(), testCase, func() error {
+				calls++
+				if calls <= len(tt.failures) {
+					return tt.failures[calls-1]
+				}
+				return nil
+			})
+			if calls != tt.calls || retries != tt.retries || err != tt.wantErr {
+				t.Errorf("withRetry() made %d calls and %d retries with %v, want %d, %d and %v",
+					calls, retries, err, tt.calls, tt.retries, tt.wantErr)
+			}
+		})
+	}
+
+	t.Run("cancelled context stops the retries", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		testCase := &tofaniov1alpha1.TestCase{Spec: tofaniov1alpha1.TestCaseSpec{
+			Retry: &tofaniov1alpha1.RetryPolicy{Attempts: 5, Backoff: &metav1.Duration{Duration: time.Hour}},
+		}}
+		retries, err := withRetry(ctx, testCase, func() error { return retriable })
+		if retries != 0 || err != retriable {
+			t.Errorf("withRetry() = %d, %v, want 0 retries and the last error", retries, err)
+		}
+	})
+}
+
+func TestAddError(t *testing.T) {
+	status := &tofaniov1alpha1.TestCaseStatus{}
+	addError(status, apierrors.NewTooManyRequests("first", 1))
+	addError(status, apierrors.NewBadRequest("bad"))
+	addError(status, apierrors.NewTooManyRequests("second", 1))
+
+	if status.Failed != 3 {
+		t.Errorf("Failed = %d, want 3", status.Failed)
+	}
+	want := []tofaniov1alpha1.ErrorCount{
+		{Reason: ErrorReasonTooManyRequests, Count: 2, Message: "second"},
+		{Reason: ErrorReasonInvalid, Count: 1, Message: "bad"},
+	}
+	if len(status.Errors) != len(want) {
+		t.Fatalf("Errors = %+v, want %+v", status.Errors, want)
+	}
+	for i := range want {
+		if status.Errors[i] != want[i] {
+			t.Errorf("Errors[%d] = %+v, want %+v", i, status.Errors[i], want[i])
+		}
+	}
+}
@@ -0,0 +1,275 @@
+package testcase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	tofaniov1alpha1 "github.com/invioteq/tofan/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/tools/cache"
+	watchtools "k8s.io/client-go/tools/watch"
+	"sort"
+	"strings"
+)
+
+const (
+	// AssertionWarningEvents checks that the instances got no more Warning Events than the TestCase allows.
+	AssertionWarningEvents = "warningEvents"
+
+	// topEventReasons is the number of Event reasons listed in a report.
+	topEventReasons = 10
+)
+
+// eventsResource is the resource of the Events collected for the objects of a run.
+var eventsResource = schema.GroupVersionResource{Group: "events.k8s.io", Version: "v1", Resource: "events"}
+
+// eventKey groups the Events of a run.
+type eventKey struct {
+	eventType  string
+	reason     string
+	controller string
+}
+
+// eventRecord is the latest version observed of an Event.
+type eventRecord struct {
+	key eventKey
+	// object is the UID of the object the Event was emitted for
+	object types.UID
+	note   string
+	// at is the time the Event last occurred, formatted as RFC3339
+	at    string
+	count int64
+}
+
+// watchEvents watches the Events emitted for objects of the kind of the instances of the run until ctx
+// is done. The Events of the instances are aggregated in the report of the run, the instances being
+// the objects whose writes were watched or that the run created. The Events of other objects of the
+// kind are dropped as they arrive.
+func (r *Reconciler) watchEvents(ctx context.Context, testCase *tofaniov1alpha1.TestCase, objTpl *tofaniov1alpha1.ObjectTemplate) {
+	resource := r.Dynamic.Resource(eventsResource)
+	// The instances may live in any namespace, their Events are told apart by the UID of their object
+	selector := "regarding.kind=" + objTpl.Status.Kind
+
+	list, err := resource.List(ctx, metav1.ListOptions{FieldSelector: selector})
+	if err != nil {
+		r.Log.Error(err, "Failed to list events", "TestCase", testCase.Name)
+		return
+	}
+	for i := range list.Items {
+		r.measurements.observeEvent(testCase, &list.Items[i])
+	}
+
+	watcher, err := watchtools.NewRetryWatcher(list.GetResourceVersion(), &cache.ListWatch{
+		WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
+			options.FieldSelector = selector
+			return resource.Watch(ctx, options)
+		},
+	})
+	if err != nil {
+		r.Log.Error(err, "Failed to watch events", "TestCase", testCase.Name)
+		return
+	}
+	defer watcher.Stop()
+
+	for {
+		select {
+		case event, ok := <-watcher.ResultChan():
+			if !ok {
+				return
+			}
+			obj, ok := event.Object.(*unstructured.Unstructured)
+			if !ok {
+				continue
+			}
+			// Expired Events are kept, they were emitted during the run
+			if event.Type == watch.Added || event.Type == watch.Modified {
+				r.measurements.observeEvent(testCase, obj)
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// observeEvent records the latest version of an Event for the current run of the TestCase, unless it
+// was emitted for an object that is not an instance of the run.
+func (g *measurementRegistry) observeEvent(testCase *tofaniov1alpha1.TestCase, event *unstructured.Unstructured) {
+	record := newEventRecord(event)
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	m := g.forRun(testCase)
+	if _, ok := m.writes[record.object]; !ok {
+		return
+	}
+	if m.events == nil {
+		m.events = make(map[types.UID]*eventRecord)
+	}
+	m.events[event.GetUID()] = record
+}
+
+// newEventRecord reads an events.k8s.io Event. A series counts every occurrence of the Event, Events
+// converted from the core API count them in deprecatedCount.
+func newEventRecord(event *unstructured.Unstructured) *eventRecord {
+	record := &eventRecord{count: 1}
+	record.key.eventType, _, _ = unstructured.NestedString(event.Object, "type")
+	record.key.reason, _, _ = unstructured.NestedString(event.Object, "reason")
+	record.key.controller, _, _ = unstructured.NestedString(event.Object, "reportingController")
+	if record.key.controller == "" {
+		record.key.controller, _, _ = unstructured.NestedString(event.Object, "deprecatedSource", "component")
+	}
+	uid, _, _ := unstructured.NestedString(event.Object, "regarding", "uid")
+	record.object = types.UID(uid)
+	record.note, _, _ = unstructured.NestedString(event.Object, "note")
+
+	if count, ok, _ := unstructured.NestedInt64(event.Object, "series", "count"); ok && count > record.count {
+		record.count = count
+	}
+	if count, ok, _ := unstructured.NestedInt64(event.Object, "deprecatedCount"); ok && count > record.count {
+		record.count = count
+	}
+	for _, field := range [][]string{{"series", "lastObservedTime"}, {"deprecatedLastTimestamp"}, {"eventTime"}} {
+		if at, ok, _ := unstructured.NestedString(event.Object, field...); ok && at != "" {
+			record.at = at
+			break
+		}
+	}
+	return record
+}
+
+// eventStats aggregates the Events of the objects of the current run of the TestCase, it returns nil
+// when none were observed.
+func (g *measurementRegistry) eventStats(testCase *tofaniov1alpha1.TestCase) *tofaniov1alpha1.EventStats {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	m, ok := g.runs[testCase.UID]
+	if !ok || m.runID != testCase.Status.RunID {
+		return nil
+	}
+	return aggregateEvents(m.events, m.writes)
+}
+
+// aggregateEvents aggregates the Events emitted for the objects whose writes were watched.
+func aggregateEvents(events map[types.UID]*eventRecord, objects map[types.UID]*objectWrites) *tofaniov1alpha1.EventStats {
+	type group struct {
+		reason  tofaniov1alpha1.EventReason
+		objects map[types.UID]bool
+		at      string
+	}
+	groups := map[eventKey]*group{}
+	withEvents := map[types.UID]bool{}
+	stats := &tofaniov1alpha1.EventStats{}
+	for _, record := range events {
+		if _, ok := objects[record.object]; !ok {
+			continue
+		}
+		g, ok := groups[record.key]
+		if !ok {
+			g = &group{
+				reason: tofaniov1alpha1.EventReason{
+					Type:       record.key.eventType,
+					Reason:     record.key.reason,
+					Controller: record.key.controller,
+				},
+				objects: map[types.UID]bool{},
+			}
+			groups[record.key] = g
+		}
+		g.reason.Count += record.count
+		g.objects[record.object] = true
+		if record.at >= g.at {
+			g.at = record.at
+			g.reason.Message = record.note
+		}
+
+		withEvents[record.object] = true
+		stats.Total += record.count
+		if record.key.eventType == corev1.EventTypeWarning {
+			stats.Warnings += record.count
+		}
+	}
+	if len(groups) == 0 {
+		return nil
+	}
+	stats.Objects = len(withEvents)
+
+	for _, g := range groups {
+		g.reason.Objects = len(g.objects)
+		stats.Reasons = append(stats.Reasons, g.reason)
+	}
+	sort.Slice(stats.Reasons, func(i, j int) bool {
+		a, b := stats.Reasons[i], stats.Reasons[j]
+		if warning := a.Type == corev1.EventTypeWarning; warning != (b.Type == corev1.EventTypeWarning) {
+			return warning
+		}
+		if a.Count != b.Count {
+			return a.Count > b.Count
+		}
+		return a.Reason+"/"+a.Controller < b.Reason+"/"+b.Controller
+	})
+	return stats
+}
+
+// addEvents adds the Events of a run to its report, the most frequent reasons only.
+func addEvents(rep *tofaniov1alpha1.Report, stats *tofaniov1alpha1.EventStats) {
+	if stats == nil {
+		return
+	}
+	if len(stats.Reasons) > topEventReasons {
+		stats.Reasons = stats.Reasons[:topEventReasons]
+	}
+	rep.Spec.Events = stats
+}
+
+// CheckEvents checks the Warning Events observed for the instances of the run against the maximum the
+// TestCase allows. A TestCase without a maximum passes at once.
+func (r *Reconciler) CheckEvents(testCase *tofaniov1alpha1.TestCase) error {
+	expected := testCase.Spec.Events
+	if expected == nil || expected.MaxWarnings == nil {
+		return nil
+	}
+	ignored := map[string]bool{}
+	for _, reason := range expected.IgnoredReasons {
+		ignored[reason] = true
+	}
+
+	var warnings int64
+	var reasons []string
+	if stats := r.measurements.eventStats(testCase); stats != nil {
+		warnings = stats.Warnings
+		for _, reason := range stats.Reasons {
+			if reason.Type != corev1.EventTypeWarning {
+				continue
+			}
+			if ignored[reason.Reason] {
+				warnings -= reason.Count
+				continue
+			}
+			if len(reasons) < maxMissingInstances {
+				reasons = append(reasons, fmt.Sprintf("%s (%d)", reason.Reason, reason.Count))
+			}
+		}
+	}
+
+	assertion := tofaniov1alpha1.Assertion{
+		Name:    AssertionWarningEvents,
+		Passed:  warnings <= *expected.MaxWarnings,
+		Message: fmt.Sprintf("%d Warning events, at most %d allowed", warnings, *expected.MaxWarnings),
+	}
+	if !assertion.Passed && len(reasons) > 0 {
+		assertion.Message += ", reasons " + strings.Join(reasons, ", ")
+	}
+	r.measurements.assert(testCase, assertion)
+	if !assertion.Passed {
+		return errors.New(assertion.Message)
+	}
+	r.Log.Info("Warning events checked", "TestCase", testCase.Name, "Warnings", warnings)
+	return nil
+}
@@ -0,0 +1,165 @@
+package testcase
+
+import (
+	"reflect"
+	"testing"
+
+	tofaniov1alpha1 "github.com/invioteq/tofan/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func TestNewEventRecord(t *testing.T) {
+	tests := []struct {
+		name  string
+		event map[string]interface{}
+		want  eventRecord
+	}{
+		{
+			name: "single occurrence",
+			event: map[string]interface{}{
+				"type": "Normal", "reason": "Scheduled", "reportingController": "widget-controller", "note": "scheduled",
+				"regarding": map[string]interface{}{"uid": "w-uid"},
+				"eventTime": "2024-05-01T10:00:00.000000Z",
+			},
+			want: eventRecord{
+				key:    eventKey{eventType: "Normal", reason: "Scheduled", controller: "widget-controller"},
+				object: "w-uid", note: "scheduled", at: "2024-05-01T10:00:00.000000Z", count: 1,
+			},
+		},
+		{
+			name: "series",
+			event: map[string]interface{}{
+				"type": "Warning", "reason": "BackOff", "reportingController": "widget-controller",
+				"regarding": map[string]interface{}{"uid": "w-uid"},
+				"eventTime": "2024-05-01T10:00:00.000000Z",
+				"series":    map[string]interface{}{"count": int64(4), "lastObservedTime": "2024-05-01T10:01:00.000000Z"},
+			},
+			want: eventRecord{
+				key:    eventKey{eventType: "Warning", reason: "BackOff", controller: "widget-controller"},
+				object: "w-uid", at: "2024-05-01T10:01:00.000000Z", count: 4,
+			},
+		},
+		{
+			name: "converted from the core API",
+			event: map[string]interface{}{
+				"type": "Warning", "reason": "Failed",
+				"regarding":               map[string]interface{}{"uid": "w-uid"},
+				"deprecatedSource":        map[string]interface{}{"component": "kubelet"},
+				"deprecatedCount":         int64(3),
+				"deprecatedLastTimestamp": "2024-05-01T10:02:00Z",
+			},
+			want: eventRecord{
+				key:    eventKey{eventType: "Warning", reason: "Failed", controller: "kubelet"},
+				object: "w-uid", at: "2024-05-01T10:02:00Z", count: 3,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := newEventRecord(&unstructured.Unstructured{Object: tt.event})
+			if *got != tt.want {
+				t.Errorf("newEventRecord() = %+v, want %+v", *got, tt.want)
+			}
+		})
+	}
+}
+
+func TestObserveEvent(t *testing.T) {
+	testCase := &tofaniov1alpha1.TestCase{
+		ObjectMeta: metav1.ObjectMeta{UID: "tc-uid"},
+		Status:     tofaniov1alpha1.TestCaseStatus{RunID: "run-1"},
+	}
+	object := func(uid string) *unstructured.Unstructured {
+		obj := &unstructured.Unstructured{Object: map[string]interface{}{}}
+		obj.SetUID(types.UID(uid))
+		return obj
+	}
+	event := func(uid, regarding string) *unstructured.Unstructured {
+		obj := &unstructured.Unstructured{Object: map[string]interface{}{
+			"type": "Warning", "reason": "BackOff", "reportingController": "widget-controller",
+			"regarding": map[string]interface{}{"uid": regarding},
+		}}
+		obj.SetUID(types.UID(uid))
+		return obj
+	}
+
+	var g measurementRegistry
+	g.observeWrite(testCase, object("w-uid"), false)
+	g.observeEvent(testCase, event("e1", "w-uid"))
+	g.observeEvent(testCase, event("e2", "other-uid"))
+
+	m := g.runs[testCase.UID]
+	if _, ok := m.events["e1"]; !ok {
+		t.Error("Event of an instance of the run dropped")
+	}
+	if _, ok := m.events["e2"]; ok {
+		t.Error("Event of another object of the kind kept")
+	}
+}
+
+func TestAggregateEvents(t *testing.T) {
+	objects := map[types.UID]*objectWrites{"a": {}, "b": {}}
+	scheduled := eventKey{eventType: corev1.EventTypeNormal, reason: "Scheduled", controller: "widget-controller"}
+	backOff := eventKey{eventType: corev1.EventTypeWarning, reason: "BackOff", controller: "widget-controller"}
+
+	tests := []struct {
+		name   string
+		events map[types.UID]*eventRecord
+		want   *tofaniov1alpha1.EventStats
+	}{
+		{name: "no events"},
+		{
+			name:   "events of other objects are left out",
+			events: map[types.UID]*eventRecord{"e1": {key: backOff, object: "other", count: 1}},
+		},
+		{
+			name: "grouped by type, reason and controller",
+			events: map[types.UID]*eventRecord{
+				"e1": {key: scheduled, object: "a", note: "first", at: "2024-05-01T10:00:00Z", count: 1},
+				"e2": {key: scheduled, object: "b", note: "second", at: "2024-05-01T10:00:05Z", count: 1},
+				"e3": {key: scheduled, object: "a", note: "again", at: "2024-05-01T10:00:10Z", count: 3},
+				"e4": {key: backOff, object: "b", note: "back-off", at: "2024-05-01T10:00:02Z", count: 2},
+				"e5": {key: backOff, object: "other", count: 7},
+			},
+			want: &tofaniov1alpha1.EventStats{
+				Total:    7,
+				Warnings: 2,
+				Objects:  2,
+				Reasons: []tofaniov1alpha1.EventReason{
+					// Warnings come first, whatever their count
+					{Type: "Warning", Reason: "BackOff", Controller: "widget-controller", Count: 2, Objects: 1, Message: "back-off"},
+					{Type: "Normal", Reason: "Scheduled", Controller: "widget-controller", Count: 5, Objects: 2, Message: "again"},
+				},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := aggregateEvents(tt.events, objects)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("aggregateEvents() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAddEvents(t *testing.T) {
+	stats := &tofaniov1alpha1.EventStats{}
+	for i := 0; i < topEventReasons+2; i++ {
+		stats.Reasons = append(stats.Reasons, tofaniov1alpha1.EventReason{Reason: "Reason", Count: int64(i)})
+	}
+	rep := &tofaniov1alpha1.Report{}
+	addEvents(rep, stats)
+	if len(rep.Spec.Events.Reasons) != topEventReasons {
+		t.Errorf("addEvents() kept %d reasons, want %d", len(rep.Spec.Events.Reasons), topEventReasons)
+	}
+
+	empty := &tofaniov1alpha1.Report{}
+	addEvents(empty, nil)
+	if empty.Spec.Events != nil {
+		t.Errorf("addEvents() without events = %+v, want nil", empty.Spec.Events)
+	}
+}
@@ -0,0 +1,271 @@
+package testcase
+
+import (
+	"context"
+	"fmt"
+	tofaniov1alpha1 "github.com/invioteq/tofan/api/v1alpha1"
+	"github.com/invioteq/tofan/pkg/report"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"regexp"
+	"strings"
+	"time"
+)
+
+const (
+	// LatencyTimeToReject is the name of the latency distribution of the create requests the API server
+	// rejected as a negative test expects.
+	LatencyTimeToReject = "timeToReject"
+	// LatencyTimeToFailure is the name of the latency distribution from the creation of an instance until
+	// the target controller set the failure condition a negative test expects.
+	LatencyTimeToFailure = "timeToFailure"
+
+	// AssertionExpectedFailure checks that every instance of a negative test failed as expected.
+	AssertionExpectedFailure = "expectedFailure"
+
+	// defaultFailureTimeout is how long instances may take to get the failure condition when the TestCase sets no timeout.
+	defaultFailureTimeout = 5 * time.Minute
+	// failureCheckInterval is how often the conditions of the instances of a negative test are checked.
+	failureCheckInterval = 5 * time.Second
+)
+
+// expectedFailure is the compiled ExpectedFailure of a TestCase.
+type expectedFailure struct {
+	admission *tofaniov1alpha1.AdmissionFailure
+	condition *tofaniov1alpha1.ConditionFailure
+	message   *regexp.Regexp
+}
+
+// compileExpectedFailure compiles the ExpectedFailure of the TestCase, it returns nil when the TestCase
+// does not expect its instances to fail.
+func compileExpectedFailure(testCase *tofaniov1alpha1.TestCase) (*expectedFailure, error) {
+	spec := testCase.Spec.ExpectedFailure
+	if spec == nil {
+		return nil, nil
+	}
+
+	expected := &expectedFailure{admission: spec.Admission, condition: spec.Condition}
+	var pattern string
+	switch {
+	case spec.Admission != nil && spec.Condition != nil:
+		return nil, fmt.Errorf("expectedFailure sets both admission and condition")
+	case spec.Admission != nil:
+		pattern = spec.Admission.MessagePattern
+	case spec.Condition != nil:
+		pattern = spec.Condition.MessagePattern
+	default:
+		return nil, fmt.Errorf("expectedFailure sets neither admission nor condition")
+	}
+	if pattern != "" {
+		message, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid messagePattern %s: %w", pattern, err)
+		}
+		expected.message = message
+	}
+	return expected, nil
+}
+
+// rejects reports whether err is the rejection of an instance the TestCase expects.
+func (e *expectedFailure) rejects(err error) bool {
+	if e == nil || e.admission == nil || err == nil {
+		return false
+	}
+	if e.admission.Reason != "" && ErrorReason(err) != e.admission.Reason {
+		return false
+	}
+	return e.message == nil || e.message.MatchString(err.Error())
+}
+
+// failedAt returns the time the resource got the failure condition the TestCase expects.
+func (e *expectedFailure) failedAt(resource *unstructured.Unstructured) (time.Time, bool) {
+	if e == nil || e.condition == nil {
+		return time.Time{}, false
+	}
+	conditionType, status := e.conditionTypeStatus()
+
+	conditions, _, _ := unstructured.NestedSlice(resource.Object, "status", "conditions")
+	for _, cond := range conditions {
+		condition, ok := cond.(map[string]interface{})
+		if !ok || condition["type"] != conditionType {
+			continue
+		}
+		reason, _ := condition["reason"].(string)
+		message, _ := condition["message"].(string)
+		if condition["status"] != string(status) ||
+			(e.condition.Reason != "" && reason != e.condition.Reason) ||
+			(e.message != nil && !e.message.MatchString(message)) {
+			return time.Time{}, false
+		}
+		raw, _ := condition["lastTransitionTime"].(string)
+		transition, err := time.Parse(time.RFC3339, raw)
+		if err != nil {
+			transition = time.Now()
+		}
+		return transition, true
+	}
+	return time.Time{}, false
+}
+
+// conditionTypeStatus returns the type and status of the expected failure condition, defaulted.
+func (e *expectedFailure) conditionTypeStatus() (string, metav1.ConditionStatus) {
+	conditionType, status := e.condition.Type, e.condition.Status
+	if conditionType == "" {
+		conditionType = "Ready"
+	}
+	if status == "" {
+		status = metav1.ConditionFalse
+	}
+	return conditionType, status
+}
+
+// describe returns the failure in the words of a message.
+func (e *expectedFailure) describe() string {
+	if e.admission != nil {
+		msg := "were rejected"
+		if e.admission.Reason != "" {
+			msg += " with reason " + e.admission.Reason
+		}
+		if e.message != nil {
+			msg += fmt.Sprintf(" matching %q", e.message.String())
+		}
+		return msg
+	}
+
+	conditionType, status := e.conditionTypeStatus()
+	msg := fmt.Sprintf("have %s=%s", conditionType, status)
+	if e.condition.Reason != "" {
+		msg += " with reason " + e.condition.Reason
+	}
+	if e.message != nil {
+		msg += fmt.Sprintf(" matching %q", e.message.String())
+	}
+	return msg
+}
+
+// failureCheck is the outcome of the expected failure for the instances of a run.
+type failureCheck struct {
+	expected  int
+	failed    int
+	samples   []time.Duration
+	unfailed  []string
+	described string
+}
+
+func (c *failureCheck) passed() bool {
+	return c.expected > 0 && c.failed == c.expected
+}
+
+func (c *failureCheck) message() string {
+	msg := fmt.Sprintf("%d of %d instances %s", c.failed, c.expected, c.described)
+	if len(c.unfailed) > 0 {
+		msg += ", not for " + strings.Join(c.unfailed, ", ")
+		if missing := c.expected - c.failed; missing > len(c.unfailed) {
+			msg += fmt.Sprintf(" and %d more", missing-len(c.unfailed))
+		}
+	}
+	return msg
+}
+
+// checkFailure checks the instances of the TestCase against the failure it expects. Rejections are
+// taken from the status of the TestCase, failure conditions from the resources.
+func checkFailure(expected *expectedFailure, testCase *tofaniov1alpha1.TestCase, resources []unstructured.Unstructured) failureCheck {
+	check := failureCheck{expected: testCase.Spec.Count, described: expected.describe()}
+	if expected.admission != nil {
+		check.failed = testCase.Status.Rejected
+		for i := range resources {
+			if len(check.unfailed) < maxMissingInstances {
+				check.unfailed = append(check.unfailed, resources[i].GetName())
+			}
+		}
+		return check
+	}
+
+	for i := range resources {
+		at, ok := expected.failedAt(&resources[i])
+		if !ok {
+			if len(check.unfailed) < maxMissingInstances {
+				check.unfailed = append(check.unfailed, resources[i].GetName())
+			}
+			continue
+		}
+		check.failed++
+		sample := at.Sub(resources[i].GetCreationTimestamp().Time)
+		if sample < 0 {
+			sample = 0
+		}
+		check.samples = append(check.samples, sample)
+	}
+	return check
+}
+
+// WaitForExpectedFailure blocks until every instance of the negative TestCase failed the way it
+// expects, checking them every interval. Rejections happen as the instances are created, so a
+// TestCase expecting them is checked once. It fails once the timeout of the expected failure
+// elapsed, and returns the context error when ctx is done first.
+func (r *Reconciler) WaitForExpectedFailure(ctx context.Context, testCase *tofaniov1alpha1.TestCase, objTpl *tofaniov1alpha1.ObjectTemplate, interval time.Duration) error {
+	expected, err := compileExpectedFailure(testCase)
+	if err != nil || expected == nil {
+		return err
+	}
+	timeout := defaultFailureTimeout
+	if testCase.Spec.ExpectedFailure.Timeout != nil {
+		timeout = testCase.Spec.ExpectedFailure.Timeout.Duration
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	expired := false
+	for {
+		resources, err := r.listTestCaseResources(ctx, testCase, objTpl)
+		if err != nil {
+			r.Log.Error(err, "Error checking expected failure", "TestCase", testCase.Name)
+		}
+		check := checkFailure(expected, testCase, resources)
+		if err == nil && check.passed() {
+			r.Log.Info("Instances failed as expected", "TestCase", testCase.Name)
+			return nil
+		}
+		if err == nil && expected.admission != nil {
+			return fmt.Errorf("instances were not rejected as expected: %s", check.message())
+		}
+		if expired {
+			if err != nil {
+				return fmt.Errorf("expected failure not verified within %s: %w", timeout, err)
+			}
+			return fmt.Errorf("instances did not fail as expected after %s: %s", timeout, check.message())
+		}
+		r.Log.Info("Expected failure check result", "TestCase", testCase.Name, "Failed", check.failed, "Expected", check.expected)
+
+		select {
+		case <-ticker.C:
+		case <-timer.C:
+			expired = true
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// addExpectedFailure adds the outcome of the expected failure of a negative TestCase to its report,
+// it replaces the readiness check its instances are not meant to pass.
+func addExpectedFailure(rep *tofaniov1alpha1.Report, testCase *tofaniov1alpha1.TestCase, resources []unstructured.Unstructured) {
+	expected, err := compileExpectedFailure(testCase)
+	if err != nil {
+		rep.Spec.Assertions = append(rep.Spec.Assertions, tofaniov1alpha1.Assertion{Name: AssertionExpectedFailure, Message: err.Error()})
+		return
+	}
+
+	check := checkFailure(expected, testCase, resources)
+	if expected.condition != nil {
+		rep.Spec.Latencies = append(rep.Spec.Latencies, report.Summarize(LatencyTimeToFailure, check.samples))
+	}
+	rep.Spec.Assertions = append(rep.Spec.Assertions, tofaniov1alpha1.Assertion{
+		Name:    AssertionExpectedFailure,
+		Passed:  check.passed(),
+		Message: check.message(),
+	})
+}
@@ -0,0 +1,153 @@
+package testcase
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	tofaniov1alpha1 "github.com/invioteq/tofan/api/v1alpha1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func TestCompileExpectedFailure(t *testing.T) {
+	tests := []struct {
+		name     string
+		spec     *tofaniov1alpha1.ExpectedFailure
+		wantNil  bool
+		wantErr  bool
+		describe string
+	}{
+		{name: "not a negative test", wantNil: true},
+		{
+			name:     "admission",
+			spec:     &tofaniov1alpha1.ExpectedFailure{Admission: &tofaniov1alpha1.AdmissionFailure{Reason: ErrorReasonWebhookDenied, MessagePattern: "size .* too large"}},
+			describe: `were rejected with reason WebhookDenied matching "size .* too large"`,
+		},
+		{
+			name:     "condition with defaults",
+			spec:     &tofaniov1alpha1.ExpectedFailure{Condition: &tofaniov1alpha1.ConditionFailure{Reason: "InvalidSpec"}},
+			describe: "have Ready=False with reason InvalidSpec",
+		},
+		{
+			name:    "both",
+			spec:    &tofaniov1alpha1.ExpectedFailure{Admission: &tofaniov1alpha1.AdmissionFailure{}, Condition: &tofaniov1alpha1.ConditionFailure{}},
+			wantErr: true,
+		},
+		{name: "neither", spec: &tofaniov1alpha1.ExpectedFailure{}, wantErr: true},
+		{
+			name:    "invalid pattern",
+			spec:    &tofaniov1alpha1.ExpectedFailure{Admission: &tofaniov1alpha1.AdmissionFailure{MessagePattern: "("}},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testCase := &tofaniov1alpha1.TestCase{Spec: tofaniov1alpha1.TestCaseSpec{ExpectedFailure: tt.spec}}
+			got, err := compileExpectedFailure(testCase)
+			switch {
+			case tt.wantErr:
+				if err == nil {
+					t.Error("compileExpectedFailure() returned no error")
+				}
+			case err != nil:
+				t.Fatalf("compileExpectedFailure() error = %v", err)
+			case tt.wantNil:
+				if got != nil {
+					t.Errorf("compileExpectedFailure() = %+v, want nil", got)
+				}
+			case got.describe() != tt.describe:
+				t.Errorf("describe() = %s, want %s", got.describe(), tt.describe)
+			}
+		})
+	}
+}
+
+func TestExpectedFailureRejects(t *testing.T) {
+	widgets := schema.GroupResource{Group: "simulator.tofan.io", Resource: "widgets"}
+	denied := apierrors.NewForbidden(widgets, "w-1", errors.New(`admission webhook "validate.tofan.io" denied the request: size 12 too large`))
+	invalid := apierrors.NewInvalid(schema.GroupKind{Kind: "Widget"}, "w-1", nil)
+
+	tests := []struct {
+		name      string
+		admission *tofaniov1alpha1.AdmissionFailure
+		err       error
+		want      bool
+	}{
+		{"any rejection", &tofaniov1alpha1.AdmissionFailure{}, invalid, true},
+		{"no error", &tofaniov1alpha1.AdmissionFailure{}, nil, false},
+		{"matching reason", &tofaniov1alpha1.AdmissionFailure{Reason: ErrorReasonWebhookDenied}, denied, true},
+		{"other reason", &tofaniov1alpha1.AdmissionFailure{Reason: ErrorReasonWebhookDenied}, invalid, false},
+		{"matching message", &tofaniov1alpha1.AdmissionFailure{MessagePattern: `size \d+ too large`}, denied, true},
+		{"other message", &tofaniov1alpha1.AdmissionFailure{MessagePattern: "quota"}, denied, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testCase := &tofaniov1alpha1.TestCase{Spec: tofaniov1alpha1.TestCaseSpec{
+				ExpectedFailure: &tofaniov1alpha1.ExpectedFailure{Admission: tt.admission},
+			}}
+			expected, err := compileExpectedFailure(testCase)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := expected.rejects(tt.err); got != tt.want {
+				t.Errorf("rejects(%v) = %t, want %t", tt.err, got, tt.want)
+			}
+		})
+	}
+
+	var none *expectedFailure
+	if none.rejects(denied) {
+		t.Error("a TestCase expecting no failure rejects errors")
+	}
+}
+
+func TestExpectedFailureFailedAt(t *testing.T) {
+	transition := time.Date(2024, 5, 1, 10, 0, 5, 0, time.UTC)
+	withCondition := func(conditions ...interface{}) *unstructured.Unstructured {
+		return &unstructured.Unstructured{Object: map[string]interface{}{
+			"status": map[string]interface{}{"conditions": conditions},
+		}}
+	}
+	condition := func(conditionType, status, reason, message string) map[string]interface{} {
+		return map[string]interface{}{
+			"type": conditionType, "status": status, "reason": reason, "message": message,
+			"lastTransitionTime": transition.Format(time.RFC3339),
+		}
+	}
+
+	tests := []struct {
+		name      string
+		condition *tofaniov1alpha1.ConditionFailure
+		resource  *unstructured.Unstructured
+		want      bool
+	}{
+		{"defaults to Ready=False", &tofaniov1alpha1.ConditionFailure{}, withCondition(condition("Ready", "False", "InvalidSpec", "bad size")), true},
+		{"still ready", &tofaniov1alpha1.ConditionFailure{}, withCondition(condition("Ready", "True", "", "")), false},
+		{"no conditions", &tofaniov1alpha1.ConditionFailure{}, withCondition(), false},
+		{"other type", &tofaniov1alpha1.ConditionFailure{Type: "Degraded", Status: "True"}, withCondition(condition("Ready", "False", "", "")), false},
+		{"matching type", &tofaniov1alpha1.ConditionFailure{Type: "Degraded", Status: "True"}, withCondition(condition("Ready", "True", "", ""), condition("Degraded", "True", "", "")), true},
+		{"other reason", &tofaniov1alpha1.ConditionFailure{Reason: "Quota"}, withCondition(condition("Ready", "False", "InvalidSpec", "")), false},
+		{"matching message", &tofaniov1alpha1.ConditionFailure{MessagePattern: "bad"}, withCondition(condition("Ready", "False", "", "bad size")), true},
+		{"other message", &tofaniov1alpha1.ConditionFailure{MessagePattern: "quota"}, withCondition(condition("Ready", "False", "", "bad size")), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testCase := &tofaniov1alpha1.TestCase{Spec: tofaniov1alpha1.TestCaseSpec{
+				ExpectedFailure: &tofaniov1alpha1.ExpectedFailure{Condition: tt.condition},
+			}}
+			expected, err := compileExpectedFailure(testCase)
+			if err != nil {
+				t.Fatal(err)
+			}
+			at, ok := expected.failedAt(tt.resource)
+			if ok != tt.want {
+				t.Fatalf("failedAt() = %t, want %t", ok, tt.want)
+			}
+			if ok && !at.Equal(transition) {
+				t.Errorf("failedAt() = %s, want the transition time %s", at, transition)
+			}
+		})
+	}
+}
@@ -0,0 +1,199 @@
+package testcase
+
+import (
+	"context"
+	tofaniov1alpha1 "github.com/invioteq/tofan/api/v1alpha1"
+	"github.com/invioteq/tofan/internal/metrics"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	utilnet "k8s.io/apimachinery/pkg/util/net"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/util/flowcontrol"
+	"net/http"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// throttledWait is the shortest wait for the rate limiter counted as the request being throttled.
+const throttledWait = time.Millisecond
+
+// loadClient is the client a run creates its objects with. It has a rate limiter of its own, so the
+// load generated does not compete with the reconciles of the operator, and counts how the requests
+// it sends are throttled.
+type loadClient struct {
+	client.Client
+	runID string
+	// namespace and testCase label the metrics of the client
+	namespace, testCase string
+	// transport holds the connections of the client, they are closed with it
+	transport http.RoundTripper
+
+	sent            atomic.Int64
+	clientThrottled atomic.Int64
+	throttledNanos  atomic.Int64
+	serverThrottled atomic.Int64
+}
+
+// stats returns the requests sent by the client so far.
+func (c *loadClient) stats() *tofaniov1alpha1.RequestStats {
+	if c == nil {
+		return nil
+	}
+	return &tofaniov1alpha1.RequestStats{
+		Sent:                c.sent.Load(),
+		ClientThrottled:     c.clientThrottled.Load(),
+		ClientThrottledTime: metav1.Duration{Duration: time.Duration(c.throttledNanos.Load())},
+		ServerThrottled:     c.serverThrottled.Load(),
+	}
+}
+
+// close closes the idle connections of the client. Requests still in flight keep theirs until they
+// are done.
+func (c *loadClient) close() {
+	utilnet.CloseIdleConnectionsFor(c.transport)
+}
+
+// loadClientRegistry holds the load clients of the runs of the TestCases, keyed by TestCase UID. A
+// client lives as long as its run, a resumed run keeps counting with it. The zero value is ready to use.
+type loadClientRegistry struct {
+	mu      sync.Mutex
+	clients map[types.UID]*loadClient
+}
+
+// get returns the load client of the current run of the TestCase, or nil when it has none.
+func (g *loadClientRegistry) get(testCase *tofaniov1alpha1.TestCase) *loadClient {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if c, ok := g.clients[testCase.UID]; ok && c.runID == testCase.Status.RunID {
+		return c
+	}
+	return nil
+}
+
+// release closes and forgets the load client of the TestCase.
+func (g *loadClientRegistry) release(uid types.UID) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if c, ok := g.clients[uid]; ok {
+		c.close()
+		delete(g.clients, uid)
+	}
+}
+
+// loadClientFor returns the client the instances of the TestCase are created with, creating it for
+// the run when needed. Without a REST config the shared client of the Reconciler is returned.
+func (r *Reconciler) loadClientFor(testCase *tofaniov1alpha1.TestCase) (client.Client, error) {
+	if r.Config == nil {
+		return r.Client, nil
+	}
+	r.loadClients.mu.Lock()
+	defer r.loadClients.mu.Unlock()
+	if c, ok := r.loadClients.clients[testCase.UID]; ok && c.runID == testCase.Status.RunID {
+		return c, nil
+	}
+
+	c := &loadClient{runID: testCase.Status.RunID, namespace: testCase.Namespace, testCase: testCase.Name}
+	cfg := rest.CopyConfig(r.Config)
+	qps, burst := r.LoadQPS, r.LoadBurst
+	if limit := testCase.Spec.ClientRateLimit; limit != nil {
+		qps, burst = float32(limit.QPS), int(limit.Burst)
+	}
+	if qps > 0 {
+		if burst < 1 {
+			burst = int(qps)
+		}
+		cfg.QPS, cfg.Burst = qps, burst
+		cfg.RateLimiter = &measuredRateLimiter{
+			RateLimiter: flowcontrol.NewTokenBucketRateLimiter(qps, burst),
+			client:      c,
+		}
+	} else {
+		// A negative QPS keeps client-go from installing its default rate limiter
+		cfg.QPS, cfg.RateLimiter = -1, nil
+	}
+	cfg.Wrap(func(rt http.RoundTripper) http.RoundTripper {
+		return &countingRoundTripper{next: rt, client: c}
+	})
+	// client-go shares its transports between configs with the same TLS settings, unless they set a
+	// proxy. The run gets connections of its own, closed when it ends.
+	if cfg.Proxy == nil {
+		cfg.Proxy = http.ProxyFromEnvironment
+	}
+
+	httpClient, err := rest.HTTPClientFor(cfg)
+	if err != nil {
+		return nil, err
+	}
+	c.transport = httpClient.Transport
+	c.Client, err = client.New(cfg, client.Options{HTTPClient: httpClient, Scheme: r.Scheme, Mapper: r.RESTMapper()})
+	if err != nil {
+		c.close()
+		return nil, err
+	}
+
+	if r.loadClients.clients == nil {
+		r.loadClients.clients = make(map[types.UID]*loadClient)
+	}
+	// The client of a previous run of the TestCase is done
+	if previous, ok := r.loadClients.clients[testCase.UID]; ok {
+		previous.close()
+	}
+	r.loadClients.clients[testCase.UID] = c
+	r.Log.Info("Created load client", "TestCase", testCase.Name, "RunID", testCase.Status.RunID, "QPS", qps, "Burst", burst)
+	return c, nil
+}
+
+// measuredRateLimiter accounts the time requests wait for the rate limiter it wraps.
+type measuredRateLimiter struct {
+	flowcontrol.RateLimiter
+	client *loadClient
+}
+
+func (l *measuredRateLimiter) Wait(ctx context.Context) error {
+	start := time.Now()
+	err := l.RateLimiter.Wait(ctx)
+	if waited := time.Since(start); waited >= throttledWait {
+		l.client.clientThrottled.Add(1)
+		l.client.throttledNanos.Add(int64(waited))
+		metrics.ClientThrottleSeconds.WithLabelValues(l.client.namespace, l.client.testCase).Add(waited.Seconds())
+	}
+	return err
+}
+
+// sentTimeKey is the context key of the time a request sent by a load client is recorded in.
+type sentTimeKey struct{}
+
+// withSentTime returns a context that has a load client record in at the time it sent the requests made
+// with it, once the client-side rate limiter let them through. Other clients leave at as it is.
+func withSentTime(ctx context.Context, at *time.Time) context.Context {
+	return context.WithValue(ctx, sentTimeKey{}, at)
+}
+
+// countingRoundTripper counts the requests sent and those the API server throttled. client-go
+// retries throttled requests on its own, so they are counted here rather than from the errors returned.
+type countingRoundTripper struct {
+	next   http.RoundTripper
+	client *loadClient
+}
+
+// WrappedRoundTripper returns the transport the requests are sent with, so its idle connections
+// can be closed.
+func (t *countingRoundTripper) WrappedRoundTripper() http.RoundTripper {
+	return t.next
+}
+
+func (t *countingRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	if at, ok := req.Context().Value(sentTimeKey{}).(*time.Time); ok {
+		*at = time.Now()
+	}
+	t.client.sent.Add(1)
+	resp, err := t.next.RoundTrip(req)
+	if err == nil && resp.StatusCode == http.StatusTooManyRequests {
+		t.client.serverThrottled.Add(1)
+		metrics.ThrottledRequests.WithLabelValues(t.client.namespace, t.client.testCase).Inc()
+	}
+	return resp, err
+}
@@ -0,0 +1,71 @@
+package testcase
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	tofaniov1alpha1 "github.com/invioteq/tofan/api/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+type idleCloser struct {
+	http.RoundTripper
+	closed bool
+}
+
+func (t *idleCloser) CloseIdleConnections() {
+	t.closed = true
+}
+
+func TestLoadClientRelease(t *testing.T) {
+	transport := &idleCloser{}
+	c := &loadClient{runID: "run-1", transport: &countingRoundTripper{next: transport}}
+	g := loadClientRegistry{clients: map[types.UID]*loadClient{"tc-uid": c}}
+
+	g.release("tc-uid")
+	if !transport.closed {
+		t.Error("release() left the idle connections of the client open")
+	}
+	testCase := &tofaniov1alpha1.TestCase{ObjectMeta: metav1.ObjectMeta{UID: "tc-uid"}, Status: tofaniov1alpha1.TestCaseStatus{RunID: "run-1"}}
+	if got := g.get(testCase); got != nil {
+		t.Errorf("get() after release() = %v, want nil", got)
+	}
+}
+
+func TestCountingRoundTripper(t *testing.T) {
+	statuses := []int{http.StatusOK, http.StatusTooManyRequests, http.StatusOK}
+	c := &loadClient{namespace: "default", testCase: "widgets"}
+	rt := &countingRoundTripper{client: c, next: roundTripperFunc(func(*http.Request) (*http.Response, error) {
+		status := statuses[0]
+		statuses = statuses[1:]
+		return &http.Response{StatusCode: status}, nil
+	})}
+
+	// The request waited for the rate limiter before it reached the transport
+	before := time.Now().Add(-time.Second)
+	sent := before
+	req, err := http.NewRequestWithContext(withSentTime(context.Background(), &sent), http.MethodPatch, "https://api/widgets/w-0", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 3; i++ {
+		if _, err := rt.RoundTrip(req); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if !sent.After(before) {
+		t.Errorf("sent time = %s, want the time the request reached the transport", sent)
+	}
+	if stats := c.stats(); stats.Sent != 3 || stats.ServerThrottled != 1 {
+		t.Errorf("stats = %+v, want 3 requests sent and 1 throttled by the server", stats)
+	}
+}
@@ -0,0 +1,175 @@
+package testcase
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/go-logr/logr"
+	tofaniov1alpha1 "github.com/invioteq/tofan/api/v1alpha1"
+	"github.com/invioteq/tofan/internal/common"
+	"github.com/invioteq/tofan/pkg/utils"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/dynamic"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// localTeardownTimeout bounds the reporting and teardown that follow a local run.
+const localTeardownTimeout = deletionTimeout
+
+// LocalRunner executes TestCases without the operator. It drives the same workflow, readiness
+// tracking and teardown as the Reconciler, but keeps the TestCase and ObjectTemplate in memory, so
+// the tofan CRDs do not have to be installed in the target cluster.
+type LocalRunner struct {
+	Reconciler *Reconciler
+	// Interval is how often the readiness of the created objects is checked
+	Interval time.Duration
+	// Timeout bounds how long the runner waits for the objects to become ready, zero waits forever
+	Timeout time.Duration
+	// SkipTeardown leaves the created objects in the cluster after the run
+	SkipTeardown bool
+	// DiagnosticsDir is the directory the snapshots of the objects of a failed or aborted run are written to,
+	// in a subdirectory named after its report. Empty captures no snapshots
+	DiagnosticsDir string
+}
+
+// NewLocalRunner creates a LocalRunner talking to the API server of the given config.
+func NewLocalRunner(cfg *rest.Config, log logr.Logger) (*LocalRunner, error) {
+	scheme := runtime.NewScheme()
+	if err := clientgoscheme.AddToScheme(scheme); err != nil {
+		return nil, err
+	}
+
+	c, err := client.New(cfg, client.Options{Scheme: scheme})
+	if err != nil {
+		return nil, err
+	}
+	dynamicClient, err := dynamic.NewForConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	return &LocalRunner{
+		Reconciler: &Reconciler{
+			Reconciler: common.Reconciler{
+				Client: c,
+				Scheme: scheme,
+				// Events are dropped, there is no TestCase in the cluster to attach them to
+				Recorder: &record.FakeRecorder{},
+				Log:      log,
+			},
+			Dynamic:    dynamicClient,
+			Standalone: true,
+			Config:     cfg,
+		},
+		Interval: 5 * time.Second,
+	}, nil
+}
+
+// Run creates the objects of the TestCase, waits until they are ready or the timeout expires, tears
+// them down and returns the Report of the run. testCase.Status is updated as the run progresses,
+// cancelling ctx aborts the run.
+func (l *LocalRunner) Run(ctx context.Context, testCase *tofaniov1alpha1.TestCase, objTpl *tofaniov1alpha1.ObjectTemplate) (*tofaniov1alpha1.Report, error) {
+	r := l.Reconciler
+
+	// The ObjectTemplate controller is not running, resolve the template type here
+	kind, group, version, err := utils.ExtractKindAndAPIVersion(objTpl)
+	if err != nil {
+		return nil, fmt.Errorf("invalid ObjectTemplate %s: %w", objTpl.Name, err)
+	}
+	objTpl.Status.Group, objTpl.Status.Version, objTpl.Status.Kind = group, version, kind
+
+	start := metav1.Now()
+	testCase.Status.Phase = StatusInProgress
+	if testCase.Status.RunID == "" {
+		testCase.Status.RunID = utils.GenerateRandomString(5)
+	}
+	testCase.Status.StartTime = &start
+	r.traces.begin(testCase)
+	r.measurements.begin(testCase)
+	watchCtx, stopWatch := context.WithCancel(ctx)
+	defer stopWatch()
+	go r.watchWrites(watchCtx, testCase.DeepCopy(), objTpl)
+	go r.watchEvents(watchCtx, testCase.DeepCopy(), objTpl)
+	r.scrapeAPIServerBaseline(ctx, testCase)
+
+	runErr := r.ProcessTestCase(ctx, objTpl, testCase)
+	if runErr == nil {
+		waitCtx := ctx
+		if l.Timeout > 0 {
+			var cancel context.CancelFunc
+			waitCtx, cancel = context.WithTimeout(ctx, l.Timeout)
+			defer cancel()
+		}
+		runErr = r.verifyRun(waitCtx, testCase, objTpl, l.Interval)
+	}
+
+	phase := StatusCompleted
+	switch {
+	case ctx.Err() != nil:
+		// The run was interrupted, report what was created so far
+		phase = StatusAborted
+		runErr = nil
+	case runErr != nil:
+		phase = StatusError
+		r.traces.get(testCase.UID).fail(runErr)
+	}
+
+	// The run context may be cancelled by now, finish the run with a context of its own
+	finishCtx, cancel := context.WithTimeout(context.Background(), localTeardownTimeout)
+	defer cancel()
+
+	rep, snapshots := r.buildRunReport(finishCtx, testCase, objTpl, phase, runErr, l.DiagnosticsDir != "")
+	if rep.Spec.Diagnostics != nil {
+		dir := filepath.Join(l.DiagnosticsDir, rep.Name)
+		if err := writeDiagnostics(dir, snapshots); err != nil {
+			r.Log.Error(err, "Failed to write diagnostics", "Path", dir)
+		} else {
+			rep.Spec.Diagnostics.Path = dir
+		}
+	}
+	defer r.measurements.release(testCase.UID)
+
+	completion := metav1.Now()
+	testCase.Status.Phase = phase
+	testCase.Status.CompletionTime = &completion
+	testCase.Status.ReportRef = rep.Name
+
+	teardown := !l.SkipTeardown && ShouldTeardown(testCase.Spec.CleanupPolicy, phase)
+	if phase == StatusAborted {
+		teardown = !l.SkipTeardown && (testCase.Spec.TeardownOnAbort == nil || *testCase.Spec.TeardownOnAbort)
+	}
+	if !teardown {
+		r.traces.end(testCase, nil)
+		return rep, nil
+	}
+
+	if delay := cleanupDelay(testCase); delay > 0 && phase != StatusAborted {
+		r.Log.Info("Keeping resources until the cleanup delay elapsed", "TestCase", testCase.Name, "Delay", delay)
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+		}
+	}
+
+	teardownCtx, cancelTeardown := context.WithTimeout(context.Background(), localTeardownTimeout)
+	defer cancelTeardown()
+	samples, err := r.MeasureTeardown(teardownCtx, testCase, objTpl)
+	if err == nil {
+		err = r.TeardownNamespaces(teardownCtx, testCase)
+	}
+	AddTeardown(rep, samples, err)
+	r.traces.end(testCase, err)
+	if err != nil {
+		return rep, fmt.Errorf("failed to tear down objects: %w", err)
+	}
+	cleanup := metav1.Now()
+	testCase.Status.CleanupTime = &cleanup
+
+	return rep, nil
+}
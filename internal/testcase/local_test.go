@@ -0,0 +1,176 @@
+package testcase
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	tofaniov1alpha1 "github.com/invioteq/tofan/api/v1alpha1"
+	"github.com/invioteq/tofan/internal/simulator"
+	rbacv1 "k8s.io/api/rbac/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/envtest"
+	"sigs.k8s.io/yaml"
+)
+
+// startEnv starts an API server serving the simulator CRD, with the simulated controller running
+// against it, and returns the config of a user bound to the manager role only, so the run proves the
+// RBAC of the operator. The test is skipped when the envtest binaries are not installed.
+func startEnv(t *testing.T) *rest.Config {
+	t.Helper()
+	if os.Getenv("KUBEBUILDER_ASSETS") == "" {
+		t.Skip("KUBEBUILDER_ASSETS not set, run the tests with make test")
+	}
+
+	env := &envtest.Environment{
+		CRDs: []*apiextensionsv1.CustomResourceDefinition{simulator.CRD(simulator.WidgetGVK)},
+	}
+	cfg, err := env.Start()
+	if err != nil {
+		t.Fatalf("failed to start envtest: %v", err)
+	}
+	t.Cleanup(func() {
+		if err := env.Stop(); err != nil {
+			t.Errorf("failed to stop envtest: %v", err)
+		}
+	})
+
+	scheme := runtime.NewScheme()
+	if err := clientgoscheme.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	admin, err := client.New(cfg, client.Options{Scheme: scheme})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// envtest runs no aggregation controller, the role granting access to Widgets is bound directly
+	for _, path := range []string{
+		filepath.Join("..", "..", "config", "rbac", "role.yaml"),
+		filepath.Join("..", "..", "config", "simulator", "role.yaml"),
+	} {
+		raw, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		role := &rbacv1.ClusterRole{}
+		if err := yaml.Unmarshal(raw, role); err != nil {
+			t.Fatal(err)
+		}
+		binding := &rbacv1.ClusterRoleBinding{
+			ObjectMeta: metav1.ObjectMeta{Name: role.Name + "-binding"},
+			RoleRef:    rbacv1.RoleRef{APIGroup: rbacv1.GroupName, Kind: "ClusterRole", Name: role.Name},
+			Subjects:   []rbacv1.Subject{{APIGroup: rbacv1.GroupName, Kind: rbacv1.UserKind, Name: "tofan"}},
+		}
+		for _, obj := range []client.Object{role, binding} {
+			if err := admin.Create(context.Background(), obj); err != nil {
+				t.Fatalf("failed to create %s: %v", obj.GetName(), err)
+			}
+		}
+	}
+	user, err := env.AddUser(envtest.User{Name: "tofan"}, cfg)
+	if err != nil {
+		t.Fatalf("failed to add user: %v", err)
+	}
+
+	mgr, err := ctrl.NewManager(cfg, ctrl.Options{Scheme: scheme, MetricsBindAddress: "0"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = (&simulator.Reconciler{
+		Client:  mgr.GetClient(),
+		Log:     logr.Discard(),
+		GVK:     simulator.WidgetGVK,
+		Latency: simulator.Latency{Distribution: simulator.LatencyUniform, Mean: 300 * time.Millisecond, StdDev: 200 * time.Millisecond},
+		Workers: 4,
+	}).SetupWithManager(mgr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		if err := mgr.Start(ctx); err != nil {
+			t.Errorf("simulator stopped: %v", err)
+		}
+	}()
+	// Registered after env.Stop, so the manager stops first
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+
+	return user.Config()
+}
+
+func TestLocalRunnerRun(t *testing.T) {
+	cfg := startEnv(t)
+
+	runner, err := NewLocalRunner(cfg, logr.Discard())
+	if err != nil {
+		t.Fatal(err)
+	}
+	runner.Interval = 200 * time.Millisecond
+	runner.Timeout = time.Minute
+
+	objTpl := &tofaniov1alpha1.ObjectTemplate{
+		ObjectMeta: metav1.ObjectMeta{Name: "widget"},
+		Spec: tofaniov1alpha1.ObjectTemplateSpec{
+			NamePrefix: "widget-",
+			Template:   runtime.RawExtension{Raw: []byte(`{"apiVersion":"simulator.tofan.io/v1alpha1","kind":"Widget","spec":{"size":1}}`)},
+		},
+	}
+	testCase := &tofaniov1alpha1.TestCase{
+		ObjectMeta: metav1.ObjectMeta{Name: "widgets", Namespace: "default"},
+		Spec: tofaniov1alpha1.TestCaseSpec{
+			Count:       10,
+			Concurrency: 3,
+		},
+	}
+	testCase.Spec.ObjectTemplateRef.Name = objTpl.Name
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+	rep, err := runner.Run(ctx, testCase, objTpl)
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	if rep.Spec.Run.Phase != StatusCompleted {
+		t.Errorf("report phase = %q, want %q", rep.Spec.Run.Phase, StatusCompleted)
+	}
+	if rep.Spec.Run.Created != testCase.Spec.Count {
+		t.Errorf("report created = %d, want %d", rep.Spec.Run.Created, testCase.Spec.Count)
+	}
+	if rep.Spec.Run.Ready != testCase.Spec.Count {
+		t.Errorf("report ready = %d, want %d", rep.Spec.Run.Ready, testCase.Spec.Count)
+	}
+	for _, assertion := range rep.Spec.Assertions {
+		if !assertion.Passed {
+			t.Errorf("assertion %s failed: %s", assertion.Name, assertion.Message)
+		}
+	}
+	if testCase.Status.CleanupTime == nil {
+		t.Error("teardown not recorded in the TestCase status")
+	}
+
+	widgets := &unstructured.UnstructuredList{}
+	widgets.SetGroupVersionKind(simulator.WidgetGVK.GroupVersion().WithKind(simulator.WidgetGVK.Kind + "List"))
+	if err := runner.Reconciler.List(context.Background(), widgets, client.InNamespace(testCase.Namespace)); err != nil {
+		t.Fatalf("failed to list widgets: %v", err)
+	}
+	if len(widgets.Items) != 0 {
+		t.Errorf("%d widgets left after teardown", len(widgets.Items))
+	}
+}
@@ -0,0 +1,134 @@
+package testcase
+
+import (
+	tofaniov1alpha1 "github.com/invioteq/tofan/api/v1alpha1"
+	"github.com/invioteq/tofan/pkg/report"
+	"k8s.io/apimachinery/pkg/types"
+	"sync"
+	"time"
+)
+
+// measurements holds what a run measured that cannot be found in the cluster once it is done, like
+// the latency of the rejected create requests or of drift corrections, when its objects were observed
+// ready, the writes to its objects and their Events, or the metrics of the API server when it started.
+type measurements struct {
+	runID string
+	// partial is set when the run was resumed by another process, what was measured before is lost
+	partial bool
+	// names holds the names of the latencies in the order they were first observed
+	names      []string
+	latencies  map[string][]time.Duration
+	assertions []tofaniov1alpha1.Assertion
+	// objectAssertions holds the results of the last evaluation of the assertions of the TestCase
+	objectAssertions []tofaniov1alpha1.ObjectAssertionResult
+	// writes holds the writes observed to the objects of the run, keyed by object UID
+	writes map[types.UID]*objectWrites
+	// readiness holds the times the objects of the run were observed created and ready, keyed by object UID
+	readiness map[types.UID]*readiness
+	// events holds the latest version of the Events observed, keyed by Event UID
+	events map[types.UID]*eventRecord
+	// apiServer holds the metrics of the API server scraped when the run started
+	apiServer *apiServerScrape
+}
+
+// measurementRegistry holds the measurements of the runs of the TestCases, keyed by TestCase UID. The
+// zero value is ready to use.
+type measurementRegistry struct {
+	mu   sync.Mutex
+	runs map[types.UID]*measurements
+}
+
+// forRun returns the measurements of the current run of the TestCase, g.mu must be held.
+func (g *measurementRegistry) forRun(testCase *tofaniov1alpha1.TestCase) *measurements {
+	if g.runs == nil {
+		g.runs = make(map[types.UID]*measurements)
+	}
+	m, ok := g.runs[testCase.UID]
+	if !ok || m.runID != testCase.Status.RunID {
+		m = &measurements{runID: testCase.Status.RunID, latencies: map[string][]time.Duration{}}
+		g.runs[testCase.UID] = m
+	}
+	return m
+}
+
+// begin starts measuring the run of the TestCase. A run that created instances before, without
+// measurements in this process, was measured by an operator that restarted since.
+func (g *measurementRegistry) begin(testCase *tofaniov1alpha1.TestCase) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if m, ok := g.runs[testCase.UID]; ok && m.runID == testCase.Status.RunID {
+		return
+	}
+	g.forRun(testCase).partial = testCase.Status.Created > 0
+}
+
+// observe records a sample of the latency with the given name for the current run of the TestCase.
+func (g *measurementRegistry) observe(testCase *tofaniov1alpha1.TestCase, name string, sample time.Duration) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	m := g.forRun(testCase)
+	if _, ok := m.latencies[name]; !ok {
+		m.names = append(m.names, name)
+	}
+	m.latencies[name] = append(m.latencies[name], sample)
+}
+
+// assert records the outcome of a check of the current run of the TestCase, it replaces the outcome
+// of the check with the same name.
+func (g *measurementRegistry) assert(testCase *tofaniov1alpha1.TestCase, assertion tofaniov1alpha1.Assertion) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	m := g.forRun(testCase)
+	for i := range m.assertions {
+		if m.assertions[i].Name == assertion.Name {
+			m.assertions[i] = assertion
+			return
+		}
+	}
+	m.assertions = append(m.assertions, assertion)
+}
+
+// assertObjects records the results of an evaluation of the assertions of the current run of the
+// TestCase, they replace those of the previous evaluation.
+func (g *measurementRegistry) assertObjects(testCase *tofaniov1alpha1.TestCase, results []tofaniov1alpha1.ObjectAssertionResult) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if results == nil {
+		results = []tofaniov1alpha1.ObjectAssertionResult{}
+	}
+	g.forRun(testCase).objectAssertions = results
+}
+
+// addTo adds the measurements of the current run of the TestCase to its report.
+func (g *measurementRegistry) addTo(rep *tofaniov1alpha1.Report, testCase *tofaniov1alpha1.TestCase) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	m, ok := g.runs[testCase.UID]
+	if !ok || m.runID != testCase.Status.RunID {
+		// A run suspended before the operator restarted is aborted without being measured again
+		rep.Spec.Run.Partial = testCase.Status.Created > 0
+		addAssertions(rep, testCase, nil)
+		return
+	}
+	rep.Spec.Run.Partial = m.partial
+	addAssertions(rep, testCase, m.objectAssertions)
+	for _, name := range m.names {
+		rep.Spec.Latencies = append(rep.Spec.Latencies, report.Summarize(name, m.latencies[name]))
+	}
+	addReadiness(rep, testCase, m.readiness)
+	rep.Spec.Assertions = append(rep.Spec.Assertions, m.assertions...)
+	addWrites(rep, m.writes)
+	addEvents(rep, aggregateEvents(m.events, m.writes))
+}
+
+// release forgets the measurements of the TestCase.
+func (g *measurementRegistry) release(uid types.UID) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	delete(g.runs, uid)
+}
@@ -0,0 +1,44 @@
+package testcase
+
+import (
+	"testing"
+
+	tofaniov1alpha1 "github.com/invioteq/tofan/api/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestMeasurementsPartial(t *testing.T) {
+	run := func(created int) *tofaniov1alpha1.TestCase {
+		return &tofaniov1alpha1.TestCase{
+			ObjectMeta: metav1.ObjectMeta{UID: "tc-uid"},
+			Status:     tofaniov1alpha1.TestCaseStatus{RunID: "run-1", Created: created},
+		}
+	}
+
+	tests := []struct {
+		name  string
+		begin []int
+		// created is the progress of the run when it is reported
+		created int
+		want    bool
+	}{
+		{name: "started in this process", begin: []int{0}, created: 10},
+		{name: "resumed in this process after a suspension", begin: []int{0, 4}, created: 10},
+		{name: "resumed after a restart", begin: []int{4}, created: 10, want: true},
+		{name: "aborted after a restart without resuming", created: 4, want: true},
+		{name: "aborted before creating anything"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var g measurementRegistry
+			for _, created := range tt.begin {
+				g.begin(run(created))
+			}
+			rep := &tofaniov1alpha1.Report{}
+			g.addTo(rep, run(tt.created))
+			if rep.Spec.Run.Partial != tt.want {
+				t.Errorf("report partial = %t, want %t", rep.Spec.Run.Partial, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,124 @@
+package testcase
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	tofaniov1alpha1 "github.com/invioteq/tofan/api/v1alpha1"
+	"hash/fnv"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"strings"
+	"text/template"
+)
+
+var namespacesGVR = schema.GroupVersionResource{Version: "v1", Resource: "namespaces"}
+
+// FanOutNamespace returns the generated namespace the instance with the given index is created in,
+// or false when the TestCase does not fan out.
+func FanOutNamespace(testCase *tofaniov1alpha1.TestCase, index int) (string, bool) {
+	fanOut := testCase.Spec.NamespaceFanOut
+	if fanOut == nil || fanOut.Count < 1 {
+		return "", false
+	}
+
+	slot := index % fanOut.Count
+	if fanOut.Distribution == tofaniov1alpha1.NamespaceDistributionRandom {
+		// Hashing the run and the index keeps the choice stable when the run is resumed
+		h := fnv.New32a()
+		fmt.Fprintf(h, "%s/%d", testCase.Status.RunID, index)
+		slot = int(h.Sum32() % uint32(fanOut.Count))
+	}
+	return fanOutNamespaceName(testCase, slot), true
+}
+
+// fanOutNamespaceName returns the name of the generated namespace with the given index.
+func fanOutNamespaceName(testCase *tofaniov1alpha1.TestCase, index int) string {
+	prefix := strings.TrimSuffix(testCase.Spec.NamespaceFanOut.Prefix, "-")
+	if prefix == "" {
+		prefix = testCase.Name
+	}
+	if testCase.Status.RunID == "" {
+		return fmt.Sprintf("%s-%d", prefix, index)
+	}
+	return fmt.Sprintf("%s-%s-%d", prefix, testCase.Status.RunID, index)
+}
+
+// namespaceLabelData is the data the label templates of generated namespaces are executed with.
+type namespaceLabelData struct {
+	Index    int
+	TestCase string
+	RunID    string
+}
+
+// ensureNamespaces creates the namespaces the TestCase fans out to. Like the instances, they are
+// labelled with the TestCase, namespaces that exist already are updated.
+func (r *Reconciler) ensureNamespaces(ctx context.Context, testCase *tofaniov1alpha1.TestCase) error {
+	fanOut := testCase.Spec.NamespaceFanOut
+	if fanOut == nil {
+		return nil
+	}
+
+	for index := 0; index < fanOut.Count; index++ {
+		labels := make(map[string]string, len(fanOut.Labels))
+		for key, value := range fanOut.Labels {
+			rendered, err := renderLabel(value, namespaceLabelData{Index: index, TestCase: testCase.Name, RunID: testCase.Status.RunID})
+			if err != nil {
+				return fmt.Errorf("invalid label %s of generated namespaces: %w", key, err)
+			}
+			labels[key] = rendered
+		}
+
+		namespace, err := json.Marshal(map[string]interface{}{
+			"apiVersion": "v1",
+			"kind":       "Namespace",
+			"metadata": map[string]interface{}{
+				"name":   fanOutNamespaceName(testCase, index),
+				"labels": labels,
+			},
+		})
+		if err != nil {
+			return err
+		}
+		// Namespaces exist already when the run resumes, whatever the apply strategy
+		if _, err := r.applyObject(ctx, namespace, testCase, tofaniov1alpha1.ApplyCreateOrUpdate); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// renderLabel executes the Go template of a label value with data.
+func renderLabel(value string, data interface{}) (string, error) {
+	tpl, err := template.New("label").Option("missingkey=error").Parse(value)
+	if err != nil {
+		return "", err
+	}
+	var buf bytes.Buffer
+	if err := tpl.Execute(&buf, data); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// TeardownNamespaces deletes the namespaces the TestCase fanned out to, along with everything left in them.
+func (r *Reconciler) TeardownNamespaces(ctx context.Context, testCase *tofaniov1alpha1.TestCase) error {
+	fanOut := testCase.Spec.NamespaceFanOut
+	if fanOut == nil {
+		return nil
+	}
+
+	for index := 0; index < fanOut.Count; index++ {
+		name := fanOutNamespaceName(testCase, index)
+		err := r.Dynamic.Resource(namespacesGVR).Delete(ctx, name, metav1.DeleteOptions{})
+		if err != nil && !apierrors.IsNotFound(err) {
+			r.Log.Error(err, "Failed to delete generated namespace", "TestCase", testCase.Name, "Namespace", name)
+			return err
+		}
+	}
+
+	r.Log.Info("Successfully deleted generated namespaces", "TestCase", testCase.Name, "Namespaces", fanOut.Count)
+	return nil
+}
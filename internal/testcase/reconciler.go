@@ -19,26 +19,38 @@ package testcase
 import (
 	"context"
 	tofaniov1alpha1 "github.com/invioteq/tofan/api/v1alpha1"
+	"github.com/invioteq/tofan/internal/metrics"
 	"github.com/invioteq/tofan/pkg/constants"
+	"github.com/invioteq/tofan/pkg/utils"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
-	"time"
 )
 
 func (r *Reconciler) syncDeleteTestCase(ctx context.Context, testCase *tofaniov1alpha1.TestCase) (result reconcile.Result, err error) {
-	// todo: double for Pending(stuck), Error status.phase >> trigger treadown process
-	if !(testCase.Status.Phase == StatusPending || testCase.Status.Phase == StatusError) {
-		objectTemplate, err := r.FetchObjectTemplate(ctx, testCase.Namespace, testCase.Spec.ObjectTemplateRef.Name)
+	r.runs.stop(testCase.UID)
+	r.traces.end(testCase, nil)
+	r.loadClients.release(testCase.UID)
+	r.measurements.release(testCase.UID)
+
+	// Objects are only created once the run left the Pending phase
+	if !(testCase.Status.Phase == "" || testCase.Status.Phase == StatusPending) {
+		objectTemplate, err := r.FetchTestCaseTemplate(ctx, testCase)
 		if err != nil {
-			time.Sleep(30 * time.Second)
+			r.Log.Error(err, "Cannot find ObjectTemplate, skipping teardown", "TestCase", testCase.Name)
+		} else {
 			if err := r.TeardownResourcesForTestCase(ctx, testCase, objectTemplate); err != nil {
 				r.Log.Error(err, "Failed to teardown resources", "TestCase", testCase.Name)
+				return ctrl.Result{}, err
 			}
 			r.Log.Info("Teardown completed successfully", "TestCase", testCase.Name)
 		}
+		if err := r.TeardownNamespaces(ctx, testCase); err != nil {
+			return ctrl.Result{}, err
+		}
 	}
+	metrics.DeleteTestCase(testCase.Namespace, testCase.Name)
 
 	if controllerutil.ContainsFinalizer(testCase, constants.TofanFinalizer) {
 		controllerutil.RemoveFinalizer(testCase, constants.TofanFinalizer)
@@ -56,61 +68,88 @@ func (r *Reconciler) syncTestCase(ctx context.Context, testCase *tofaniov1alpha1
 	if !controllerutil.ContainsFinalizer(testCase, constants.TofanFinalizer) {
 		controllerutil.AddFinalizer(testCase, constants.TofanFinalizer)
 
-		if testCase.Status.Phase == "" {
-			r.EmitEvent(testCase, testCase.GetName(), controllerutil.OperationResultUpdatedStatusOnly, StatusPendingMsg, nil)
-			r.ProcessCondition(ctx, testCase, constants.ObjConditionCreating, metav1.ConditionFalse, StatusPendingReason, StatusPendingMsg)
-			testCase.Status.Phase = StatusPending
-			err = r.UpdateStatus(ctx, testCase)
-			if err != nil {
-				r.Log.Info("error updating the status")
-			}
+		if err = r.Update(ctx, testCase); err != nil {
+			r.Log.Info("Reconciling TestCase")
+
+			return ctrl.Result{}, err
 		}
+	}
 
-		objectTemplate, err := r.FetchObjectTemplate(ctx, testCase.Namespace, testCase.Spec.ObjectTemplateRef.Name)
+	if IsFinished(testCase.Status.Phase) {
+		wait, err := r.syncCleanup(ctx, testCase)
 		if err != nil {
-			r.EmitEvent(testCase, testCase.GetName(), controllerutil.OperationResultUpdatedStatusOnly, "Cannot Find ObjectTemplateRef", err)
-			r.ProcessCondition(ctx, testCase, constants.ObjConditionFailed, metav1.ConditionFalse, StatusPendingReason, StatusPendingMsg)
-			testCase.Status.Phase = StatusPending
-			err = r.UpdateStatus(ctx, testCase)
-			if err != nil {
-				r.Log.Info("error updating the status")
-			}
+			r.Log.Error(err, "Failed to clean up resources", "TestCase", testCase.Name)
 			return ctrl.Result{}, err
 		}
+		return ctrl.Result{RequeueAfter: wait}, nil
+	}
 
-		if !(testCase.Status.Phase == StatusInProgress || testCase.Status.Phase == StatusCompleted || testCase.Status.Phase == StatusError) {
-			r.EmitEvent(testCase, testCase.GetName(), controllerutil.OperationResultUpdatedStatus, StatusInProgressMsg, nil)
-			r.ProcessCondition(ctx, testCase, constants.ObjConditionCreating, metav1.ConditionUnknown, StatusInProgressReason, StatusInProgressMsg)
-			testCase.Status.Phase = StatusInProgress
-			err = r.UpdateStatus(ctx, testCase)
-			if err != nil {
-				r.Log.Info("error updating the status")
-			}
+	if testCase.Spec.Abort {
+		if err = r.abortRun(ctx, testCase); err != nil {
+			r.Log.Error(err, "Failed to abort run", "TestCase", testCase.Name)
+			return ctrl.Result{}, err
+		}
+		return ctrl.Result{}, nil
+	}
 
-			err = r.ProcessTestCase(ctx, objectTemplate, testCase)
-			if err == nil {
-				r.Log.Info("Starting readiness resource watcher", "TestCase", testCase.Name)
-				r.startReadinessWatcher(ctx, testCase, objectTemplate)
-
-				return reconcile.Result{}, err
-			} else {
-				r.EmitEvent(testCase, testCase.GetName(), controllerutil.OperationResultUpdatedStatus, StatusErrorMsg, err)
-				r.ProcessCondition(ctx, testCase, constants.ObjConditionReady, metav1.ConditionFalse, StatusErrorReason, StatusErrorMsg)
-				testCase.Status.Phase = StatusError
-				r.Log.Error(err, "Failed to teardown resources for", "TestCase", testCase.Name)
-				err = r.UpdateStatus(ctx, testCase)
-				if err != nil {
-					r.Log.Info("error updating the status")
-				}
-			}
+	if testCase.Status.Phase == "" {
+		r.EmitEvent(testCase, testCase.GetName(), controllerutil.OperationResultUpdatedStatusOnly, StatusPendingMsg, nil)
+		r.ProcessCondition(ctx, testCase, constants.ObjConditionCreating, metav1.ConditionFalse, StatusPendingReason, StatusPendingMsg)
+		testCase.Status.Phase = StatusPending
+		err = r.UpdateStatus(ctx, testCase)
+		if err != nil {
+			r.Log.Info("error updating the status")
 		}
+	}
 
-		if err = r.Update(ctx, testCase); err != nil {
-			r.Log.Info("Reconciling TestCase")
+	objectTemplate, err := r.FetchTestCaseTemplate(ctx, testCase)
+	if err != nil {
+		r.EmitEvent(testCase, testCase.GetName(), controllerutil.OperationResultUpdatedStatusOnly, "Cannot Find ObjectTemplateRef", err)
+		r.ProcessCondition(ctx, testCase, constants.ObjConditionFailed, metav1.ConditionFalse, StatusPendingReason, StatusPendingMsg)
+		return ctrl.Result{}, err
+	}
 
+	switch testCase.Status.Phase {
+	case StatusPending:
+		r.EmitEvent(testCase, testCase.GetName(), controllerutil.OperationResultUpdatedStatus, StatusInProgressMsg, nil)
+		err = r.setStatus(ctx, testCase, func(latest *tofaniov1alpha1.TestCase) {
+			r.SetCondition(latest, constants.ObjConditionCreating, metav1.ConditionUnknown, StatusInProgressReason, StatusInProgressMsg)
+			now := metav1.Now()
+			latest.Status.Phase = StatusInProgress
+			latest.Status.RunID = utils.GenerateRandomString(5)
+			latest.Status.Created = 0
+			latest.Status.Failed = 0
+			latest.Status.Rejected = 0
+			latest.Status.Retries = 0
+			latest.Status.Errors = nil
+			latest.Status.StartTime = &now
+		})
+		if err != nil {
+			r.Log.Info("error updating the status")
 			return ctrl.Result{}, err
 		}
+		r.startRun(testCase, objectTemplate)
+	case StatusInProgress:
+		// The run does not survive an operator restart, pick it up where it stopped
+		if !r.runs.running(testCase.UID) {
+			r.startRun(testCase, objectTemplate)
+		}
+	case StatusSuspended:
+		if testCase.Spec.Suspend {
+			break
+		}
+		r.EmitEvent(testCase, testCase.GetName(), controllerutil.OperationResultUpdatedStatus, StatusResumedMsg, nil)
+		err = r.setStatus(ctx, testCase, func(latest *tofaniov1alpha1.TestCase) {
+			r.SetCondition(latest, constants.ObjConditionCreating, metav1.ConditionUnknown, StatusInProgressReason, StatusResumedMsg)
+			latest.Status.Phase = StatusInProgress
+		})
+		if err != nil {
+			r.Log.Info("error updating the status")
+			return ctrl.Result{}, err
+		}
+		r.startRun(testCase, objectTemplate)
 	}
+
 	return ctrl.Result{
 		RequeueAfter: constants.RequeueAfter,
 	}, nil
@@ -0,0 +1,267 @@
+package testcase
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	tofaniov1alpha1 "github.com/invioteq/tofan/api/v1alpha1"
+	"github.com/invioteq/tofan/pkg/constants"
+	"github.com/invioteq/tofan/pkg/report"
+	"github.com/invioteq/tofan/pkg/utils"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+const (
+	// LatencyTimeToReady is the name of the latency distribution from object creation to readiness, as
+	// observed by a watch on the objects.
+	LatencyTimeToReady = "timeToReady"
+	// LatencyTimeToDelete is the name of the latency distribution from the teardown request to the objects being gone.
+	LatencyTimeToDelete = "timeToDelete"
+
+	// AssertionObjectsCreated checks that object creation did not fail.
+	AssertionObjectsCreated = "objectsCreated"
+	// AssertionObjectsReady checks that every created object became ready.
+	AssertionObjectsReady = "objectsReady"
+	// AssertionObjectsDeleted checks that every object went away during teardown.
+	AssertionObjectsDeleted = "objectsDeleted"
+
+	// timelinePoints is the number of samples kept in the objects-over-time timeline of a report.
+	timelinePoints = 100
+)
+
+// BuildReport assembles the Report of a run from the resources found in the cluster for the TestCase.
+// runErr is the error the run failed with, if any.
+func BuildReport(testCase *tofaniov1alpha1.TestCase, objTpl *tofaniov1alpha1.ObjectTemplate, resources []unstructured.Unstructured, phase string, runErr error) *tofaniov1alpha1.Report {
+	now := metav1.NewTime(time.Now())
+	rep := &tofaniov1alpha1.Report{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      testCase.Name + "-" + utils.GenerateRandomString(5),
+			Namespace: testCase.Namespace,
+			Labels: map[string]string{
+				constants.TofanReportTestCaseLabel: testCase.Name,
+			},
+		},
+		Spec: tofaniov1alpha1.ReportSpec{
+			TestCaseRef: testCase.Name,
+			Run: tofaniov1alpha1.RunInfo{
+				Phase:          phase,
+				StartTime:      testCase.Status.StartTime,
+				CompletionTime: &now,
+				Count:          testCase.Spec.Count,
+				Concurrency:    testCase.Spec.Concurrency,
+				Created:        len(resources),
+			},
+		},
+	}
+	if objTpl != nil {
+		rep.Spec.Run.ObjectTemplate = objTpl.Name
+		rep.Spec.Run.Group = objTpl.Status.Group
+		rep.Spec.Run.Version = objTpl.Status.Version
+		rep.Spec.Run.Kind = objTpl.Status.Kind
+	}
+
+	var samples []time.Duration
+	var createdAt, readyAt []time.Time
+	for i := range resources {
+		createdAt = append(createdAt, resources[i].GetCreationTimestamp().Time)
+		if !IsResourceReady(&resources[i]) {
+			continue
+		}
+		rep.Spec.Run.Ready++
+		if at, ok := resourceReadyTime(&resources[i]); ok {
+			readyAt = append(readyAt, at)
+			samples = append(samples, at.Sub(resources[i].GetCreationTimestamp().Time))
+		}
+	}
+	rep.Spec.Latencies = append(rep.Spec.Latencies, report.Summarize(LatencyTimeToReady, samples))
+
+	var start time.Time
+	if testCase.Status.StartTime != nil {
+		start = testCase.Status.StartTime.Time
+	}
+	rep.Spec.Timeline = report.Timeline(start, createdAt, readyAt, timelinePoints)
+	rep.Spec.Errors = append([]tofaniov1alpha1.ErrorCount(nil), testCase.Status.Errors...)
+
+	created := tofaniov1alpha1.Assertion{Name: AssertionObjectsCreated, Passed: runErr == nil}
+	switch {
+	case runErr != nil:
+		created.Message = runErr.Error()
+	case testCase.Status.Failed > 0:
+		created.Message = fmt.Sprintf("%d objects created, %d failed within the error budget of %d", rep.Spec.Run.Created, testCase.Status.Failed, testCase.Spec.ErrorBudget)
+	default:
+		created.Message = fmt.Sprintf("%d objects created", rep.Spec.Run.Created)
+	}
+	rep.Spec.Assertions = append(rep.Spec.Assertions, created)
+	if testCase.Spec.ExpectedFailure != nil {
+		addExpectedFailure(rep, testCase, resources)
+	} else {
+		rep.Spec.Assertions = append(rep.Spec.Assertions, tofaniov1alpha1.Assertion{
+			Name:    AssertionObjectsReady,
+			Passed:  rep.Spec.Run.Created > 0 && rep.Spec.Run.Ready == rep.Spec.Run.Created,
+			Message: fmt.Sprintf("%d of %d objects ready", rep.Spec.Run.Ready, rep.Spec.Run.Created),
+		})
+	}
+
+	for _, target := range testCase.Spec.TargetMetrics {
+		rep.Spec.Metrics = append(rep.Spec.Metrics, tofaniov1alpha1.MetricSeries{Name: target.Name, Expr: target.Expr})
+	}
+
+	return rep
+}
+
+// recordReport builds the Report of the run, stores it in the cluster and exports it to the
+// destinations configured on the TestCase. It returns the name of the stored Report.
+func (r *Reconciler) recordReport(ctx context.Context, testCase *tofaniov1alpha1.TestCase, objTpl *tofaniov1alpha1.ObjectTemplate, phase string, runErr error) (string, error) {
+	rep, snapshots := r.buildRunReport(ctx, testCase, objTpl, phase, runErr, true)
+	if rep.Spec.Diagnostics != nil {
+		rep.Spec.Diagnostics.ConfigMapName = rep.Name + "-diagnostics"
+	}
+	if err := r.Create(ctx, rep); err != nil {
+		r.Log.Error(err, "Failed to create report", "TestCase", testCase.Name)
+		return "", err
+	}
+	r.Log.Info("Report created", "TestCase", testCase.Name, "Report", rep.Name)
+	if len(snapshots) > 0 {
+		if err := r.storeDiagnostics(ctx, rep, snapshots); err != nil {
+			r.Log.Error(err, "Failed to store diagnostics", "Report", rep.Name)
+		}
+	}
+
+	exports, err := r.ExportReport(ctx, testCase, rep)
+	if err != nil {
+		r.EmitEvent(testCase, testCase.GetName(), controllerutil.OperationResultUpdatedStatus, "Failed to export report", err)
+	}
+	if len(exports) > 0 {
+		rep.Status.Exports = exports
+		if err := r.UpdateStatus(ctx, rep); err != nil {
+			r.Log.Error(err, "Failed to update report status", "Report", rep.Name)
+		}
+	}
+
+	return rep.Name, nil
+}
+
+// buildRunReport builds the Report of the run of the TestCase from the resources of the run found in
+// the cluster and what the run measured, then releases the load client of the run. With diagnose, the
+// objects of a failed or aborted run are captured and returned along with the Report.
+func (r *Reconciler) buildRunReport(ctx context.Context, testCase *tofaniov1alpha1.TestCase, objTpl *tofaniov1alpha1.ObjectTemplate, phase string, runErr error, diagnose bool) (*tofaniov1alpha1.Report, []snapshot) {
+	var resources []unstructured.Unstructured
+	if objTpl != nil {
+		var err error
+		resources, err = r.listTestCaseResources(ctx, testCase, objTpl)
+		if err != nil {
+			r.Log.Error(err, "Failed to list resources for report", "TestCase", testCase.Name)
+		}
+	}
+
+	rep := BuildReport(testCase, objTpl, resources, phase, runErr)
+	r.addMetricSamples(ctx, rep)
+	rep.Spec.Requests = r.loadClients.get(testCase).stats()
+	// The run sent its last request, teardown goes through the shared clients
+	r.loadClients.release(testCase.UID)
+	r.addRunChildren(ctx, rep, testCase, resources)
+	r.measurements.addTo(rep, testCase)
+	r.addAPIServer(ctx, rep, testCase, objTpl)
+	if !diagnose || !diagnosed(phase) {
+		return rep, nil
+	}
+
+	// Teardown follows a failed or aborted run, capture what is needed to debug it while the objects still exist
+	snapshots := r.captureDiagnostics(ctx, testCase, resources)
+	addDiagnostics(rep, snapshots)
+	return rep, snapshots
+}
+
+// addRunChildren checks the children of the resources of the run and adds the outcome to its report.
+func (r *Reconciler) addRunChildren(ctx context.Context, rep *tofaniov1alpha1.Report, testCase *tofaniov1alpha1.TestCase, resources []unstructured.Unstructured) {
+	checks, err := r.checkChildren(ctx, testCase, resources)
+	if err != nil {
+		r.Log.Error(err, "Failed to check children for report", "TestCase", testCase.Name)
+		return
+	}
+	addChildren(rep, checks)
+}
+
+// ExportReport renders the report in the formats requested by the TestCase and writes it to the
+// configured ConfigMap and report volume path. It returns the destinations written to.
+func (r *Reconciler) ExportReport(ctx context.Context, testCase *tofaniov1alpha1.TestCase, rep *tofaniov1alpha1.Report) ([]string, error) {
+	reporting := testCase.Spec.Reporting
+	if reporting == nil || (reporting.ConfigMapName == "" && reporting.Path == "") {
+		return nil, nil
+	}
+
+	formats := reporting.Formats
+	if len(formats) == 0 {
+		formats = []tofaniov1alpha1.ReportFormat{tofaniov1alpha1.ReportFormatJUnit, tofaniov1alpha1.ReportFormatJSON, tofaniov1alpha1.ReportFormatCSV}
+	}
+
+	rendered := make(map[string]string, len(formats))
+	for _, format := range formats {
+		var buf bytes.Buffer
+		if err := report.Export(&buf, rep, format); err != nil {
+			return nil, err
+		}
+		rendered[report.FileName(rep, format)] = buf.String()
+	}
+
+	var exports []string
+	if reporting.ConfigMapName != "" {
+		configMap := &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: reporting.ConfigMapName, Namespace: testCase.Namespace},
+		}
+		_, err := controllerutil.CreateOrUpdate(ctx, r.Client, configMap, func() error {
+			if configMap.Labels == nil {
+				configMap.Labels = map[string]string{}
+			}
+			configMap.Labels[constants.TofanReportTestCaseLabel] = testCase.Name
+			if configMap.Data == nil {
+				configMap.Data = map[string]string{}
+			}
+			for name, content := range rendered {
+				configMap.Data[name] = content
+			}
+			return nil
+		})
+		if err != nil {
+			r.Log.Error(err, "Failed to write report to ConfigMap", "ConfigMap", reporting.ConfigMapName)
+			return exports, err
+		}
+		exports = append(exports, "configmap/"+reporting.ConfigMapName)
+	}
+
+	if reporting.Path != "" {
+		dir, err := r.reportPath(reporting.Path)
+		if err != nil {
+			return exports, err
+		}
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return exports, err
+		}
+		for name, content := range rendered {
+			file := filepath.Join(dir, name)
+			if err := os.WriteFile(file, []byte(content), 0o644); err != nil {
+				r.Log.Error(err, "Failed to write report file", "Path", file)
+				return exports, err
+			}
+			exports = append(exports, "file://"+file)
+		}
+	}
+
+	return exports, nil
+}
+
+// reportPath resolves a TestCase report path inside the report volume.
+func (r *Reconciler) reportPath(path string) (string, error) {
+	if r.ReportDir == "" {
+		return "", fmt.Errorf("report path %q requested but no report volume is configured", path)
+	}
+	// Cleaning the path against the root keeps ".." elements from leaving the volume
+	return filepath.Join(r.ReportDir, filepath.Clean("/"+path)), nil
+}
@@ -0,0 +1,273 @@
+package testcase
+
+import (
+	"context"
+	tofaniov1alpha1 "github.com/invioteq/tofan/api/v1alpha1"
+	"github.com/invioteq/tofan/internal/metrics"
+	"github.com/invioteq/tofan/pkg/constants"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/retry"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sync"
+	"time"
+)
+
+const (
+	// createdStatusInterval is how often the number of created instances is written to the TestCase status.
+	createdStatusInterval = 5 * time.Second
+	// statusUpdateTimeout bounds the status update made when a run stops.
+	statusUpdateTimeout = 30 * time.Second
+)
+
+// run is a TestCase run executing in the background.
+type run struct {
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// runRegistry tracks the runs executing in the background, keyed by TestCase UID. The zero value is ready to use.
+type runRegistry struct {
+	mu   sync.Mutex
+	runs map[types.UID]*run
+}
+
+// start registers a run for the TestCase and returns its context, or false when the TestCase already has one.
+func (g *runRegistry) start(uid types.UID) (context.Context, *run, bool) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.runs == nil {
+		g.runs = make(map[types.UID]*run)
+	}
+	if _, ok := g.runs[uid]; ok {
+		return nil, nil, false
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	rn := &run{cancel: cancel, done: make(chan struct{})}
+	g.runs[uid] = rn
+	return ctx, rn, true
+}
+
+// finish unregisters a run once it returned.
+func (g *runRegistry) finish(uid types.UID, rn *run) {
+	g.mu.Lock()
+	if g.runs[uid] == rn {
+		delete(g.runs, uid)
+	}
+	g.mu.Unlock()
+
+	rn.cancel()
+	close(rn.done)
+}
+
+// running reports whether the TestCase has a run executing.
+func (g *runRegistry) running(uid types.UID) bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	_, ok := g.runs[uid]
+	return ok
+}
+
+// stop cancels the run of the TestCase, if any, and waits for it to return.
+func (g *runRegistry) stop(uid types.UID) {
+	g.mu.Lock()
+	rn := g.runs[uid]
+	g.mu.Unlock()
+
+	if rn == nil {
+		return
+	}
+	rn.cancel()
+	<-rn.done
+}
+
+// IsFinished reports whether a TestCase in the given phase is done running.
+func IsFinished(phase string) bool {
+	return phase == StatusCompleted || phase == StatusError || phase == StatusAborted
+}
+
+// startRun executes the run of the TestCase in the background. Creation resumes from
+// testCase.Status.Created, so the same call starts, resumes and restarts a run.
+func (r *Reconciler) startRun(testCase *tofaniov1alpha1.TestCase, objTpl *tofaniov1alpha1.ObjectTemplate) {
+	ctx, rn, ok := r.runs.start(testCase.UID)
+	if !ok {
+		return
+	}
+
+	r.Log.Info("Starting run", "TestCase", testCase.Name, "RunID", testCase.Status.RunID, "Created", testCase.Status.Created)
+	r.traces.begin(testCase)
+	go func() {
+		defer r.runs.finish(testCase.UID, rn)
+		metrics.ActiveRuns.Inc()
+		defer metrics.ActiveRuns.Dec()
+		r.executeRun(ctx, testCase.DeepCopy(), objTpl)
+	}()
+}
+
+// executeRun creates the remaining instances of the TestCase and completes the run once they are
+// ready. Creation stops when the TestCase is suspended or aborted, an abort is finished by the
+// reconciler.
+func (r *Reconciler) executeRun(ctx context.Context, testCase *tofaniov1alpha1.TestCase, objTpl *tofaniov1alpha1.ObjectTemplate) {
+	r.measurements.begin(testCase)
+	// Writes and Events are counted for as long as the run executes, the watch gets a copy of the TestCase the run updates
+	watchCtx, stopWatch := context.WithCancel(ctx)
+	defer stopWatch()
+	go r.watchWrites(watchCtx, testCase.DeepCopy(), objTpl)
+	go r.watchEvents(watchCtx, testCase.DeepCopy(), objTpl)
+	r.scrapeAPIServerBaseline(ctx, testCase)
+
+	key := client.ObjectKeyFromObject(testCase)
+	persisted := testCase.Status.Created
+	lastPersist := time.Now()
+
+	err := r.CreateInstances(ctx, objTpl, testCase, func(created int) bool {
+		current := &tofaniov1alpha1.TestCase{}
+		if err := r.Get(ctx, key, current); err == nil && (current.Spec.Suspend || current.Spec.Abort) {
+			return false
+		}
+		if created != persisted && time.Since(lastPersist) >= createdStatusInterval {
+			progress := testCase.Status.DeepCopy()
+			if err := r.setStatus(ctx, testCase, func(latest *tofaniov1alpha1.TestCase) {
+				setProgress(&latest.Status, progress)
+			}); err == nil {
+				persisted = created
+			}
+			lastPersist = time.Now()
+		}
+		return true
+	})
+
+	// Persist the progress even when the run was cancelled, creation resumes from it
+	finishCtx, cancel := context.WithTimeout(context.Background(), statusUpdateTimeout)
+	defer cancel()
+	progress := testCase.Status.DeepCopy()
+	if err := r.setStatus(finishCtx, testCase, func(latest *tofaniov1alpha1.TestCase) {
+		setProgress(&latest.Status, progress)
+	}); err != nil {
+		r.Log.Error(err, "Failed to update created instances", "TestCase", testCase.Name)
+	}
+
+	if ctx.Err() != nil {
+		return
+	}
+
+	if err != nil {
+		r.failRun(finishCtx, testCase, objTpl, err)
+		return
+	}
+
+	if testCase.Status.Created < testCase.Spec.Count {
+		if testCase.Spec.Abort {
+			return
+		}
+		r.EmitEvent(testCase, testCase.GetName(), controllerutil.OperationResultUpdatedStatus, StatusSuspendedMsg, nil)
+		if err := r.setStatus(finishCtx, testCase, func(latest *tofaniov1alpha1.TestCase) {
+			r.SetCondition(latest, constants.ObjConditionCreating, metav1.ConditionFalse, StatusSuspendedReason, StatusSuspendedMsg)
+			latest.Status.Phase = StatusSuspended
+		}); err != nil {
+			r.Log.Error(err, "Failed to update TestCase status to suspended", "TestCase", testCase.Name)
+		}
+		return
+	}
+
+	r.Log.Info("Starting readiness resource watcher", "TestCase", testCase.Name)
+	r.completeWhenReady(ctx, testCase, objTpl)
+}
+
+// setProgress copies the progress of the creation of the instances to status.
+func setProgress(status, progress *tofaniov1alpha1.TestCaseStatus) {
+	status.Created = progress.Created
+	status.Failed = progress.Failed
+	status.Retries = progress.Retries
+	status.Rejected = progress.Rejected
+	status.Errors = progress.Errors
+}
+
+// failRun records the Report of a run that failed, moves the TestCase to the Error phase and tears
+// the run down as the cleanup policy asks.
+func (r *Reconciler) failRun(ctx context.Context, testCase *tofaniov1alpha1.TestCase, objTpl *tofaniov1alpha1.ObjectTemplate, runErr error) {
+	r.Log.Error(runErr, "Run failed", "TestCase", testCase.Name)
+	r.traces.get(testCase.UID).fail(runErr)
+	r.EmitEvent(testCase, testCase.GetName(), controllerutil.OperationResultUpdatedStatus, StatusErrorMsg, runErr)
+
+	reportName, err := r.recordReport(ctx, testCase, objTpl, StatusError, runErr)
+	if err != nil {
+		r.Log.Error(err, "Failed to record report", "TestCase", testCase.Name)
+	}
+
+	if err := r.setStatus(ctx, testCase, func(latest *tofaniov1alpha1.TestCase) {
+		r.SetCondition(latest, constants.ObjConditionReady, metav1.ConditionFalse, StatusErrorReason, StatusErrorMsg)
+		now := metav1.Now()
+		latest.Status.Phase = StatusError
+		latest.Status.CompletionTime = &now
+		latest.Status.ReportRef = reportName
+	}); err != nil {
+		r.Log.Error(err, "Failed to update TestCase status to error", "TestCase", testCase.Name)
+		return
+	}
+
+	switch {
+	case !ShouldTeardown(testCase.Spec.CleanupPolicy, StatusError):
+		r.traces.end(testCase, nil)
+	case cleanupDelay(testCase) == 0:
+		r.teardownRun(ctx, testCase, objTpl)
+	}
+}
+
+// abortRun stops the run of the TestCase, records a Report of the instances created so far and,
+// unless TeardownOnAbort is disabled, deletes them in the background.
+func (r *Reconciler) abortRun(ctx context.Context, testCase *tofaniov1alpha1.TestCase) error {
+	r.runs.stop(testCase.UID)
+
+	var reportName string
+	objectTemplate, err := r.FetchTestCaseTemplate(ctx, testCase)
+	if err != nil {
+		r.Log.Error(err, "Cannot find ObjectTemplate, aborting without report and teardown", "TestCase", testCase.Name)
+	} else {
+		reportName, err = r.recordReport(ctx, testCase, objectTemplate, StatusAborted, nil)
+		if err != nil {
+			r.Log.Error(err, "Failed to record report", "TestCase", testCase.Name)
+		}
+	}
+
+	r.EmitEvent(testCase, testCase.GetName(), controllerutil.OperationResultUpdatedStatus, StatusAbortedMsg, nil)
+	err = r.setStatus(ctx, testCase, func(latest *tofaniov1alpha1.TestCase) {
+		r.SetCondition(latest, constants.ObjConditionReady, metav1.ConditionFalse, StatusAbortedReason, StatusAbortedMsg)
+		now := metav1.Now()
+		latest.Status.Phase = StatusAborted
+		latest.Status.CompletionTime = &now
+		latest.Status.ReportRef = reportName
+	})
+	if err != nil {
+		return err
+	}
+
+	if objectTemplate != nil && (testCase.Spec.TeardownOnAbort == nil || *testCase.Spec.TeardownOnAbort) {
+		r.startTeardown(testCase, objectTemplate)
+		return nil
+	}
+	r.traces.end(testCase, nil)
+	return nil
+}
+
+// setStatus updates the status of the TestCase, mutate is applied to the latest version of the
+// TestCase before every attempt. testCase is refreshed with the updated TestCase.
+func (r *Reconciler) setStatus(ctx context.Context, testCase *tofaniov1alpha1.TestCase, mutate func(latest *tofaniov1alpha1.TestCase)) error {
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		// Re-fetch the latest version of testCase before attempting update
+		latest := &tofaniov1alpha1.TestCase{}
+		if err := r.Get(ctx, client.ObjectKeyFromObject(testCase), latest); err != nil {
+			return err
+		}
+		mutate(latest)
+		if err := r.Status().Update(ctx, latest); err != nil {
+			return err
+		}
+		latest.DeepCopyInto(testCase)
+		return nil
+	})
+}
@@ -0,0 +1,84 @@
+package testcase
+
+import (
+	"context"
+	"fmt"
+	tofaniov1alpha1 "github.com/invioteq/tofan/api/v1alpha1"
+	"github.com/prometheus/client_golang/api"
+	promv1 "github.com/prometheus/client_golang/api/prometheus/v1"
+	"github.com/prometheus/common/model"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"strconv"
+	"time"
+)
+
+// metricSamples is the number of samples queried for a TargetMetrics series, the step of the query is
+// the run duration divided by it.
+const metricSamples = 250
+
+// addMetricSamples queries the TargetMetrics series of the report from Prometheus over the run window
+// and adds their samples. Without a Prometheus URL the series are listed without samples.
+func (r *Reconciler) addMetricSamples(ctx context.Context, rep *tofaniov1alpha1.Report) {
+	run := rep.Spec.Run
+	if r.PrometheusURL == "" || len(rep.Spec.Metrics) == 0 || run.StartTime == nil || run.CompletionTime == nil {
+		return
+	}
+	client, err := api.NewClient(api.Config{Address: r.PrometheusURL})
+	if err != nil {
+		r.Log.Error(err, "Failed to create Prometheus client", "Report", rep.Name)
+		return
+	}
+	window := promv1.Range{
+		Start: run.StartTime.Time,
+		End:   run.CompletionTime.Time,
+		Step:  metricStep(run.CompletionTime.Sub(run.StartTime.Time)),
+	}
+
+	prometheus := promv1.NewAPI(client)
+	for i := range rep.Spec.Metrics {
+		samples, err := queryMetric(ctx, prometheus, rep.Spec.Metrics[i].Expr, window)
+		if err != nil {
+			r.Log.Error(err, "Failed to query target metric", "Report", rep.Name, "Metric", rep.Spec.Metrics[i].Name)
+			continue
+		}
+		rep.Spec.Metrics[i].Samples = samples
+	}
+}
+
+// metricStep returns the step of the queries of a run that lasted d, one second at least.
+func metricStep(d time.Duration) time.Duration {
+	step := (d / metricSamples).Truncate(time.Second)
+	if step < time.Second {
+		step = time.Second
+	}
+	return step
+}
+
+// queryMetric evaluates expr over the window. A TargetMetrics expression is expected to return a
+// single series, it fails when it returns several.
+func queryMetric(ctx context.Context, prometheus promv1.API, expr string, window promv1.Range) ([]tofaniov1alpha1.MetricSample, error) {
+	value, _, err := prometheus.QueryRange(ctx, expr, window)
+	if err != nil {
+		return nil, err
+	}
+	matrix, ok := value.(model.Matrix)
+	if !ok {
+		return nil, fmt.Errorf("unexpected %s result", value.Type())
+	}
+	switch len(matrix) {
+	case 0:
+		return nil, nil
+	case 1:
+	default:
+		return nil, fmt.Errorf("%d series returned, aggregate them into one", len(matrix))
+	}
+
+	samples := make([]tofaniov1alpha1.MetricSample, 0, len(matrix[0].Values))
+	for _, pair := range matrix[0].Values {
+		samples = append(samples, tofaniov1alpha1.MetricSample{
+			Time:  metav1.NewTime(pair.Timestamp.Time()),
+			Value: strconv.FormatFloat(float64(pair.Value), 'f', -1, 64),
+		})
+	}
+	return samples, nil
+}
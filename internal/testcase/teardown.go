@@ -0,0 +1,211 @@
+package testcase
+
+import (
+	"context"
+	"fmt"
+	tofaniov1alpha1 "github.com/invioteq/tofan/api/v1alpha1"
+	"github.com/invioteq/tofan/internal/metrics"
+	"github.com/invioteq/tofan/pkg/report"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/watch"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"time"
+)
+
+// deletionTimeout bounds how long a teardown waits for the deleted objects to go away.
+const deletionTimeout = 10 * time.Minute
+
+// ShouldTeardown reports whether the objects of a run that finished in the given phase are deleted
+// under the cleanup policy. With AfterDelay they are, once the delay elapsed.
+func ShouldTeardown(policy tofaniov1alpha1.CleanupPolicy, phase string) bool {
+	switch policy {
+	case tofaniov1alpha1.CleanupNever:
+		return false
+	case tofaniov1alpha1.CleanupOnSuccess:
+		return phase == StatusCompleted
+	case tofaniov1alpha1.CleanupOnFailure:
+		return phase == StatusError
+	default:
+		return true
+	}
+}
+
+// cleanupDelay returns how long the objects of a finished run are kept before they are deleted.
+func cleanupDelay(testCase *tofaniov1alpha1.TestCase) time.Duration {
+	if testCase.Spec.CleanupPolicy != tofaniov1alpha1.CleanupAfterDelay || testCase.Spec.CleanupDelay == nil {
+		return 0
+	}
+	return testCase.Spec.CleanupDelay.Duration
+}
+
+// MeasureTeardown deletes the resources of the TestCase and waits until they are gone, that is until
+// the finalizers of every object were cleared. It returns how long each object took to go away.
+func (r *Reconciler) MeasureTeardown(ctx context.Context, testCase *tofaniov1alpha1.TestCase, objTpl *tofaniov1alpha1.ObjectTemplate) ([]time.Duration, error) {
+	mapping, err := r.templateMapping(objTpl)
+	if err != nil {
+		return nil, err
+	}
+	gvr := mapping.Resource
+	labelSelector := testCaseSelector(testCase)
+	// The resources are listed and watched across namespaces, the run may have created them in several
+	resource := r.Dynamic.Resource(gvr)
+
+	list, err := resource.List(ctx, metav1.ListOptions{LabelSelector: labelSelector})
+	if err != nil {
+		r.Log.Error(err, "Failed to list resources for testCase", "TestCase", testCase.Name, "GVR", gvr)
+		return nil, err
+	}
+	if len(list.Items) == 0 {
+		return nil, nil
+	}
+
+	pending := make(map[string]bool, len(list.Items))
+	for _, item := range list.Items {
+		pending[item.GetNamespace()+"/"+item.GetName()] = true
+	}
+
+	// Watch from the listed version so no deletion is missed between the delete call and the watch
+	watcher, err := resource.Watch(ctx, metav1.ListOptions{LabelSelector: labelSelector, ResourceVersion: list.GetResourceVersion()})
+	if err != nil {
+		r.Log.Error(err, "Failed to watch resources for testCase", "TestCase", testCase.Name, "GVR", gvr)
+		return nil, err
+	}
+	defer watcher.Stop()
+
+	rt := r.traces.get(testCase.UID)
+	start := time.Now()
+	if err := r.TeardownResourcesForTestCase(ctx, testCase, objTpl); err != nil {
+		return nil, err
+	}
+
+	timeout := time.NewTimer(deletionTimeout)
+	defer timeout.Stop()
+
+	samples := make([]time.Duration, 0, len(pending))
+	for len(pending) > 0 {
+		select {
+		case event, ok := <-watcher.ResultChan():
+			if !ok {
+				return samples, fmt.Errorf("watch closed with %d objects not deleted", len(pending))
+			}
+			if event.Type != watch.Deleted {
+				continue
+			}
+			obj, ok := event.Object.(*unstructured.Unstructured)
+			if !ok {
+				continue
+			}
+			key := obj.GetNamespace() + "/" + obj.GetName()
+			if !pending[key] {
+				continue
+			}
+			delete(pending, key)
+			samples = append(samples, time.Since(start))
+			rt.objectDeleted(obj)
+		case <-timeout.C:
+			return samples, fmt.Errorf("%d objects not deleted after %s", len(pending), deletionTimeout)
+		case <-ctx.Done():
+			return samples, ctx.Err()
+		}
+	}
+
+	metrics.TeardownDuration.WithLabelValues(testCase.Namespace, testCase.Name).Observe(time.Since(start).Seconds())
+	r.Log.Info("Resources deleted", "TestCase", testCase.Name, "Deleted", len(samples), "Duration", time.Since(start))
+	return samples, nil
+}
+
+// AddTeardown adds the deletion latencies measured by a teardown to the report. teardownErr is the
+// error the teardown failed with, if any.
+func AddTeardown(rep *tofaniov1alpha1.Report, samples []time.Duration, teardownErr error) {
+	rep.Spec.Latencies = append(rep.Spec.Latencies, report.Summarize(LatencyTimeToDelete, samples))
+
+	deleted := tofaniov1alpha1.Assertion{Name: AssertionObjectsDeleted, Passed: teardownErr == nil}
+	if teardownErr != nil {
+		deleted.Message = teardownErr.Error()
+	} else {
+		deleted.Message = fmt.Sprintf("%d objects deleted", len(samples))
+	}
+	rep.Spec.Assertions = append(rep.Spec.Assertions, deleted)
+}
+
+// teardownRun deletes the resources and generated namespaces of a finished run, adds the deletion latencies to its Report and
+// records the cleanup time on the TestCase. The trace of the run ends with it.
+func (r *Reconciler) teardownRun(ctx context.Context, testCase *tofaniov1alpha1.TestCase, objTpl *tofaniov1alpha1.ObjectTemplate) {
+	samples, teardownErr := r.MeasureTeardown(ctx, testCase, objTpl)
+	if teardownErr == nil {
+		teardownErr = r.TeardownNamespaces(ctx, testCase)
+	}
+	if teardownErr != nil {
+		r.Log.Error(teardownErr, "Failed to teardown resources", "TestCase", testCase.Name)
+	}
+	r.traces.end(testCase, teardownErr)
+
+	if testCase.Status.ReportRef != "" {
+		if err := r.recordTeardown(ctx, testCase, samples, teardownErr); err != nil {
+			r.Log.Error(err, "Failed to add teardown to report", "TestCase", testCase.Name, "Report", testCase.Status.ReportRef)
+		}
+	}
+
+	if teardownErr != nil {
+		return
+	}
+	if err := r.setStatus(ctx, testCase, func(latest *tofaniov1alpha1.TestCase) {
+		now := metav1.Now()
+		latest.Status.CleanupTime = &now
+	}); err != nil {
+		r.Log.Error(err, "Failed to update cleanup time", "TestCase", testCase.Name)
+	}
+}
+
+// recordTeardown adds the deletion latencies to the Report of the run and exports it again.
+func (r *Reconciler) recordTeardown(ctx context.Context, testCase *tofaniov1alpha1.TestCase, samples []time.Duration, teardownErr error) error {
+	rep := &tofaniov1alpha1.Report{}
+	if err := r.Get(ctx, client.ObjectKey{Namespace: testCase.Namespace, Name: testCase.Status.ReportRef}, rep); err != nil {
+		return err
+	}
+
+	AddTeardown(rep, samples, teardownErr)
+	if err := r.Update(ctx, rep); err != nil {
+		return err
+	}
+
+	if _, err := r.ExportReport(ctx, testCase, rep); err != nil {
+		r.Log.Error(err, "Failed to export report", "Report", rep.Name)
+	}
+	return nil
+}
+
+// syncCleanup deletes the resources of a run finished under the AfterDelay policy once the delay
+// elapsed. It returns how long to wait until the cleanup is due.
+func (r *Reconciler) syncCleanup(ctx context.Context, testCase *tofaniov1alpha1.TestCase) (time.Duration, error) {
+	if testCase.Spec.CleanupPolicy != tofaniov1alpha1.CleanupAfterDelay || testCase.Status.CleanupTime != nil ||
+		testCase.Status.Phase == StatusAborted || testCase.Status.CompletionTime == nil {
+		return 0, nil
+	}
+
+	if wait := time.Until(testCase.Status.CompletionTime.Add(cleanupDelay(testCase))); wait > 0 {
+		return wait, nil
+	}
+
+	objectTemplate, err := r.FetchTestCaseTemplate(ctx, testCase)
+	if err != nil {
+		return 0, err
+	}
+	r.startTeardown(testCase, objectTemplate)
+	return 0, nil
+}
+
+// startTeardown executes teardownRun in the background, it is tracked like a run so deleting the
+// TestCase stops it.
+func (r *Reconciler) startTeardown(testCase *tofaniov1alpha1.TestCase, objTpl *tofaniov1alpha1.ObjectTemplate) {
+	ctx, rn, ok := r.runs.start(testCase.UID)
+	if !ok {
+		return
+	}
+
+	go func() {
+		defer r.runs.finish(testCase.UID, rn)
+		r.teardownRun(ctx, testCase.DeepCopy(), objTpl)
+	}()
+}
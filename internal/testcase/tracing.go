@@ -0,0 +1,197 @@
+package testcase
+
+import (
+	"context"
+	tofaniov1alpha1 "github.com/invioteq/tofan/api/v1alpha1"
+	"github.com/invioteq/tofan/internal/tracing"
+	"github.com/invioteq/tofan/pkg/constants"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// runTrace is the trace of a run: a root span, and a span per created object that ends once the
+// object is deleted. A nil runTrace records nothing.
+type runTrace struct {
+	ctx  context.Context
+	root trace.Span
+
+	mu sync.Mutex
+	// objects holds the spans of the objects not deleted yet, keyed by instance index
+	objects map[int]trace.Span
+}
+
+// traceRegistry holds the traces of the runs of the TestCases, keyed by TestCase UID. A trace outlives
+// the goroutine of its run, it is ended once the objects of the run are torn down or kept for good.
+// The zero value is ready to use.
+type traceRegistry struct {
+	mu     sync.Mutex
+	traces map[types.UID]*runTrace
+}
+
+// begin returns the trace of the run of the TestCase, starting it when there is none. A run resumed
+// after the operator restarted gets a new trace.
+func (g *traceRegistry) begin(testCase *tofaniov1alpha1.TestCase) *runTrace {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.traces == nil {
+		g.traces = make(map[types.UID]*runTrace)
+	}
+	if rt, ok := g.traces[testCase.UID]; ok {
+		return rt
+	}
+	rt := newRunTrace(testCase)
+	g.traces[testCase.UID] = rt
+	return rt
+}
+
+// get returns the trace of the run of the TestCase, or nil when it is not traced.
+func (g *traceRegistry) get(uid types.UID) *runTrace {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.traces[uid]
+}
+
+// end ends the trace of the run of the TestCase, if any.
+func (g *traceRegistry) end(testCase *tofaniov1alpha1.TestCase, err error) {
+	g.mu.Lock()
+	rt := g.traces[testCase.UID]
+	delete(g.traces, testCase.UID)
+	g.mu.Unlock()
+
+	rt.end(testCase.Status.Phase, err)
+}
+
+// newRunTrace starts the root span of the run of the TestCase.
+func newRunTrace(testCase *tofaniov1alpha1.TestCase) *runTrace {
+	ctx, root := tracing.Tracer().Start(context.Background(), "TestCase run", trace.WithAttributes(
+		tracing.TestCaseNamespaceKey.String(testCase.Namespace),
+		tracing.TestCaseNameKey.String(testCase.Name),
+		tracing.RunIDKey.String(testCase.Status.RunID),
+		tracing.CountKey.Int(testCase.Spec.Count),
+		tracing.ConcurrencyKey.Int(testCase.Spec.Concurrency),
+	))
+	return &runTrace{ctx: ctx, root: root, objects: map[int]trace.Span{}}
+}
+
+// startObject starts the span of the instance with the given index and returns ctx carrying it, the
+// create request of the object is made with it.
+func (t *runTrace) startObject(ctx context.Context, objTpl *tofaniov1alpha1.ObjectTemplate, testCase *tofaniov1alpha1.TestCase, index int) context.Context {
+	if t == nil {
+		return ctx
+	}
+
+	attrs := []attribute.KeyValue{
+		tracing.GroupKey.String(objTpl.Status.Group),
+		tracing.VersionKey.String(objTpl.Status.Version),
+		tracing.KindKey.String(objTpl.Status.Kind),
+		tracing.IndexKey.Int(index),
+	}
+	for _, field := range testCase.Spec.DynamicFields {
+		if value, ok := DynamicFieldValue(field, index); ok {
+			attrs = append(attrs, attribute.String(tracing.DynamicFieldPrefix+field.Path, string(value.Raw)))
+		}
+	}
+
+	// The span is a child of the root span, whatever the context of the caller
+	_, span := tracing.Tracer().Start(t.ctx, objTpl.Status.Kind, trace.WithAttributes(attrs...))
+	t.mu.Lock()
+	if previous, ok := t.objects[index]; ok {
+		// The instance is created again after a restart of the run
+		previous.End()
+	}
+	t.objects[index] = span
+	t.mu.Unlock()
+	return trace.ContextWithSpan(ctx, span)
+}
+
+// objectCreated records the outcome of the create request of the instance. The span of an instance
+// that could not be created ends here.
+func (t *runTrace) objectCreated(index int, err error) {
+	if t == nil {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	span, ok := t.objects[index]
+	if !ok {
+		return
+	}
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		span.End()
+		delete(t.objects, index)
+		return
+	}
+	span.AddEvent(tracing.EventCreated)
+}
+
+// objectReady records the readiness of the instance, at the time it became ready.
+func (t *runTrace) objectReady(resource *unstructured.Unstructured, readyAt time.Time) {
+	t.objectEvent(resource, tracing.EventReady, readyAt, false)
+}
+
+// objectDeleted records the deletion of the instance and ends its span.
+func (t *runTrace) objectDeleted(resource *unstructured.Unstructured) {
+	t.objectEvent(resource, tracing.EventDeleted, time.Now(), true)
+}
+
+func (t *runTrace) objectEvent(resource *unstructured.Unstructured, name string, at time.Time, end bool) {
+	if t == nil {
+		return
+	}
+	index, err := strconv.Atoi(resource.GetLabels()[constants.TofanInstanceIndexLabel])
+	if err != nil {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	span, ok := t.objects[index]
+	if !ok {
+		return
+	}
+	span.AddEvent(name, trace.WithTimestamp(at))
+	if end {
+		span.End(trace.WithTimestamp(at))
+		delete(t.objects, index)
+	}
+}
+
+// fail records the error the run failed with on the root span.
+func (t *runTrace) fail(err error) {
+	if t == nil {
+		return
+	}
+	t.root.RecordError(err)
+	t.root.SetStatus(codes.Error, err.Error())
+}
+
+// end ends the spans of the objects left and the root span, with the phase the run finished in.
+func (t *runTrace) end(phase string, err error) {
+	if t == nil {
+		return
+	}
+
+	t.mu.Lock()
+	for index, span := range t.objects {
+		span.End()
+		delete(t.objects, index)
+	}
+	t.mu.Unlock()
+
+	t.root.SetAttributes(tracing.PhaseKey.String(phase))
+	if err != nil {
+		t.root.RecordError(err)
+		t.root.SetStatus(codes.Error, err.Error())
+	}
+	t.root.End()
+}
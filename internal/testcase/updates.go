@@ -0,0 +1,247 @@
+package testcase
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	tofaniov1alpha1 "github.com/invioteq/tofan/api/v1alpha1"
+	"github.com/invioteq/tofan/internal/metrics"
+	"github.com/invioteq/tofan/pkg/utils"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/watch"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"strings"
+	"time"
+)
+
+const (
+	// LatencyTimeToConverge is the name of the latency distribution from an update of an instance until
+	// the target controller observed the generation it produced.
+	LatencyTimeToConverge = "timeToConverge"
+	// AssertionUpdatesConverged checks that the target controller observed every update of the instances.
+	AssertionUpdatesConverged = "updatesConverged"
+
+	// defaultUpdateTimeout is how long instances may take to converge when the TestCase sets no timeout.
+	defaultUpdateTimeout = 5 * time.Minute
+)
+
+// generationWrite is a generation of an instance produced by an update.
+type generationWrite struct {
+	key        types.NamespacedName
+	generation int64
+	// at is the time the update request was sent, after it waited for the rate limiter
+	at        time.Time
+	converged bool
+}
+
+// ApplyUpdates applies the update rounds of the TestCase to its instances. After every round it waits
+// until the target controller observed the generation every update produced, recording the
+// convergence latencies for the report. The instances are watched, a convergence is timed when the
+// event showing it is received. It fails once the update timeout elapsed with instances not
+// converged, and returns the context error when ctx is done first. A TestCase without updates passes
+// at once.
+func (r *Reconciler) ApplyUpdates(ctx context.Context, testCase *tofaniov1alpha1.TestCase, objTpl *tofaniov1alpha1.ObjectTemplate) error {
+	updates := testCase.Spec.Updates
+	if updates == nil {
+		return nil
+	}
+	if updates.Path == "" || len(updates.Values) == 0 {
+		return fmt.Errorf("updates require a path and values")
+	}
+	timeout := defaultUpdateTimeout
+	if updates.Timeout != nil {
+		timeout = updates.Timeout.Duration
+	}
+	mapping, err := r.templateMapping(objTpl)
+	if err != nil {
+		return err
+	}
+
+	converged := 0
+	for round, raw := range updates.Values {
+		var value interface{}
+		if err := json.Unmarshal(raw.Raw, &value); err != nil {
+			return fmt.Errorf("invalid update value %d: %w", round+1, err)
+		}
+		// The watch starts before the update is applied, so no convergence is missed
+		watchCtx, stopWatch := context.WithCancel(ctx)
+		list, events, err := r.watchObjects(watchCtx, mapping.Resource, "", metav1.ListOptions{LabelSelector: testCaseSelector(testCase)})
+		if err != nil {
+			stopWatch()
+			return fmt.Errorf("failed to watch the instances: %w", err)
+		}
+		writes, err := r.updateInstances(ctx, testCase, updates.Path, value, list.Items)
+		if err != nil {
+			stopWatch()
+			return fmt.Errorf("update %d failed: %w", round+1, err)
+		}
+		r.Log.Info("Update applied", "TestCase", testCase.Name, "Round", round+1, "Updated", len(writes))
+
+		err = r.waitForGenerations(ctx, testCase, events, writes, timeout)
+		stopWatch()
+		converged += convergedWrites(writes)
+		if err != nil {
+			if ctx.Err() == nil {
+				err = fmt.Errorf("update %d not observed after %s: %w", round+1, timeout, err)
+				r.measurements.assert(testCase, tofaniov1alpha1.Assertion{Name: AssertionUpdatesConverged, Message: err.Error()})
+			}
+			return err
+		}
+	}
+
+	r.measurements.assert(testCase, tofaniov1alpha1.Assertion{
+		Name:    AssertionUpdatesConverged,
+		Passed:  true,
+		Message: fmt.Sprintf("%d updates in %d rounds converged", converged, len(updates.Values)),
+	})
+	return nil
+}
+
+// updateInstances sets the field at path of every instance to value, with the load client of the run.
+// An update that did not change the spec leaves the generation as it was and is not tracked.
+func (r *Reconciler) updateInstances(ctx context.Context, testCase *tofaniov1alpha1.TestCase, path string, value interface{}, resources []unstructured.Unstructured) ([]*generationWrite, error) {
+	patch := map[string]interface{}{}
+	if err := utils.NavigateAndApplyValue(&patch, path, value); err != nil {
+		return nil, err
+	}
+	data, err := json.Marshal(patch)
+	if err != nil {
+		return nil, err
+	}
+	c, err := r.loadClientFor(testCase)
+	if err != nil {
+		return nil, err
+	}
+
+	writes := make([]*generationWrite, 0, len(resources))
+	for i := range resources {
+		updated := &unstructured.Unstructured{}
+		updated.SetGroupVersionKind(resources[i].GroupVersionKind())
+		updated.SetNamespace(resources[i].GetNamespace())
+		updated.SetName(resources[i].GetName())
+		start := time.Now()
+		// Convergence is timed from the request leaving the rate limiter of the client
+		sent := start
+		err := c.Patch(withSentTime(ctx, &sent), updated, client.RawPatch(types.MergePatchType, data), client.FieldOwner(FieldManager))
+		r.observeRequest("patch", resources[i].GroupVersionKind(), start)
+		if err != nil {
+			return writes, fmt.Errorf("failed to update %s: %w", resources[i].GetName(), err)
+		}
+		if updated.GetGeneration() == resources[i].GetGeneration() {
+			continue
+		}
+		writes = append(writes, &generationWrite{
+			key:        types.NamespacedName{Namespace: updated.GetNamespace(), Name: updated.GetName()},
+			generation: updated.GetGeneration(),
+			at:         sent,
+		})
+	}
+	return writes, nil
+}
+
+// waitForGenerations handles the watch events of the instances until the target controller observed
+// the generation of every write, or the timeout elapsed.
+func (r *Reconciler) waitForGenerations(ctx context.Context, testCase *tofaniov1alpha1.TestCase, events <-chan stampedEvent, writes []*generationWrite, timeout time.Duration) error {
+	pending := make(map[types.NamespacedName]*generationWrite, len(writes))
+	for _, w := range writes {
+		pending[w.key] = w
+	}
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	for len(pending) > 0 {
+		select {
+		case event, ok := <-events:
+			if !ok {
+				if ctx.Err() != nil {
+					return ctx.Err()
+				}
+				return fmt.Errorf("watch of the instances ended: %s", generationMessage(writes))
+			}
+			resource, ok := event.Object.(*unstructured.Unstructured)
+			if !ok || event.Type == watch.Deleted {
+				continue
+			}
+			key := types.NamespacedName{Namespace: resource.GetNamespace(), Name: resource.GetName()}
+			w, ok := pending[key]
+			if !ok {
+				continue
+			}
+			if observed, ok := observedGeneration(resource); !ok || observed < w.generation {
+				continue
+			}
+			delete(pending, key)
+			w.converged = true
+			sample := event.at.Sub(w.at)
+			r.measurements.observe(testCase, LatencyTimeToConverge, sample)
+			metrics.TimeToConverge.WithLabelValues(testCase.Namespace, testCase.Name).Observe(sample.Seconds())
+		case <-timer.C:
+			return errors.New(generationMessage(writes))
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return nil
+}
+
+func convergedWrites(writes []*generationWrite) int {
+	converged := 0
+	for _, w := range writes {
+		if w.converged {
+			converged++
+		}
+	}
+	return converged
+}
+
+// generationMessage describes the convergence of the writes, naming the first instances not converged.
+func generationMessage(writes []*generationWrite) string {
+	converged := convergedWrites(writes)
+	msg := fmt.Sprintf("%d of %d instances observed their new generation", converged, len(writes))
+	var pending []string
+	for _, w := range writes {
+		if !w.converged && len(pending) < maxMissingInstances {
+			pending = append(pending, fmt.Sprintf("%s (generation %d)", w.key.Name, w.generation))
+		}
+	}
+	if len(pending) > 0 {
+		msg += ", pending for " + strings.Join(pending, ", ")
+		if missing := len(writes) - converged; missing > len(pending) {
+			msg += fmt.Sprintf(" and %d more", missing-len(pending))
+		}
+	}
+	return msg
+}
+
+// observedGeneration returns the generation the controller of the resource last observed, taken from
+// status.observedGeneration, or else from the observedGeneration of its Ready condition, or the
+// highest one of its conditions when it has no Ready condition.
+func observedGeneration(resource *unstructured.Unstructured) (int64, bool) {
+	if observed, found, err := unstructured.NestedInt64(resource.Object, "status", "observedGeneration"); found && err == nil {
+		return observed, true
+	}
+
+	conditions, _, _ := unstructured.NestedSlice(resource.Object, "status", "conditions")
+	var observed int64
+	found := false
+	for _, cond := range conditions {
+		condition, ok := cond.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		generation, ok, _ := unstructured.NestedInt64(condition, "observedGeneration")
+		if !ok {
+			continue
+		}
+		if condition["type"] == "Ready" {
+			return generation, true
+		}
+		if !found || generation > observed {
+			observed, found = generation, true
+		}
+	}
+	return observed, found
+}
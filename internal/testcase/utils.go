@@ -3,71 +3,196 @@ package testcase
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	tofaniov1alpha1 "github.com/invioteq/tofan/api/v1alpha1"
+	"github.com/invioteq/tofan/internal/metrics"
+	"github.com/invioteq/tofan/pkg/constants"
 	"github.com/invioteq/tofan/pkg/utils"
+	extv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
 )
 
+// ProcessTestCase creates every instance of the TestCase that has not been created yet.
 func (r *Reconciler) ProcessTestCase(ctx context.Context, objectTemplate *tofaniov1alpha1.ObjectTemplate, testCase *tofaniov1alpha1.TestCase) error {
-	for _, field := range testCase.Spec.DynamicFields {
-		// `field.Values` is now a map, so iterate through the map
-		for key, jsonValue := range field.Values {
-			rawJSONValue, err := jsonValue.MarshalJSON()
-			if err != nil {
-				r.Log.Error(err, "Failed to marshal dynamic field value to JSON", "Path", field.Path, "Key", key)
-				continue // Move to the next value or field on error
-			}
+	return r.CreateInstances(ctx, objectTemplate, testCase, nil)
+}
 
-			// Apply each value to the template independently
-			modifiedTemplate, err := r.ApplyValueToTemplate(objectTemplate, field.Path, rawJSONValue)
-			if err != nil {
-				r.Log.Error(err, "Failed to apply dynamic field value to template", "Path", field.Path, "Value", rawJSONValue)
-				continue // Move to the next value or field on error
-			}
+// CreateInstances creates the instances of the TestCase from testCase.Status.Created up to Spec.Count,
+// Spec.Concurrency of them at a time, and advances testCase.Status.Created as it goes. Instances that
+// could not be created are counted by reason in testCase.Status, creation fails once more than
+// Spec.ErrorBudget of them failed. Instances rejected the way a negative TestCase expects are counted
+// as Rejected instead. proceed is called before every batch with the number of instances
+// processed so far, creation stops without error when it returns false.
+func (r *Reconciler) CreateInstances(ctx context.Context, objectTemplate *tofaniov1alpha1.ObjectTemplate, testCase *tofaniov1alpha1.TestCase, proceed func(created int) bool) error {
+	concurrency := testCase.Spec.Concurrency
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	expected, err := compileExpectedFailure(testCase)
+	if err != nil {
+		return err
+	}
 
-			r.Log.Info("Successfully applied value to template", "Path", field.Path, "Key", key, "ModifiedTemplate", string(modifiedTemplate))
+	if testCase.Status.Created < testCase.Spec.Count {
+		if err := r.ensureNamespaces(ctx, testCase); err != nil {
+			r.Log.Error(err, "Failed to create generated namespaces", "TestCase", testCase.Name)
+			return err
+		}
+	}
 
-			// create or update the resource based on the modified template
-			// This involves converting the JSON back into a Kubernetes object and applying it
-			err = r.ApplyObjectToCluster(ctx, modifiedTemplate, testCase.GetName())
+	for testCase.Status.Created < testCase.Spec.Count {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if proceed != nil && !proceed(testCase.Status.Created) {
+			return nil
+		}
+
+		start := testCase.Status.Created
+		end := start + concurrency
+		if end > testCase.Spec.Count {
+			end = testCase.Spec.Count
+		}
+
+		errs := make([]error, end-start)
+		retries := make([]int, end-start)
+		latencies := make([]time.Duration, end-start)
+		var wg sync.WaitGroup
+		for index := start; index < end; index++ {
+			wg.Add(1)
+			go func(index int) {
+				defer wg.Done()
+				requested := time.Now()
+				retries[index-start], errs[index-start] = r.createInstance(ctx, objectTemplate, testCase, index)
+				latencies[index-start] = time.Since(requested)
+			}(index)
+		}
+		wg.Wait()
+
+		if err := ctx.Err(); err != nil {
+			// The batch was interrupted, its instances are created again when the run resumes
+			return err
+		}
+		testCase.Status.Created = end
+		var lastErr error
+		for i, err := range errs {
+			testCase.Status.Retries += retries[i]
+			if expected.rejects(err) {
+				testCase.Status.Rejected++
+				r.measurements.observe(testCase, LatencyTimeToReject, latencies[i])
+				continue
+			}
 			if err != nil {
-				r.Log.Error(err, "Failed to apply object to cluster", "ModifiedTemplate", modifiedTemplate)
-				return err
+				addError(&testCase.Status, err)
+				lastErr = err
 			}
 		}
+		if testCase.Status.Failed > testCase.Spec.ErrorBudget {
+			return fmt.Errorf("%d instances failed, exceeding the error budget of %d: %w", testCase.Status.Failed, testCase.Spec.ErrorBudget, lastErr)
+		}
 	}
 	return nil
 }
 
-// ApplyValueToTemplate applies dynamic field value to the specified ObjectTemplate
-func (r *Reconciler) ApplyValueToTemplate(objectTemplate *tofaniov1alpha1.ObjectTemplate, path string, value []byte) ([]byte, error) {
+// createInstance renders the instance with the given index and applies it to the cluster, retrying
+// as the retry policy of the TestCase allows. It returns the number of retries made.
+func (r *Reconciler) createInstance(ctx context.Context, objectTemplate *tofaniov1alpha1.ObjectTemplate, testCase *tofaniov1alpha1.TestCase, index int) (int, error) {
+	rt := r.traces.get(testCase.UID)
+	ctx = rt.startObject(ctx, objectTemplate, testCase, index)
+
+	modifiedTemplate, err := r.RenderInstance(objectTemplate, testCase, index)
+	if err != nil {
+		r.Log.Error(err, "Failed to render instance", "TestCase", testCase.Name, "Index", index)
+		err = &templateError{err: err}
+		metrics.ObjectsFailed.WithLabelValues(testCase.Namespace, testCase.Name, ErrorReason(err)).Inc()
+		rt.objectCreated(index, err)
+		return 0, err
+	}
+
+	// create or update the resource based on the modified template
+	// This involves converting the JSON back into a Kubernetes object and applying it
+	retries, err := withRetry(ctx, testCase, func() error {
+		return r.ApplyObjectToCluster(ctx, modifiedTemplate, testCase)
+	})
+	if retries > 0 {
+		metrics.RequestRetries.WithLabelValues(testCase.Namespace, testCase.Name).Add(float64(retries))
+	}
+	if err != nil && createdBefore(testCase, err) {
+		r.Log.Info("Instance already created by the run", "TestCase", testCase.Name, "Index", index)
+		err = nil
+	}
+	rt.objectCreated(index, err)
+	if err != nil {
+		r.Log.Error(err, "Failed to apply object to cluster", "ModifiedTemplate", string(modifiedTemplate), "Retries", retries)
+		metrics.ObjectsFailed.WithLabelValues(testCase.Namespace, testCase.Name, ErrorReason(err)).Inc()
+		return retries, err
+	}
+	metrics.ObjectsCreated.WithLabelValues(testCase.Namespace, testCase.Name).Inc()
+	return retries, nil
+}
+
+// createdBefore reports whether err only tells that the instance exists already. Progress is saved
+// periodically, so instances from Status.Created on may have been created before the run was
+// interrupted, or by an attempt whose response was lost. Their names are unique to the run, so with
+// the Create strategy, the default, an existing object is the instance itself.
+func createdBefore(testCase *tofaniov1alpha1.TestCase, err error) bool {
+	strategy := testCase.Spec.ApplyStrategy
+	creates := strategy == tofaniov1alpha1.ApplyCreate || strategy == ""
+	return creates && testCase.Status.RunID != "" && apierrors.IsAlreadyExists(err)
+}
+
+// RenderInstance applies the dynamic field values of the instance with the given index to the
+// ObjectTemplate and names the object after the template, the run and the index.
+func (r *Reconciler) RenderInstance(objectTemplate *tofaniov1alpha1.ObjectTemplate, testCase *tofaniov1alpha1.TestCase, index int) ([]byte, error) {
 	var templateMap map[string]interface{}
 	if err := json.Unmarshal(objectTemplate.Spec.Template.Raw, &templateMap); err != nil {
 		r.Log.Error(err, "Failed to unmarshal ObjectTemplate into map")
 		return nil, err
 	}
 
-	// Deserialize the Raw content of runtime.RawExtension (now referred to as value) to the expected type
-	var actualValue interface{}
-	if err := json.Unmarshal(value, &actualValue); err != nil {
-		r.Log.Error(err, "Failed to unmarshal value", "Path", path)
-		return nil, err
+	for _, field := range testCase.Spec.DynamicFields {
+		value, ok := DynamicFieldValue(field, index)
+		if !ok {
+			continue
+		}
+
+		// Deserialize the raw JSON value to the expected type
+		var actualValue interface{}
+		if err := json.Unmarshal(value.Raw, &actualValue); err != nil {
+			r.Log.Error(err, "Failed to unmarshal value", "Path", field.Path)
+			return nil, err
+		}
+
+		// Navigate and apply the deserialized value to the specified path
+		if err := utils.NavigateAndApplyValue(&templateMap, field.Path, actualValue); err != nil {
+			r.Log.Error(err, "Failed to apply value to path", "Path", field.Path, "Value", actualValue)
+			return nil, err
+		}
 	}
 
-	// Navigate and apply the deserialized value to the specified path
-	if err := utils.NavigateAndApplyValue(&templateMap, path, actualValue); err != nil {
-		r.Log.Error(err, "Failed to apply value to path", "Path", path, "Value", actualValue)
-		return nil, err
+	metadata, ok := templateMap["metadata"].(map[string]interface{})
+	if !ok {
+		metadata = map[string]interface{}{}
+		templateMap["metadata"] = metadata
+	}
+	baseName, _ := metadata["name"].(string)
+	metadata["name"] = InstanceName(objectTemplate, testCase, baseName, index)
+	if namespace, ok := FanOutNamespace(testCase, index); ok {
+		metadata["namespace"] = namespace
 	}
 
-	// Generate a unique name for the object if it has a metadata.name field
-	if metadata, ok := templateMap["metadata"].(map[string]interface{}); ok {
-		if name, ok := metadata["name"].(string); ok {
-			uniqueName := name + "-" + utils.GenerateRandomString(5) // Ensure unique naming
-			metadata["name"] = uniqueName
-			r.Log.Info("Set unique name for object", "Name", uniqueName)
-		}
+	labels, ok := metadata["labels"].(map[string]interface{})
+	if !ok {
+		labels = map[string]interface{}{}
+		metadata["labels"] = labels
 	}
+	labels[constants.TofanInstanceIndexLabel] = strconv.Itoa(index)
 
 	// Re-serialize the modified map back to JSON
 	modifiedTemplate, err := json.Marshal(templateMap)
@@ -79,8 +204,39 @@ func (r *Reconciler) ApplyValueToTemplate(objectTemplate *tofaniov1alpha1.Object
 	return modifiedTemplate, nil
 }
 
-// isResourceReady  check the specific readiness conditions relevant to testcase resources.
-func isResourceReady(resource *unstructured.Unstructured) bool {
+// InstanceName returns the name of the instance with the given index. The ObjectTemplate NamePrefix
+// takes precedence over the name set in the template.
+func InstanceName(objectTemplate *tofaniov1alpha1.ObjectTemplate, testCase *tofaniov1alpha1.TestCase, baseName string, index int) string {
+	if objectTemplate.Spec.NamePrefix != "" {
+		baseName = strings.TrimSuffix(objectTemplate.Spec.NamePrefix, "-")
+	}
+	if baseName == "" {
+		baseName = "testcase"
+	}
+	if testCase.Status.RunID == "" {
+		return fmt.Sprintf("%s-%d", baseName, index)
+	}
+	return fmt.Sprintf("%s-%s-%d", baseName, testCase.Status.RunID, index)
+}
+
+// DynamicFieldValue returns the value of a dynamic field for the instance with the given index. The
+// values are assigned round-robin in the order of their sorted keys.
+func DynamicFieldValue(field tofaniov1alpha1.DynamicField, index int) (extv1.JSON, bool) {
+	if len(field.Values) == 0 {
+		return extv1.JSON{}, false
+	}
+
+	keys := make([]string, 0, len(field.Values))
+	for key := range field.Values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	return field.Values[keys[index%len(keys)]], true
+}
+
+// IsResourceReady checks the specific readiness conditions relevant to testcase resources.
+func IsResourceReady(resource *unstructured.Unstructured) bool {
 
 	status, found, _ := unstructured.NestedFieldNoCopy(resource.Object, "status", "conditions")
 	if !found {
@@ -105,3 +261,33 @@ func isResourceReady(resource *unstructured.Unstructured) bool {
 
 	return false
 }
+
+// resourceReadyTime returns the time the resource became ready, taken from the last transition of
+// its Ready condition, or of the latest True condition when the resource has no Ready condition.
+func resourceReadyTime(resource *unstructured.Unstructured) (time.Time, bool) {
+	conditions, found, _ := unstructured.NestedSlice(resource.Object, "status", "conditions")
+	if !found {
+		return time.Time{}, false
+	}
+
+	var readyAt time.Time
+	for _, cond := range conditions {
+		condition, ok := cond.(map[string]interface{})
+		if !ok || condition["status"] != "True" {
+			continue
+		}
+		raw, _ := condition["lastTransitionTime"].(string)
+		transition, err := time.Parse(time.RFC3339, raw)
+		if err != nil {
+			continue
+		}
+		if condition["type"] == "Ready" {
+			return transition, true
+		}
+		if transition.After(readyAt) {
+			readyAt = transition
+		}
+	}
+
+	return readyAt, !readyAt.IsZero()
+}
@@ -0,0 +1,316 @@
+package testcase
+
+import (
+	"context"
+	"fmt"
+	tofaniov1alpha1 "github.com/invioteq/tofan/api/v1alpha1"
+	"github.com/invioteq/tofan/pkg/constants"
+	"github.com/invioteq/tofan/pkg/report"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// LatencyTimeToChildren prefixes the name of the latency distribution from the creation of an
+	// instance until its children of a ChildExpectation were created.
+	LatencyTimeToChildren = "timeToChildren/"
+	// AssertionChildren prefixes the name of the check that every instance has the children of a ChildExpectation.
+	AssertionChildren = "children/"
+
+	// defaultVerificationTimeout is how long children may take to appear when the TestCase sets no timeout.
+	defaultVerificationTimeout = 5 * time.Minute
+	// childrenCheckInterval is how often the children of the instances are checked.
+	childrenCheckInterval = 5 * time.Second
+	// maxMissingInstances bounds the instances missing children named in a message.
+	maxMissingInstances = 5
+)
+
+// childCheck is the outcome of a ChildExpectation for the instances of a run.
+type childCheck struct {
+	name string
+	kind string
+	// expected is the number of children expected for every instance
+	expected  int
+	instances int
+	satisfied int
+	// samples holds the time from the creation of every satisfied instance until its last expected
+	// child was created
+	samples []time.Duration
+	// missing names the first instances missing children
+	missing []string
+}
+
+func (c *childCheck) passed() bool {
+	return c.satisfied == c.instances
+}
+
+func (c *childCheck) message() string {
+	msg := fmt.Sprintf("%d of %d instances have %d %s", c.satisfied, c.instances, c.expected, c.kind)
+	if len(c.missing) > 0 {
+		msg += ", missing for " + strings.Join(c.missing, ", ")
+		if missing := c.instances - c.satisfied; missing > len(c.missing) {
+			msg += fmt.Sprintf(" and %d more", missing-len(c.missing))
+		}
+	}
+	return msg
+}
+
+// childLabelData is the data the selector templates of a ChildExpectation are executed with.
+type childLabelData struct {
+	Name      string
+	Namespace string
+	UID       string
+	Index     int
+}
+
+// childName returns the name of the expectation in the Report.
+func childName(child tofaniov1alpha1.ChildExpectation) string {
+	if child.Name != "" {
+		return child.Name
+	}
+	return child.Kind
+}
+
+// WaitForChildren blocks until every instance of the TestCase has the children its verification
+// expects, checking them every interval. It fails once the verification timeout elapsed, and
+// returns the context error when ctx is done first. A TestCase without verification passes at once.
+func (r *Reconciler) WaitForChildren(ctx context.Context, testCase *tofaniov1alpha1.TestCase, objTpl *tofaniov1alpha1.ObjectTemplate, interval time.Duration) error {
+	verification := testCase.Spec.Verification
+	if verification == nil || len(verification.Children) == 0 {
+		return nil
+	}
+	timeout := defaultVerificationTimeout
+	if verification.Timeout != nil {
+		timeout = verification.Timeout.Duration
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	expired := false
+	for {
+		checks, err := r.checkRunChildren(ctx, testCase, objTpl)
+		if err != nil {
+			r.Log.Error(err, "Error checking children", "TestCase", testCase.Name)
+		}
+		var failed []string
+		for i := range checks {
+			if !checks[i].passed() {
+				failed = append(failed, checks[i].message())
+			}
+		}
+		if err == nil && len(failed) == 0 {
+			r.Log.Info("Children verified", "TestCase", testCase.Name)
+			return nil
+		}
+		if expired {
+			if err != nil {
+				return fmt.Errorf("children not verified within %s: %w", timeout, err)
+			}
+			return fmt.Errorf("children missing after %s: %s", timeout, strings.Join(failed, "; "))
+		}
+		r.Log.Info("Children check result", "TestCase", testCase.Name, "Missing", failed)
+
+		select {
+		case <-ticker.C:
+		case <-timer.C:
+			expired = true
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// checkRunChildren checks the children of the instances of the TestCase found in the cluster.
+func (r *Reconciler) checkRunChildren(ctx context.Context, testCase *tofaniov1alpha1.TestCase, objTpl *tofaniov1alpha1.ObjectTemplate) ([]childCheck, error) {
+	resources, err := r.listTestCaseResources(ctx, testCase, objTpl)
+	if err != nil {
+		return nil, err
+	}
+	return r.checkChildren(ctx, testCase, resources)
+}
+
+// checkChildren checks the children of the given instances against every ChildExpectation of the TestCase.
+func (r *Reconciler) checkChildren(ctx context.Context, testCase *tofaniov1alpha1.TestCase, resources []unstructured.Unstructured) ([]childCheck, error) {
+	if testCase.Spec.Verification == nil {
+		return nil, nil
+	}
+
+	var checks []childCheck
+	for _, child := range testCase.Spec.Verification.Children {
+		check, err := r.checkChild(ctx, child, resources)
+		if err != nil {
+			return nil, fmt.Errorf("failed to check children %s: %w", childName(child), err)
+		}
+		checks = append(checks, check)
+	}
+	return checks, nil
+}
+
+func (r *Reconciler) checkChild(ctx context.Context, child tofaniov1alpha1.ChildExpectation, resources []unstructured.Unstructured) (childCheck, error) {
+	expected := childCount(child)
+	check := childCheck{name: childName(child), kind: child.Kind, expected: expected, instances: len(resources)}
+
+	finder, err := r.newChildFinder(child)
+	if err != nil {
+		return check, err
+	}
+	for i := range resources {
+		instance := &resources[i]
+		children, err := finder.find(ctx, instance)
+		if err != nil {
+			return check, err
+		}
+
+		var created []time.Time
+		for _, candidate := range children {
+			if candidate.GetDeletionTimestamp() == nil {
+				created = append(created, candidate.GetCreationTimestamp().Time)
+			}
+		}
+		if len(created) < expected {
+			if len(check.missing) < maxMissingInstances {
+				check.missing = append(check.missing, instance.GetName())
+			}
+			continue
+		}
+
+		check.satisfied++
+		sort.Slice(created, func(i, j int) bool { return created[i].Before(created[j]) })
+		sample := created[expected-1].Sub(instance.GetCreationTimestamp().Time)
+		if sample < 0 {
+			sample = 0
+		}
+		check.samples = append(check.samples, sample)
+	}
+	return check, nil
+}
+
+// childCount returns the number of children expected for every instance.
+func childCount(child tofaniov1alpha1.ChildExpectation) int {
+	if child.Count < 1 {
+		return 1
+	}
+	return child.Count
+}
+
+// childFinder finds the children of a ChildExpectation for instances. Owned objects are listed once
+// per namespace and matched to their owner afterwards, a finder is meant for a single check.
+type childFinder struct {
+	r       *Reconciler
+	child   tofaniov1alpha1.ChildExpectation
+	mapping *meta.RESTMapping
+	owned   map[string][]unstructured.Unstructured
+}
+
+func (r *Reconciler) newChildFinder(child tofaniov1alpha1.ChildExpectation) (*childFinder, error) {
+	gvk := schema.FromAPIVersionAndKind(child.APIVersion, child.Kind)
+	mapping, err := r.RESTMapper().RESTMapping(gvk.GroupKind(), gvk.Version)
+	if err != nil {
+		return nil, err
+	}
+	return &childFinder{r: r, child: child, mapping: mapping, owned: map[string][]unstructured.Unstructured{}}, nil
+}
+
+// find returns the children of the instance, those being deleted included.
+func (f *childFinder) find(ctx context.Context, instance *unstructured.Unstructured) ([]unstructured.Unstructured, error) {
+	selector, err := childSelector(f.child, instance)
+	if err != nil {
+		return nil, err
+	}
+	// Children of a cluster-scoped instance may live in any namespace
+	namespace := ""
+	if f.mapping.Scope.Name() == meta.RESTScopeNameNamespace {
+		namespace = instance.GetNamespace()
+	}
+	resource := f.r.Dynamic.Resource(f.mapping.Resource).Namespace(namespace)
+
+	if f.child.Match == tofaniov1alpha1.ChildMatchLabelSelector {
+		if selector.Empty() {
+			return nil, fmt.Errorf("the LabelSelector match requires a selector")
+		}
+		list, err := resource.List(ctx, metav1.ListOptions{LabelSelector: selector.String()})
+		if err != nil {
+			return nil, err
+		}
+		return list.Items, nil
+	}
+
+	list, ok := f.owned[namespace]
+	if !ok {
+		objects, err := resource.List(ctx, metav1.ListOptions{})
+		if err != nil {
+			return nil, err
+		}
+		list = objects.Items
+		f.owned[namespace] = list
+	}
+	var children []unstructured.Unstructured
+	for i := range list {
+		if f.matches(&list[i], instance, selector) {
+			children = append(children, list[i])
+		}
+	}
+	return children, nil
+}
+
+// matches reports whether candidate is a child of the instance, selector being the selector of the
+// ChildExpectation rendered for the instance.
+func (f *childFinder) matches(candidate, instance *unstructured.Unstructured, selector labels.Selector) bool {
+	// Children of a cluster-scoped instance may live in any namespace
+	namespaced := f.mapping.Scope.Name() == meta.RESTScopeNameNamespace && instance.GetNamespace() != ""
+	if namespaced && candidate.GetNamespace() != instance.GetNamespace() {
+		return false
+	}
+	if !selector.Matches(labels.Set(candidate.GetLabels())) {
+		return false
+	}
+	return f.child.Match == tofaniov1alpha1.ChildMatchLabelSelector || isOwnedBy(candidate, instance)
+}
+
+// childSelector renders the selector of the ChildExpectation for the instance.
+func childSelector(child tofaniov1alpha1.ChildExpectation, instance *unstructured.Unstructured) (labels.Selector, error) {
+	index, _ := strconv.Atoi(instance.GetLabels()[constants.TofanInstanceIndexLabel])
+	data := childLabelData{Name: instance.GetName(), Namespace: instance.GetNamespace(), UID: string(instance.GetUID()), Index: index}
+
+	set := make(labels.Set, len(child.Selector))
+	for key, value := range child.Selector {
+		rendered, err := renderLabel(value, data)
+		if err != nil {
+			return nil, fmt.Errorf("invalid selector label %s: %w", key, err)
+		}
+		set[key] = rendered
+	}
+	return labels.ValidatedSelectorFromSet(set)
+}
+
+// isOwnedBy reports whether obj has an owner reference to owner.
+func isOwnedBy(obj, owner *unstructured.Unstructured) bool {
+	for _, ref := range obj.GetOwnerReferences() {
+		if ref.UID == owner.GetUID() {
+			return true
+		}
+	}
+	return false
+}
+
+// addChildren adds the outcome of the ChildExpectations of a run to its report.
+func addChildren(rep *tofaniov1alpha1.Report, checks []childCheck) {
+	for i := range checks {
+		rep.Spec.Latencies = append(rep.Spec.Latencies, report.Summarize(LatencyTimeToChildren+checks[i].name, checks[i].samples))
+		rep.Spec.Assertions = append(rep.Spec.Assertions, tofaniov1alpha1.Assertion{
+			Name:    AssertionChildren + checks[i].name,
+			Passed:  checks[i].passed(),
+			Message: checks[i].message(),
+		})
+	}
+}
@@ -0,0 +1,70 @@
+package testcase
+
+import (
+	"context"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/tools/cache"
+	watchtools "k8s.io/client-go/tools/watch"
+	"time"
+)
+
+// stampedEvent is a watch event with the time it was received.
+type stampedEvent struct {
+	watch.Event
+	at time.Time
+}
+
+// watchObjects lists the objects of the resource the options select in the namespace, or in every
+// namespace when it is empty, and watches them from the version listed. The events are stamped as they are received, so they can be handled
+// late without skewing the latencies measured from them. The channel is closed when ctx is done or
+// the watch ended.
+func (r *Reconciler) watchObjects(ctx context.Context, gvr schema.GroupVersionResource, namespace string, options metav1.ListOptions) (*unstructured.UnstructuredList, <-chan stampedEvent, error) {
+	resource := r.Dynamic.Resource(gvr).Namespace(namespace)
+	list, err := resource.List(ctx, options)
+	if err != nil {
+		return nil, nil, err
+	}
+	watcher, err := watchtools.NewRetryWatcher(list.GetResourceVersion(), &cache.ListWatch{
+		WatchFunc: func(watchOptions metav1.ListOptions) (watch.Interface, error) {
+			watchOptions.LabelSelector = options.LabelSelector
+			watchOptions.FieldSelector = options.FieldSelector
+			return resource.Watch(ctx, watchOptions)
+		},
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	events := make(chan stampedEvent)
+	go func() {
+		defer close(events)
+		defer watcher.Stop()
+
+		// Events are queued until they are handled, the watcher is read as soon as it delivers
+		var queue []stampedEvent
+		in := watcher.ResultChan()
+		for in != nil || len(queue) > 0 {
+			var out chan<- stampedEvent
+			var next stampedEvent
+			if len(queue) > 0 {
+				out, next = events, queue[0]
+			}
+			select {
+			case event, ok := <-in:
+				if !ok {
+					in = nil
+					continue
+				}
+				queue = append(queue, stampedEvent{Event: event, at: time.Now()})
+			case out <- next:
+				queue = queue[1:]
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return list, events, nil
+}
@@ -2,59 +2,229 @@ package testcase
 
 import (
 	"context"
+	"fmt"
 	tofaniov1alpha1 "github.com/invioteq/tofan/api/v1alpha1"
+	"github.com/invioteq/tofan/internal/metrics"
 	"github.com/invioteq/tofan/pkg/constants"
+	"github.com/invioteq/tofan/pkg/report"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/types"
-	"k8s.io/client-go/util/retry"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 	"time"
 )
 
-// startReadinessWatcher initiates a goroutine that periodically checks the readiness of resources
-// associated with the given TestCase and ObjectTemplate. It uses a ticker to perform checks
-func (r *Reconciler) startReadinessWatcher(ctx context.Context, testCase *tofaniov1alpha1.TestCase, objTpl *tofaniov1alpha1.ObjectTemplate) {
-	go func() {
-		ticker := time.NewTicker(30 * time.Second)
-		defer ticker.Stop()
-
-		for {
-			select {
-			case <-ticker.C:
-				allReady, err := r.CheckTestCaseResourcesReadiness(ctx, testCase, objTpl)
-				if err != nil {
-					r.Log.Error(err, "Error checking resource readiness", "TestCase", testCase.Name)
+// readinessCheckInterval is how often the readiness watcher checks the resources of a running TestCase.
+const readinessCheckInterval = 30 * time.Second
+
+// completeWhenReady verifies the run of the TestCase, then completes it, records its report and tears
+// its resources down as the cleanup policy asks. A run that fails verification is failed instead.
+func (r *Reconciler) completeWhenReady(ctx context.Context, testCase *tofaniov1alpha1.TestCase, objTpl *tofaniov1alpha1.ObjectTemplate) {
+	err := r.verifyRun(ctx, testCase, objTpl, readinessCheckInterval)
+	if err != nil {
+		if ctx.Err() == nil {
+			r.failRun(ctx, testCase, objTpl, err)
+		}
+		return
+	}
+
+	r.EmitEvent(testCase, testCase.GetName(), controllerutil.OperationResultUpdatedStatus, StatusCompletedMsg, nil)
+
+	reportName, err := r.recordReport(ctx, testCase, objTpl, StatusCompleted, nil)
+	if err != nil {
+		r.Log.Error(err, "Failed to record report", "TestCase", testCase.Name)
+	}
+
+	err = r.setStatus(ctx, testCase, func(latest *tofaniov1alpha1.TestCase) {
+		r.SetCondition(latest, constants.ObjConditionReady, metav1.ConditionTrue, StatusCompletedReason, StatusCompletedMsg)
+		now := metav1.Now()
+		latest.Status.Phase = StatusCompleted
+		latest.Status.CompletionTime = &now
+		latest.Status.ReportRef = reportName
+	})
+
+	if err != nil {
+		r.Log.Error(err, "Failed to update TestCase status to completed after retries", "TestCase", testCase.Name)
+		return
+	}
+
+	// With the AfterDelay policy the reconciler tears the run down once the delay elapsed
+	if !ShouldTeardown(testCase.Spec.CleanupPolicy, StatusCompleted) || cleanupDelay(testCase) > 0 {
+		r.Log.Info("Readiness confirmed, keeping resources", "TestCase", testCase.Name, "CleanupPolicy", testCase.Spec.CleanupPolicy)
+		if cleanupDelay(testCase) == 0 {
+			r.traces.end(testCase, nil)
+		}
+		return
+	}
+	r.teardownRun(ctx, testCase, objTpl)
+	r.Log.Info("Readiness confirmed and teardown completed", "TestCase", testCase.Name)
+}
+
+// verifyRun checks the resources of the run once they were created: they become ready, have the
+// children the verification expects and hold the assertions of the TestCase, or fail the way a
+// negative TestCase expects. The updates and the drift of the TestCase are applied and measured last,
+// then the Warning Events of the resources are checked. Readiness is checked every interval, children,
+// assertions and expected failures at least as often as their own check intervals.
+func (r *Reconciler) verifyRun(ctx context.Context, testCase *tofaniov1alpha1.TestCase, objTpl *tofaniov1alpha1.ObjectTemplate, interval time.Duration) error {
+	if testCase.Spec.ExpectedFailure != nil {
+		if err := r.WaitForExpectedFailure(ctx, testCase, objTpl, atMost(interval, failureCheckInterval)); err != nil {
+			return err
+		}
+	} else {
+		if err := r.WaitForResourcesReady(ctx, testCase, objTpl, interval); err != nil {
+			return fmt.Errorf("objects did not become ready: %w", err)
+		}
+		if err := r.WaitForChildren(ctx, testCase, objTpl, atMost(interval, childrenCheckInterval)); err != nil {
+			return fmt.Errorf("children not verified: %w", err)
+		}
+	}
+	if err := r.CheckAssertions(ctx, testCase, objTpl, atMost(interval, assertionsCheckInterval)); err != nil {
+		return err
+	}
+	if err := r.ApplyUpdates(ctx, testCase, objTpl); err != nil {
+		return err
+	}
+	if err := r.MeasureDrift(ctx, testCase, objTpl); err != nil {
+		return err
+	}
+	return r.CheckEvents(testCase)
+}
+
+// atMost returns interval, or limit when interval is longer.
+func atMost(interval, limit time.Duration) time.Duration {
+	if interval > limit {
+		return limit
+	}
+	return interval
+}
+
+// WaitForResourcesReady blocks until every resource created for the TestCase is ready, checking them
+// every interval. It returns the context error when ctx is done first.
+func (r *Reconciler) WaitForResourcesReady(ctx context.Context, testCase *tofaniov1alpha1.TestCase, objTpl *tofaniov1alpha1.ObjectTemplate, interval time.Duration) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	// observed holds the resources whose time to ready was recorded already
+	observed := map[types.UID]bool{}
+	rt := r.traces.get(testCase.UID)
+	for {
+		select {
+		case <-ticker.C:
+			resources, err := r.listTestCaseResources(ctx, testCase, objTpl)
+			if err != nil {
+				r.Log.Error(err, "Error checking resource readiness", "TestCase", testCase.Name)
+				continue
+			}
+
+			allReady := true
+			for i := range resources {
+				if !IsResourceReady(&resources[i]) {
+					allReady = false
 					continue
 				}
-				r.Log.Info("Resource readiness check result", "TestCase", testCase.Name, "AllReady", allReady)
-
-				if allReady {
-					r.EmitEvent(testCase, testCase.GetName(), controllerutil.OperationResultUpdatedStatus, StatusCompletedMsg, nil)
-					r.ProcessCondition(ctx, testCase, constants.ObjConditionReady, metav1.ConditionTrue, StatusCompletedReason, StatusCompletedMsg)
-
-					err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
-						// Re-fetch the latest version of testCase before attempting update
-						updatedTestCase := &tofaniov1alpha1.TestCase{}
-						if err := r.Get(ctx, types.NamespacedName{Name: testCase.Name, Namespace: testCase.Namespace}, updatedTestCase); err != nil {
-							return err
-						}
-						updatedTestCase.Status.Phase = StatusCompleted
-						return r.Status().Update(ctx, updatedTestCase)
-					})
-
-					if err != nil {
-						r.Log.Error(err, "Failed to update TestCase status to completed after retries", "TestCase", testCase.Name)
-					} else {
-						if err := r.TeardownResourcesForTestCase(ctx, testCase, objTpl); err != nil {
-							r.Log.Error(err, "Failed to teardown resources", "TestCase", testCase.Name)
-						}
-						r.Log.Info("Readiness confirmed and teardown completed successfully", "TestCase", testCase.Name)
-					}
-					return
+				if observed[resources[i].GetUID()] {
+					continue
+				}
+				observed[resources[i].GetUID()] = true
+				createdAt, readyAt, ok := r.measurements.readyTimes(testCase, &resources[i])
+				if ok {
+					metrics.TimeToReady.WithLabelValues(testCase.Namespace, testCase.Name).Observe(readyAt.Sub(createdAt).Seconds())
+				} else {
+					readyAt = time.Now()
 				}
-			case <-ctx.Done():
-				return
+				rt.objectReady(&resources[i], readyAt)
 			}
+			r.Log.Info("Resource readiness check result", "TestCase", testCase.Name, "AllReady", allReady)
+
+			if allReady {
+				return nil
+			}
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// readiness holds the times an object of a run was observed created and ready.
+type readiness struct {
+	created time.Time
+	ready   time.Time
+}
+
+// observeReadiness records a version of an object of the current run of the TestCase received by a
+// watch at the given time. The conditions and the creationTimestamp of an object only have a resolution
+// of a second, so the times the watch received the object and its first ready version are taken
+// instead. A zero time stands for an object listed before the watch started, its times are then
+// taken from the object.
+func (g *measurementRegistry) observeReadiness(testCase *tofaniov1alpha1.TestCase, obj *unstructured.Unstructured, at time.Time) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	m := g.forRun(testCase)
+	if m.readiness == nil {
+		m.readiness = make(map[types.UID]*readiness)
+	}
+	o, ok := m.readiness[obj.GetUID()]
+	if !ok {
+		o = &readiness{created: at}
+		if at.IsZero() {
+			o.created = obj.GetCreationTimestamp().Time
 		}
-	}()
+		m.readiness[obj.GetUID()] = o
+	}
+	if !o.ready.IsZero() || !IsResourceReady(obj) {
+		return
+	}
+	o.ready = at
+	if at.IsZero() {
+		o.ready, _ = resourceReadyTime(obj)
+	}
+}
+
+// readyTimes returns the times the ready object of the current run of the TestCase was created and
+// became ready, as observed by the watch or else as told by the object.
+func (g *measurementRegistry) readyTimes(testCase *tofaniov1alpha1.TestCase, obj *unstructured.Unstructured) (time.Time, time.Time, bool) {
+	g.mu.Lock()
+	m, ok := g.runs[testCase.UID]
+	var o *readiness
+	if ok && m.runID == testCase.Status.RunID {
+		o = m.readiness[obj.GetUID()]
+	}
+	g.mu.Unlock()
+	if o != nil && !o.ready.IsZero() {
+		return o.created, o.ready, true
+	}
+
+	readyAt, ok := resourceReadyTime(obj)
+	return obj.GetCreationTimestamp().Time, readyAt, ok
+}
+
+// addReadiness replaces the time to ready and the timeline of the report, computed from the timestamps
+// of the objects, with those computed from the times the objects were observed.
+func addReadiness(rep *tofaniov1alpha1.Report, testCase *tofaniov1alpha1.TestCase, observed map[types.UID]*readiness) {
+	if len(observed) == 0 {
+		return
+	}
+
+	var samples []time.Duration
+	var createdAt, readyAt []time.Time
+	for _, o := range observed {
+		createdAt = append(createdAt, o.created)
+		if o.ready.IsZero() {
+			continue
+		}
+		readyAt = append(readyAt, o.ready)
+		samples = append(samples, o.ready.Sub(o.created))
+	}
+	for i := range rep.Spec.Latencies {
+		if rep.Spec.Latencies[i].Name == LatencyTimeToReady {
+			rep.Spec.Latencies[i] = report.Summarize(LatencyTimeToReady, samples)
+		}
+	}
+
+	var start time.Time
+	if testCase.Status.StartTime != nil {
+		start = testCase.Status.StartTime.Time
+	}
+	rep.Spec.Timeline = report.Timeline(start, createdAt, readyAt, timelinePoints)
 }
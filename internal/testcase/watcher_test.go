@@ -0,0 +1,67 @@
+package testcase
+
+import (
+	"testing"
+	"time"
+
+	tofaniov1alpha1 "github.com/invioteq/tofan/api/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func TestObserveReadiness(t *testing.T) {
+	t0 := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	testCase := &tofaniov1alpha1.TestCase{
+		ObjectMeta: metav1.ObjectMeta{UID: "tc-uid"},
+		Status:     tofaniov1alpha1.TestCaseStatus{RunID: "run-1", StartTime: &metav1.Time{Time: t0}},
+	}
+	widget := func(uid string, ready bool) *unstructured.Unstructured {
+		obj := &unstructured.Unstructured{Object: map[string]interface{}{}}
+		obj.SetUID(types.UID(uid))
+		obj.SetCreationTimestamp(metav1.NewTime(t0))
+		if ready {
+			conditions := []interface{}{map[string]interface{}{
+				"type": "Ready", "status": "True", "lastTransitionTime": t0.Add(2 * time.Second).Format(time.RFC3339),
+			}}
+			_ = unstructured.SetNestedSlice(obj.Object, conditions, "status", "conditions")
+		}
+		return obj
+	}
+
+	var g measurementRegistry
+	// Listed ready before the watch started, the timestamps of the object are all there is
+	g.observeReadiness(testCase, widget("listed", true), time.Time{})
+	g.observeReadiness(testCase, widget("watched", false), t0.Add(100*time.Millisecond))
+	g.observeReadiness(testCase, widget("watched", true), t0.Add(350*time.Millisecond))
+	// Later versions do not move the time the object became ready
+	g.observeReadiness(testCase, widget("watched", true), t0.Add(time.Second))
+	g.observeReadiness(testCase, widget("pending", false), t0.Add(200*time.Millisecond))
+
+	created, ready, ok := g.readyTimes(testCase, widget("watched", true))
+	if !ok || ready.Sub(created) != 250*time.Millisecond {
+		t.Errorf("readyTimes(watched) = %s, %s, %t, want ready after 250ms", created, ready, ok)
+	}
+	created, ready, ok = g.readyTimes(testCase, widget("listed", true))
+	if !ok || ready.Sub(created) != 2*time.Second {
+		t.Errorf("readyTimes(listed) = %s, %s, %t, want ready after 2s", created, ready, ok)
+	}
+	// Objects the watch did not see ready yet fall back to their conditions
+	created, ready, ok = g.readyTimes(testCase, widget("unseen", true))
+	if !ok || ready.Sub(created) != 2*time.Second {
+		t.Errorf("readyTimes(unseen) = %s, %s, %t, want ready after 2s", created, ready, ok)
+	}
+
+	rep := &tofaniov1alpha1.Report{Spec: tofaniov1alpha1.ReportSpec{
+		Latencies: []tofaniov1alpha1.LatencySummary{{Name: LatencyTimeToReady, Count: 1}},
+	}}
+	g.addTo(rep, testCase)
+	latency := rep.Spec.Latencies[0]
+	if latency.Count != 2 || latency.Min.Duration != 250*time.Millisecond || latency.Max.Duration != 2*time.Second {
+		t.Errorf("timeToReady = %d samples from %s to %s, want 2 from 250ms to 2s", latency.Count, latency.Min.Duration, latency.Max.Duration)
+	}
+	last := rep.Spec.Timeline[len(rep.Spec.Timeline)-1]
+	if last.Created != 3 || last.Ready != 2 {
+		t.Errorf("timeline ends with %d created and %d ready, want 3 and 2", last.Created, last.Ready)
+	}
+}
@@ -5,32 +5,49 @@ import (
 	"encoding/json"
 	"fmt"
 	tofaniov1alpha1 "github.com/invioteq/tofan/api/v1alpha1"
+	"github.com/invioteq/tofan/internal/metrics"
+	"github.com/invioteq/tofan/internal/tracing"
 	"github.com/invioteq/tofan/pkg/constants"
 	"github.com/invioteq/tofan/pkg/utils"
-	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"go.opentelemetry.io/otel/trace"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime/schema"
-	"k8s.io/client-go/dynamic"
-	"sigs.k8s.io/controller-runtime/pkg/client"
-	"sigs.k8s.io/controller-runtime/pkg/client/config"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 	"sigs.k8s.io/yaml"
-	"strings"
+	"time"
 )
 
-func (r *Reconciler) ApplyObjectToCluster(ctx context.Context, objJSON []byte, testCaseName string) error {
+// ApplyObjectToCluster writes an instance of the TestCase with its apply strategy. Objects without a
+// namespace are created in the namespace of the TestCase. Every object is labelled with the name and
+// UID of the TestCase, objects in the TestCase namespace are owned by it and the others carry an
+// annotation pointing back to it.
+func (r *Reconciler) ApplyObjectToCluster(ctx context.Context, objJSON []byte, testCase *tofaniov1alpha1.TestCase) error {
+	obj, err := r.applyObject(ctx, objJSON, testCase, testCase.Spec.ApplyStrategy)
+	if err != nil {
+		return err
+	}
+	// The target controller may emit Events for the instance before the watch of the writes sees it
+	r.measurements.observeWrite(testCase, obj, false)
+	return nil
+}
+
+// applyObject writes the instance like ApplyObjectToCluster, with the given apply strategy, and
+// returns the object as the API server answered.
+func (r *Reconciler) applyObject(ctx context.Context, objJSON []byte, testCase *tofaniov1alpha1.TestCase, strategy tofaniov1alpha1.ApplyStrategy) (*unstructured.Unstructured, error) {
 	// First, convert JSON to YAML because some Kubernetes APIs expect YAML
 	objJSON, err := yaml.YAMLToJSON(objJSON)
 	if err != nil {
 		r.Log.Error(err, "Failed to convert object YAML to JSON")
-		return err
+		return nil, err
 	}
 
 	// Decode the JSON into an unstructured.Unstructured object
 	var unstrObj unstructured.Unstructured
 	if err := json.Unmarshal(objJSON, &unstrObj); err != nil {
 		r.Log.Error(err, "Failed to unmarshal JSON into Unstructured object")
-		return err
+		return nil, err
 	}
 
 	// Set GVK from the unstructured object itself
@@ -38,8 +55,15 @@ func (r *Reconciler) ApplyObjectToCluster(ctx context.Context, objJSON []byte, t
 
 	// Prepare the object for the Create or Update operation
 	unstrObj.SetGroupVersionKind(gvk)
-	if unstrObj.GetNamespace() == "" {
-		unstrObj.SetNamespace("default")
+	namespaced, err := r.IsObjectNamespaced(&unstrObj)
+	if err != nil {
+		r.Log.Error(err, "Failed to resolve the scope of the resource", "GVK", gvk)
+		return nil, err
+	}
+	if !namespaced {
+		unstrObj.SetNamespace("")
+	} else if unstrObj.GetNamespace() == "" {
+		unstrObj.SetNamespace(testCase.Namespace)
 	}
 	// Prepare the resource name
 	if unstrObj.GetName() == "" {
@@ -52,109 +76,154 @@ func (r *Reconciler) ApplyObjectToCluster(ctx context.Context, objJSON []byte, t
 		labels = make(map[string]string) // Initialize if nil
 	}
 	// Set or update the label with the object's name.
-	labels[constants.TofanTestCaseNameLabel] = testCaseName
+	labels[constants.TofanTestCaseNameLabel] = testCase.Name
+	if testCase.UID != "" {
+		labels[constants.TofanTestCaseUIDLabel] = string(testCase.UID)
+	}
+	if r.Standalone {
+		// Keeps the orphan sweeper of an operator running in the cluster away from the object
+		labels[constants.TofanStandaloneLabel] = "true"
+	}
 	unstrObj.SetLabels(labels)
 
-	// Check if the object already exists
-	namespacedName := client.ObjectKey{Namespace: unstrObj.GetNamespace(), Name: unstrObj.GetName()}
-	var existing unstructured.Unstructured
-	existing.SetGroupVersionKind(gvk)
-	err = r.Client.Get(ctx, namespacedName, &existing)
-
-	if err != nil {
-		if apierrors.IsNotFound(err) {
-			// Resource does not exist, so create it
-			if err := r.Client.Create(ctx, &unstrObj); err != nil {
-				r.Log.Error(err, "Failed to create new resource")
-				return err
-			}
-			r.Log.Info("Successfully created new resource", "GVK", gvk, "Name", unstrObj.GetName())
-			return nil
-		} else {
-			// An actual error occurred other than Not Found
-			r.Log.Error(err, "Failed to get existing resource")
-			return err
+	// Owner references cannot cross namespaces, and need the TestCase to exist in the cluster
+	if namespaced && unstrObj.GetNamespace() == testCase.Namespace && !r.Standalone {
+		if err := controllerutil.SetOwnerReference(testCase, &unstrObj, r.Scheme); err != nil {
+			r.Log.Error(err, "Failed to set owner reference", "Name", unstrObj.GetName())
+			return nil, err
 		}
 	} else {
-		// Resource exists, update it
-		unstrObj.SetResourceVersion(existing.GetResourceVersion())
-		if err := r.Client.Update(ctx, &unstrObj); err != nil {
-			r.Log.Error(err, "Failed to update existing resource")
-			return err
+		annotations := unstrObj.GetAnnotations()
+		if annotations == nil {
+			annotations = make(map[string]string)
 		}
-		r.Log.Info("Successfully updated existing resource", "GVK", gvk, "Name", unstrObj.GetName())
-		return nil
+		annotations[constants.TofanTestCaseAnnotation] = testCase.Namespace + "/" + testCase.Name
+		unstrObj.SetAnnotations(annotations)
+	}
+	// Target controllers continue the trace of the object from its annotation
+	if traceParent := tracing.TraceParent(ctx); traceParent != "" {
+		annotations := unstrObj.GetAnnotations()
+		if annotations == nil {
+			annotations = make(map[string]string)
+		}
+		annotations[constants.TofanTraceParentAnnotation] = traceParent
+		unstrObj.SetAnnotations(annotations)
 	}
-}
+	trace.SpanFromContext(ctx).SetAttributes(tracing.NamespaceKey.String(unstrObj.GetNamespace()), tracing.NameKey.String(unstrObj.GetName()))
 
-// TeardownResourcesForTestCase deletes all resources associated with a given TestCase, using objTpl to identify resource types.
-func (r *Reconciler) TeardownResourcesForTestCase(ctx context.Context, testCase *tofaniov1alpha1.TestCase, objTpl *tofaniov1alpha1.ObjectTemplate) error {
-	cfg, err := config.GetConfig()
+	// The requests are sent with the client of the run, so their rate is limited apart from the reconciles
+	c, err := r.loadClientFor(testCase)
 	if err != nil {
-		r.Log.Error(err, "Failed to get cluster config")
-		return err
+		r.Log.Error(err, "Failed to create load client", "TestCase", testCase.Name)
+		return nil, err
 	}
 
-	dynamicClient, err := dynamic.NewForConfig(cfg)
+	if err := r.writeObject(ctx, c, &unstrObj, strategy); err != nil {
+		return nil, err
+	}
+	return &unstrObj, nil
+}
+
+// observeRequest records the latency of a write request sent for an instance of the TestCase. The
+// requests the API server throttled are counted by the load client.
+func (r *Reconciler) observeRequest(verb string, gvk schema.GroupVersionKind, start time.Time) {
+	metrics.RequestDuration.WithLabelValues(verb, gvk.Group, gvk.Version, gvk.Kind).Observe(time.Since(start).Seconds())
+}
+
+// TeardownResourcesForTestCase deletes all resources associated with a given TestCase, using objTpl to
+// identify resource types. Resources are deleted in every namespace the run created them in.
+func (r *Reconciler) TeardownResourcesForTestCase(ctx context.Context, testCase *tofaniov1alpha1.TestCase, objTpl *tofaniov1alpha1.ObjectTemplate) error {
+	mapping, err := r.templateMapping(objTpl)
 	if err != nil {
-		r.Log.Error(err, "Failed to create dynamic client")
 		return err
 	}
-	// Construct the GroupVersionResource from ObjectTemplate status information
-	gvr := schema.GroupVersionResource{
-		Group:    objTpl.Status.Group,
-		Version:  objTpl.Status.Version,
-		Resource: fmt.Sprintf("%ss", strings.ToLower(objTpl.Status.Kind)), // Assuming simple pluralization
-	}
+	gvr := mapping.Resource
 
 	// Matching labels indicating they belong to the testCase
-	labelSelector := fmt.Sprintf("%s=%s", constants.TofanTestCaseNameLabel, testCase.Name)
+	labelSelector := testCaseSelector(testCase)
 	deletePolicy := metav1.DeletePropagationForeground
 	deleteOptions := metav1.DeleteOptions{
 		PropagationPolicy: &deletePolicy,
 	}
 
-	if err := dynamicClient.Resource(gvr).Namespace(testCase.Namespace).DeleteCollection(ctx, deleteOptions, metav1.ListOptions{LabelSelector: labelSelector}); err != nil {
-		r.Log.Error(err, "Failed to delete resources for testCase", "TestCase", testCase.Name, "GVR", gvr)
+	if mapping.Scope.Name() != meta.RESTScopeNameNamespace {
+		if err := r.Dynamic.Resource(gvr).DeleteCollection(ctx, deleteOptions, metav1.ListOptions{LabelSelector: labelSelector}); err != nil {
+			r.Log.Error(err, "Failed to delete resources for testCase", "TestCase", testCase.Name, "GVR", gvr)
+			return err
+		}
+		r.Log.Info("Successfully deleted resources for testCase", "TestCase", testCase.Name, "GVR", gvr)
+		return nil
+	}
+
+	resources, err := r.listTestCaseResources(ctx, testCase, objTpl)
+	if err != nil {
 		return err
 	}
+	namespaces := map[string]bool{testCase.Namespace: true}
+	for _, resource := range resources {
+		namespaces[resource.GetNamespace()] = true
+	}
 
-	r.Log.Info("Successfully deleted resources for testCase", "TestCase", testCase.Name, "GVR", gvr)
+	for namespace := range namespaces {
+		if err := r.Dynamic.Resource(gvr).Namespace(namespace).DeleteCollection(ctx, deleteOptions, metav1.ListOptions{LabelSelector: labelSelector}); err != nil {
+			r.Log.Error(err, "Failed to delete resources for testCase", "TestCase", testCase.Name, "GVR", gvr, "Namespace", namespace)
+			return err
+		}
+	}
+
+	r.Log.Info("Successfully deleted resources for testCase", "TestCase", testCase.Name, "GVR", gvr, "Namespaces", len(namespaces))
 	return nil
 }
 
 // CheckTestCaseResourcesReadiness checks if the given resources are ready by examining its status conditions.
 func (r *Reconciler) CheckTestCaseResourcesReadiness(ctx context.Context, testCase *tofaniov1alpha1.TestCase, objTpl *tofaniov1alpha1.ObjectTemplate) (bool, error) {
-	cfg, err := config.GetConfig()
+	resources, err := r.listTestCaseResources(ctx, testCase, objTpl)
 	if err != nil {
-		r.Log.Error(err, "Failed to get cluster config")
 		return false, err
 	}
 
-	dynamicClient, err := dynamic.NewForConfig(cfg)
-	if err != nil {
-		r.Log.Error(err, "Failed to create dynamic client")
-		return false, err
+	for _, resource := range resources {
+		if !IsResourceReady(&resource) {
+			return false, nil
+		}
 	}
 
-	gvr := schema.GroupVersionResource{
-		Group:    objTpl.Status.Group,
-		Version:  objTpl.Status.Version,
-		Resource: fmt.Sprintf("%ss", strings.ToLower(objTpl.Status.Kind)), // Assuming simple pluralization
+	return true, nil
+}
+
+// listTestCaseResources lists the resources created for the given TestCase, in all namespaces.
+func (r *Reconciler) listTestCaseResources(ctx context.Context, testCase *tofaniov1alpha1.TestCase, objTpl *tofaniov1alpha1.ObjectTemplate) ([]unstructured.Unstructured, error) {
+	mapping, err := r.templateMapping(objTpl)
+	if err != nil {
+		return nil, err
 	}
+	gvr := mapping.Resource
 
-	labelSelector := fmt.Sprintf("%s=%s", constants.TofanTestCaseNameLabel, testCase.Name)
-	resources, err := dynamicClient.Resource(gvr).Namespace(testCase.Namespace).List(ctx, metav1.ListOptions{LabelSelector: labelSelector})
+	resources, err := r.Dynamic.Resource(gvr).List(ctx, metav1.ListOptions{LabelSelector: testCaseSelector(testCase)})
 	if err != nil {
 		r.Log.Error(err, "Failed to list resources for testCase", "TestCase", testCase.Name, "GVR", gvr)
-		return false, err
+		return nil, err
 	}
 
-	for _, resource := range resources.Items {
-		if !isResourceReady(&resource) {
-			return false, nil
-		}
+	return resources.Items, nil
+}
+
+// templateMapping resolves the resource and scope of the objects of an ObjectTemplate from its status information.
+func (r *Reconciler) templateMapping(objTpl *tofaniov1alpha1.ObjectTemplate) (*meta.RESTMapping, error) {
+	gk := schema.GroupKind{Group: objTpl.Status.Group, Kind: objTpl.Status.Kind}
+	mapping, err := r.RESTMapper().RESTMapping(gk, objTpl.Status.Version)
+	if err != nil {
+		r.Log.Error(err, "Failed to resolve the resource of ObjectTemplate", "ObjectTemplate", objTpl.Name, "GroupKind", gk)
+		return nil, err
 	}
+	return mapping, nil
+}
 
-	return true, nil
+// testCaseSelector returns the label selector matching the objects created for the TestCase.
+func testCaseSelector(testCase *tofaniov1alpha1.TestCase) string {
+	selector := fmt.Sprintf("%s=%s", constants.TofanTestCaseNameLabel, testCase.Name)
+	if testCase.UID != "" {
+		selector += fmt.Sprintf(",%s=%s", constants.TofanTestCaseUIDLabel, testCase.UID)
+	}
+	return selector
 }
@@ -0,0 +1,233 @@
+package testcase
+
+import (
+	"context"
+	"fmt"
+	tofaniov1alpha1 "github.com/invioteq/tofan/api/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/tools/cache"
+	watchtools "k8s.io/client-go/tools/watch"
+	"sort"
+	"time"
+)
+
+const (
+	// unknownManager is the manager of the writes that could not be attributed to a field manager.
+	unknownManager = "Unknown"
+	// hotObjects is the number of objects written most listed in a report.
+	hotObjects = 10
+)
+
+// managerKey identifies an entry of the managedFields of an object.
+type managerKey struct {
+	manager     string
+	operation   string
+	subresource string
+}
+
+// objectWrites counts the writes to an object of a run.
+type objectWrites struct {
+	namespace       string
+	name            string
+	resourceVersion string
+	writes          int64
+	// managed holds the time of every entry of the managedFields of the last version observed
+	managed  map[managerKey]time.Time
+	managers map[managerKey]int64
+}
+
+// watchWrites watches the objects of the run of the TestCase until ctx is done and counts the writes
+// to them, attributing every write to a field manager. The counts are added to the report of the run,
+// along with the times the objects were observed created and ready.
+func (r *Reconciler) watchWrites(ctx context.Context, testCase *tofaniov1alpha1.TestCase, objTpl *tofaniov1alpha1.ObjectTemplate) {
+	mapping, err := r.templateMapping(objTpl)
+	if err != nil {
+		return
+	}
+	resource := r.Dynamic.Resource(mapping.Resource)
+	selector := testCaseSelector(testCase)
+
+	// Objects of a resumed run exist already, their current version is the baseline
+	list, err := resource.List(ctx, metav1.ListOptions{LabelSelector: selector})
+	if err != nil {
+		r.Log.Error(err, "Failed to list resources to watch writes", "TestCase", testCase.Name)
+		return
+	}
+	for i := range list.Items {
+		r.measurements.observeWrite(testCase, &list.Items[i], false)
+		r.measurements.observeReadiness(testCase, &list.Items[i], time.Time{})
+	}
+
+	watcher, err := watchtools.NewRetryWatcher(list.GetResourceVersion(), &cache.ListWatch{
+		WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
+			options.LabelSelector = selector
+			return resource.Watch(ctx, options)
+		},
+	})
+	if err != nil {
+		r.Log.Error(err, "Failed to watch writes", "TestCase", testCase.Name)
+		return
+	}
+	defer watcher.Stop()
+
+	for {
+		select {
+		case event, ok := <-watcher.ResultChan():
+			if !ok {
+				return
+			}
+			obj, ok := event.Object.(*unstructured.Unstructured)
+			if !ok {
+				continue
+			}
+			switch event.Type {
+			case watch.Added:
+				r.measurements.observeWrite(testCase, obj, false)
+				r.measurements.observeReadiness(testCase, obj, time.Now())
+			case watch.Modified:
+				r.measurements.observeWrite(testCase, obj, true)
+				r.measurements.observeReadiness(testCase, obj, time.Now())
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// observeWrite records a version of an object of the current run of the TestCase. modified counts it as
+// a write when its resourceVersion changed, the first version observed is the baseline.
+func (g *measurementRegistry) observeWrite(testCase *tofaniov1alpha1.TestCase, obj *unstructured.Unstructured, modified bool) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	m := g.forRun(testCase)
+	if m.writes == nil {
+		m.writes = make(map[types.UID]*objectWrites)
+	}
+	managed := managedTimes(obj.GetManagedFields())
+	w, ok := m.writes[obj.GetUID()]
+	if !ok {
+		m.writes[obj.GetUID()] = &objectWrites{
+			namespace:       obj.GetNamespace(),
+			name:            obj.GetName(),
+			resourceVersion: obj.GetResourceVersion(),
+			managed:         managed,
+			managers:        map[managerKey]int64{},
+		}
+		return
+	}
+	if !modified || w.resourceVersion == obj.GetResourceVersion() {
+		return
+	}
+
+	w.writes++
+	w.managers[writer(w.managed, managed)]++
+	w.resourceVersion = obj.GetResourceVersion()
+	w.managed = managed
+}
+
+// managedTimes returns the time of every entry of the managedFields.
+func managedTimes(entries []metav1.ManagedFieldsEntry) map[managerKey]time.Time {
+	managed := make(map[managerKey]time.Time, len(entries))
+	for _, entry := range entries {
+		var at time.Time
+		if entry.Time != nil {
+			at = entry.Time.Time
+		}
+		managed[managerKey{manager: entry.Manager, operation: string(entry.Operation), subresource: entry.Subresource}] = at
+	}
+	return managed
+}
+
+// writer returns the field manager a write is attributed to: the entry of the managedFields whose time
+// advanced since the previous version, the latest one when several did. managedFields have a
+// resolution of a second, a manager writing twice within it does not advance its time, so the write
+// is attributed to the latest entry when none advanced.
+func writer(previous, current map[managerKey]time.Time) managerKey {
+	var latest, advanced managerKey
+	var latestAt, advancedAt time.Time
+	for key, at := range current {
+		if before, ok := previous[key]; !ok || at.After(before) {
+			if advanced == (managerKey{}) || at.After(advancedAt) {
+				advanced, advancedAt = key, at
+			}
+		}
+		if latest == (managerKey{}) || at.After(latestAt) {
+			latest, latestAt = key, at
+		}
+	}
+
+	switch {
+	case advanced != (managerKey{}):
+		return advanced
+	case latest != (managerKey{}):
+		return latest
+	default:
+		return managerKey{manager: unknownManager}
+	}
+}
+
+// addWrites adds the writes observed for the objects of a run to its report.
+func addWrites(rep *tofaniov1alpha1.Report, writes map[types.UID]*objectWrites) {
+	if len(writes) == 0 {
+		return
+	}
+
+	stats := &tofaniov1alpha1.WriteStats{Objects: len(writes)}
+	managers := map[managerKey]int64{}
+	objects := make([]tofaniov1alpha1.ObjectWrites, 0, len(writes))
+	for _, w := range writes {
+		stats.Total += w.writes
+		if w.writes > stats.Max {
+			stats.Max = w.writes
+		}
+		for key, count := range w.managers {
+			managers[key] += count
+		}
+		objects = append(objects, tofaniov1alpha1.ObjectWrites{
+			Namespace: w.namespace,
+			Name:      w.name,
+			Writes:    w.writes,
+			Managers:  managerWrites(w.managers),
+		})
+	}
+	stats.PerObject = fmt.Sprintf("%.2f", float64(stats.Total)/float64(stats.Objects))
+	stats.Managers = managerWrites(managers)
+
+	sort.Slice(objects, func(i, j int) bool {
+		if objects[i].Writes != objects[j].Writes {
+			return objects[i].Writes > objects[j].Writes
+		}
+		return objects[i].Namespace+"/"+objects[i].Name < objects[j].Namespace+"/"+objects[j].Name
+	})
+	for _, object := range objects {
+		if len(stats.HotObjects) == hotObjects || object.Writes == 0 {
+			break
+		}
+		stats.HotObjects = append(stats.HotObjects, object)
+	}
+	rep.Spec.Writes = stats
+}
+
+// managerWrites returns the writes by field manager, the managers writing most first.
+func managerWrites(counts map[managerKey]int64) []tofaniov1alpha1.ManagerWrites {
+	managers := make([]tofaniov1alpha1.ManagerWrites, 0, len(counts))
+	for key, count := range counts {
+		managers = append(managers, tofaniov1alpha1.ManagerWrites{
+			Manager:     key.manager,
+			Operation:   key.operation,
+			Subresource: key.subresource,
+			Writes:      count,
+		})
+	}
+	sort.Slice(managers, func(i, j int) bool {
+		if managers[i].Writes != managers[j].Writes {
+			return managers[i].Writes > managers[j].Writes
+		}
+		return managers[i].Manager+"/"+managers[i].Subresource < managers[j].Manager+"/"+managers[j].Subresource
+	})
+	return managers
+}
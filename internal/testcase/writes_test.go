@@ -0,0 +1,143 @@
+package testcase
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	tofaniov1alpha1 "github.com/invioteq/tofan/api/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func TestWriter(t *testing.T) {
+	t0 := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	tofan := managerKey{manager: "tofan", operation: "Update"}
+	controller := managerKey{manager: "widget-controller", operation: "Update", subresource: "status"}
+	apply := managerKey{manager: "kubectl", operation: "Apply"}
+
+	tests := []struct {
+		name     string
+		previous map[managerKey]time.Time
+		current  map[managerKey]time.Time
+		want     managerKey
+	}{
+		{
+			name:     "the entry that advanced",
+			previous: map[managerKey]time.Time{tofan: t0, controller: t0},
+			current:  map[managerKey]time.Time{tofan: t0, controller: t0.Add(time.Second)},
+			want:     controller,
+		},
+		{
+			name:     "a new entry",
+			previous: map[managerKey]time.Time{tofan: t0},
+			current:  map[managerKey]time.Time{tofan: t0, apply: t0},
+			want:     apply,
+		},
+		{
+			name:     "the latest of several that advanced",
+			previous: map[managerKey]time.Time{tofan: t0, controller: t0},
+			current:  map[managerKey]time.Time{tofan: t0.Add(2 * time.Second), controller: t0.Add(time.Second)},
+			want:     tofan,
+		},
+		{
+			name:     "the latest entry when none advanced within the second",
+			previous: map[managerKey]time.Time{tofan: t0, controller: t0.Add(time.Second)},
+			current:  map[managerKey]time.Time{tofan: t0, controller: t0.Add(time.Second)},
+			want:     controller,
+		},
+		{
+			name: "unknown without managedFields",
+			want: managerKey{manager: unknownManager},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := writer(tt.previous, tt.current); got != tt.want {
+				t.Errorf("writer() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestObserveWrite(t *testing.T) {
+	t0 := metav1.NewTime(time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC))
+	t1 := metav1.NewTime(t0.Add(time.Second))
+	testCase := &tofaniov1alpha1.TestCase{
+		ObjectMeta: metav1.ObjectMeta{UID: "tc-uid"},
+		Status:     tofaniov1alpha1.TestCaseStatus{RunID: "run-1"},
+	}
+	version := func(resourceVersion string, managed ...metav1.ManagedFieldsEntry) *unstructured.Unstructured {
+		obj := &unstructured.Unstructured{Object: map[string]interface{}{}}
+		obj.SetUID("w-uid")
+		obj.SetNamespace("default")
+		obj.SetName("w-0")
+		obj.SetResourceVersion(resourceVersion)
+		obj.SetManagedFields(managed)
+		return obj
+	}
+	created := metav1.ManagedFieldsEntry{Manager: "tofan", Operation: metav1.ManagedFieldsOperationUpdate, Time: &t0}
+	status := metav1.ManagedFieldsEntry{Manager: "widget-controller", Operation: metav1.ManagedFieldsOperationUpdate, Subresource: "status", Time: &t1}
+
+	var g measurementRegistry
+	g.observeWrite(testCase, version("1", created), false)
+	// The watch replays the version it started from
+	g.observeWrite(testCase, version("1", created), true)
+	g.observeWrite(testCase, version("2", created, status), true)
+	g.observeWrite(testCase, version("3", created, status), true)
+
+	rep := &tofaniov1alpha1.Report{}
+	addWrites(rep, g.runs[types.UID("tc-uid")].writes)
+	want := &tofaniov1alpha1.WriteStats{
+		Objects:   1,
+		Total:     2,
+		PerObject: "2.00",
+		Max:       2,
+		Managers:  []tofaniov1alpha1.ManagerWrites{{Manager: "widget-controller", Operation: "Update", Subresource: "status", Writes: 2}},
+		HotObjects: []tofaniov1alpha1.ObjectWrites{{
+			Namespace: "default",
+			Name:      "w-0",
+			Writes:    2,
+			Managers:  []tofaniov1alpha1.ManagerWrites{{Manager: "widget-controller", Operation: "Update", Subresource: "status", Writes: 2}},
+		}},
+	}
+	if !reflect.DeepEqual(rep.Spec.Writes, want) {
+		t.Errorf("addWrites() = %+v, want %+v", rep.Spec.Writes, want)
+	}
+}
+
+func TestAddWrites(t *testing.T) {
+	tofan := managerKey{manager: "tofan", operation: "Update"}
+	controller := managerKey{manager: "widget-controller", operation: "Update"}
+	writes := map[types.UID]*objectWrites{
+		"a": {namespace: "default", name: "w-a", writes: 1, managers: map[managerKey]int64{controller: 1}},
+		"b": {namespace: "default", name: "w-b", writes: 4, managers: map[managerKey]int64{controller: 3, tofan: 1}},
+		"c": {namespace: "default", name: "w-c", managers: map[managerKey]int64{}},
+	}
+
+	rep := &tofaniov1alpha1.Report{}
+	addWrites(rep, writes)
+	got := rep.Spec.Writes
+	if got.Objects != 3 || got.Total != 5 || got.PerObject != "1.67" || got.Max != 4 {
+		t.Errorf("addWrites() = %d objects, %d writes, %s per object, %d max, want 3, 5, 1.67 and 4",
+			got.Objects, got.Total, got.PerObject, got.Max)
+	}
+	wantManagers := []tofaniov1alpha1.ManagerWrites{
+		{Manager: "widget-controller", Operation: "Update", Writes: 4},
+		{Manager: "tofan", Operation: "Update", Writes: 1},
+	}
+	if !reflect.DeepEqual(got.Managers, wantManagers) {
+		t.Errorf("Managers = %+v, want %+v", got.Managers, wantManagers)
+	}
+	// Objects never written are not hot
+	if len(got.HotObjects) != 2 || got.HotObjects[0].Name != "w-b" || got.HotObjects[1].Name != "w-a" {
+		t.Errorf("HotObjects = %+v, want w-b then w-a", got.HotObjects)
+	}
+
+	empty := &tofaniov1alpha1.Report{}
+	addWrites(empty, nil)
+	if empty.Spec.Writes != nil {
+		t.Errorf("addWrites() without objects = %+v, want nil", empty.Spec.Writes)
+	}
+}
@@ -0,0 +1,42 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// tracesPath is the path OTLP/HTTP receivers accept spans on.
+const tracesPath = "/v1/traces"
+
+// NewExporter returns an OTLP/HTTP exporter for the given endpoint. A bare host:port is reached over
+// plain HTTP, and spans are posted to /v1/traces unless the endpoint has a path of its own.
+func NewExporter(ctx context.Context, endpoint string) (sdktrace.SpanExporter, error) {
+	u, err := endpointURL(endpoint)
+	if err != nil {
+		return nil, err
+	}
+	return otlptracehttp.New(ctx, otlptracehttp.WithEndpointURL(u))
+}
+
+// endpointURL returns the URL spans are posted to for the given endpoint.
+func endpointURL(endpoint string) (string, error) {
+	if !strings.Contains(endpoint, "://") {
+		endpoint = "http://" + endpoint
+	}
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return "", fmt.Errorf("invalid OTLP endpoint %q: %w", endpoint, err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return "", fmt.Errorf("invalid OTLP endpoint %q: scheme must be http or https", endpoint)
+	}
+	if u.Path == "" || u.Path == "/" {
+		u.Path = tracesPath
+	}
+	return u.String(), nil
+}
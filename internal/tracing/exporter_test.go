@@ -0,0 +1,105 @@
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+	collectortracepb "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestExporterExportSpans(t *testing.T) {
+	requests := make(chan *collectortracepb.ExportTraceServiceRequest, 10)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != tracesPath || r.Header.Get("Content-Type") != "application/x-protobuf" {
+			t.Errorf("got %s with content type %q, want %s as protobuf", r.URL.Path, r.Header.Get("Content-Type"), tracesPath)
+		}
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			t.Errorf("failed to read request: %v", err)
+		}
+		req := &collectortracepb.ExportTraceServiceRequest{}
+		if err := proto.Unmarshal(body, req); err != nil {
+			t.Errorf("invalid request: %v", err)
+		}
+		requests <- req
+	}))
+	defer server.Close()
+
+	exporter, err := NewExporter(context.Background(), server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	tracer := provider.Tracer("tofan-test")
+
+	ctx, parent := tracer.Start(context.Background(), "run", trace.WithAttributes(attribute.Int64("count", 42)))
+	_, child := tracer.Start(ctx, "create")
+	child.AddEvent("ready")
+	child.SetStatus(codes.Error, "object failed")
+	child.End()
+	parent.End()
+	if err := provider.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	close(requests)
+
+	spans := map[string]*tracepb.Span{}
+	for req := range requests {
+		for _, rs := range req.ResourceSpans {
+			for _, ss := range rs.ScopeSpans {
+				if ss.Scope.GetName() != "tofan-test" {
+					t.Errorf("scope = %q, want tofan-test", ss.Scope.GetName())
+				}
+				for _, s := range ss.Spans {
+					spans[s.Name] = s
+				}
+			}
+		}
+	}
+	run, create := spans["run"], spans["create"]
+	if run == nil || create == nil {
+		t.Fatalf("got spans %v, want run and create", spans)
+	}
+
+	if !bytes.Equal(create.TraceId, run.TraceId) || !bytes.Equal(create.ParentSpanId, run.SpanId) {
+		t.Errorf("create has trace %x and parent %x, want trace %x and parent %x", create.TraceId, create.ParentSpanId, run.TraceId, run.SpanId)
+	}
+	if attrs := run.Attributes; len(attrs) != 1 || attrs[0].Key != "count" || attrs[0].Value.GetIntValue() != 42 {
+		t.Errorf("run attributes = %v, want count 42", attrs)
+	}
+	if create.Status.GetCode() != tracepb.Status_STATUS_CODE_ERROR || create.Status.GetMessage() != "object failed" {
+		t.Errorf("create status = %v, want an error with the description", create.Status)
+	}
+	if len(create.Events) != 1 || create.Events[0].Name != "ready" {
+		t.Errorf("create events = %v, want the ready event", create.Events)
+	}
+}
+
+func TestEndpointURL(t *testing.T) {
+	tests := []struct {
+		endpoint, want string
+	}{
+		{"collector:4318", "http://collector:4318/v1/traces"},
+		{"https://collector:4318", "https://collector:4318/v1/traces"},
+		{"http://collector:4318/custom", "http://collector:4318/custom"},
+	}
+	for _, tt := range tests {
+		got, err := endpointURL(tt.endpoint)
+		if err != nil || got != tt.want {
+			t.Errorf("endpointURL(%q) = %q, %v, want %s", tt.endpoint, got, err, tt.want)
+		}
+	}
+	if _, err := endpointURL("grpc://collector:4317"); err == nil {
+		t.Error("endpointURL() accepted a grpc endpoint")
+	}
+}
@@ -0,0 +1,81 @@
+// Package tracing exports TestCase runs as OpenTelemetry traces: a root span per run, with a child
+// span per created object that lasts from its create request until it is deleted.
+package tracing
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.21.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ScopeName is the instrumentation scope of the spans of tofan.
+const ScopeName = "github.com/invioteq/tofan"
+
+// Attribute keys of the spans.
+const (
+	TestCaseNamespaceKey = attribute.Key("tofan.testcase.namespace")
+	TestCaseNameKey      = attribute.Key("tofan.testcase.name")
+	RunIDKey             = attribute.Key("tofan.run.id")
+	CountKey             = attribute.Key("tofan.run.count")
+	ConcurrencyKey       = attribute.Key("tofan.run.concurrency")
+	PhaseKey             = attribute.Key("tofan.run.phase")
+	GroupKey             = attribute.Key("k8s.object.group")
+	VersionKey           = attribute.Key("k8s.object.version")
+	KindKey              = attribute.Key("k8s.object.kind")
+	NamespaceKey         = attribute.Key("k8s.object.namespace")
+	NameKey              = attribute.Key("k8s.object.name")
+	IndexKey             = attribute.Key("tofan.object.index")
+	// DynamicFieldPrefix prefixes the path of a dynamic field in the attribute holding its value
+	DynamicFieldPrefix = "tofan.dynamic_field."
+)
+
+// Events recorded on object spans.
+const (
+	EventCreated = "created"
+	EventReady   = "ready"
+	EventDeleted = "deleted"
+)
+
+// Tracer returns the tracer the spans of tofan are started with.
+func Tracer() trace.Tracer {
+	return otel.Tracer(ScopeName)
+}
+
+// Setup installs a global tracer provider exporting spans to the OTLP/HTTP endpoint, along with the
+// W3C trace context propagator. The returned function flushes the spans left and must be called
+// before the process exits. An empty endpoint leaves tracing disabled.
+func Setup(ctx context.Context, endpoint, serviceName string) (func(context.Context) error, error) {
+	if endpoint == "" {
+		return func(context.Context) error { return nil }, nil
+	}
+
+	exporter, err := NewExporter(ctx, endpoint)
+	if err != nil {
+		return nil, err
+	}
+	res, err := resource.Merge(resource.Default(), resource.NewWithAttributes(semconv.SchemaURL, semconv.ServiceName(serviceName)))
+	if err != nil {
+		return nil, err
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+	)
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	return provider.Shutdown, nil
+}
+
+// TraceParent returns the W3C traceparent of the span in ctx, or "" when ctx carries no sampled span.
+func TraceParent(ctx context.Context) string {
+	carrier := propagation.MapCarrier{}
+	propagation.TraceContext{}.Inject(ctx, carrier)
+	return carrier.Get("traceparent")
+}
@@ -10,5 +10,12 @@ const (
 	ObjConditionCreating string = "Creating"
 	ObjConditionFailed   string = "Failed"
 
-	TofanTestCaseNameLabel string = "tofan.io/testcase-name"
+	TofanTestCaseNameLabel   string = "tofan.io/testcase-name"
+	TofanTestCaseUIDLabel    string = "tofan.io/testcase-uid"
+	TofanStandaloneLabel     string = "tofan.io/standalone"
+	TofanInstanceIndexLabel  string = "tofan.io/instance-index"
+	TofanReportTestCaseLabel string = "tofan.io/report-testcase"
+
+	TofanTestCaseAnnotation    string = "tofan.io/testcase"
+	TofanTraceParentAnnotation string = "tofan.io/traceparent"
 )
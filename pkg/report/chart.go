@@ -0,0 +1,199 @@
+package report
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+const (
+	chartWidth        = 720
+	chartHeight       = 280
+	chartMarginLeft   = 64
+	chartMarginRight  = 16
+	chartMarginTop    = 16
+	chartMarginBottom = 44
+	chartTicks        = 5
+)
+
+// chartPalette holds the colors assigned to the series of a chart, in order.
+var chartPalette = []string{"#1f77b4", "#ff7f0e", "#2ca02c", "#d62728", "#9467bd", "#8c564b", "#e377c2", "#7f7f7f"}
+
+// point is a data point of a chart series.
+type point struct {
+	X, Y float64
+}
+
+// series is a named set of data points drawn as a line or as bars.
+type series struct {
+	Name   string
+	Points []point
+}
+
+// chart is an SVG chart with all geometry precomputed for the HTML template.
+type chart struct {
+	Title  string
+	XLabel string
+	YLabel string
+	Width  int
+	Height int
+	// Plot area bounds
+	Left, Right, Top, Bottom float64
+	XTicks                   []tick
+	YTicks                   []tick
+	Paths                    []path
+	Bars                     []bar
+	Empty                    bool
+
+	xMin, xMax, yMax float64
+}
+
+type tick struct {
+	Pos   float64
+	Label string
+}
+
+type path struct {
+	Name  string
+	Color string
+	D     string
+	// Dot marks the position of a series that consists of a single point
+	Dot *point
+}
+
+type bar struct {
+	X, Y, Width, Height float64
+	Color               string
+	Title               string
+}
+
+// newChart lays out the axes of a chart covering the given series.
+func newChart(title, xLabel, yLabel string, data []series, xFormat, yFormat func(float64) string) *chart {
+	c := &chart{
+		Title:  title,
+		XLabel: xLabel,
+		YLabel: yLabel,
+		Width:  chartWidth,
+		Height: chartHeight,
+		Left:   chartMarginLeft,
+		Right:  chartWidth - chartMarginRight,
+		Top:    chartMarginTop,
+		Bottom: chartHeight - chartMarginBottom,
+		Empty:  true,
+	}
+
+	minX, maxX, maxY := math.Inf(1), math.Inf(-1), 0.0
+	for _, s := range data {
+		for _, p := range s.Points {
+			c.Empty = false
+			minX = math.Min(minX, p.X)
+			maxX = math.Max(maxX, p.X)
+			maxY = math.Max(maxY, p.Y)
+		}
+	}
+	if c.Empty {
+		return c
+	}
+	if maxX == minX {
+		maxX = minX + 1
+	}
+	if maxY == 0 {
+		maxY = 1
+	}
+
+	for i := 0; i <= chartTicks; i++ {
+		fx := minX + (maxX-minX)*float64(i)/chartTicks
+		fy := maxY * float64(i) / chartTicks
+		c.XTicks = append(c.XTicks, tick{Pos: c.scaleX(fx, minX, maxX), Label: xFormat(fx)})
+		c.YTicks = append(c.YTicks, tick{Pos: c.scaleY(fy, maxY), Label: yFormat(fy)})
+	}
+
+	c.xMin, c.xMax, c.yMax = minX, maxX, maxY
+	return c
+}
+
+// newLineChart renders every series as a polyline.
+func newLineChart(title, xLabel, yLabel string, data []series, xFormat, yFormat func(float64) string) *chart {
+	c := newChart(title, xLabel, yLabel, data, xFormat, yFormat)
+	if c.Empty {
+		return c
+	}
+	for i, s := range data {
+		var d strings.Builder
+		for j, p := range s.Points {
+			cmd := "L"
+			if j == 0 {
+				cmd = "M"
+			}
+			fmt.Fprintf(&d, "%s%.1f %.1f ", cmd, c.scaleX(p.X, c.xMin, c.xMax), c.scaleY(p.Y, c.yMax))
+		}
+		p := path{Name: s.Name, Color: color(i), D: strings.TrimSpace(d.String())}
+		if len(s.Points) == 1 {
+			p.Dot = &point{X: c.scaleX(s.Points[0].X, c.xMin, c.xMax), Y: c.scaleY(s.Points[0].Y, c.yMax)}
+		}
+		c.Paths = append(c.Paths, p)
+	}
+	return c
+}
+
+// newBarChart renders a single series as bars, where each point's X is the upper bound of its bar.
+func newBarChart(title, xLabel, yLabel string, s series, lowerBound float64, xFormat, yFormat func(float64) string) *chart {
+	withOrigin := series{Name: s.Name, Points: append([]point{{X: lowerBound}}, s.Points...)}
+	c := newChart(title, xLabel, yLabel, []series{withOrigin}, xFormat, yFormat)
+	if c.Empty {
+		return c
+	}
+	prev := lowerBound
+	for _, p := range s.Points {
+		x0, x1 := c.scaleX(prev, c.xMin, c.xMax), c.scaleX(p.X, c.xMin, c.xMax)
+		y := c.scaleY(p.Y, c.yMax)
+		width := math.Max(x1-x0-1, 1)
+		c.Bars = append(c.Bars, bar{
+			X:      x0,
+			Y:      y,
+			Width:  width,
+			Height: c.Bottom - y,
+			Color:  color(0),
+			Title:  fmt.Sprintf("%s–%s: %s", xFormat(prev), xFormat(p.X), yFormat(p.Y)),
+		})
+		prev = p.X
+	}
+	c.Paths = append(c.Paths, path{Name: s.Name, Color: color(0)})
+	return c
+}
+
+func (c *chart) scaleX(x, minX, maxX float64) float64 {
+	return roundPixel(c.Left + (x-minX)/(maxX-minX)*(c.Right-c.Left))
+}
+
+func (c *chart) scaleY(y, maxY float64) float64 {
+	return roundPixel(c.Bottom - y/maxY*(c.Bottom-c.Top))
+}
+
+// roundPixel rounds a coordinate to a tenth of a pixel to keep the SVG compact.
+func roundPixel(v float64) float64 {
+	return math.Round(v*10) / 10
+}
+
+func color(i int) string {
+	return chartPalette[i%len(chartPalette)]
+}
+
+// formatAxisSeconds formats an axis value given in seconds.
+func formatAxisSeconds(v float64) string {
+	return formatAxisNumber(v) + "s"
+}
+
+// formatAxisNumber formats a plain axis value with a precision that depends on its magnitude.
+func formatAxisNumber(v float64) string {
+	precision := 3
+	switch abs := math.Abs(v); {
+	case abs >= 100:
+		precision = 0
+	case abs >= 1:
+		precision = 1
+	}
+	scale := math.Pow10(precision)
+	return strconv.FormatFloat(math.Round(v*scale)/scale, 'f', -1, 64)
+}
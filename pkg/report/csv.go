@@ -0,0 +1,173 @@
+package report
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+	"time"
+
+	tofaniov1alpha1 "github.com/invioteq/tofan/api/v1alpha1"
+)
+
+// csvHeader is the header of the long-format table produced by writeCSV.
+var csvHeader = []string{"report", "testcase", "section", "name", "key", "value"}
+
+// writeCSV renders the report as a long-format table, one value per row, so that
+// reports of several runs can be concatenated and pivoted by dashboards.
+func writeCSV(w io.Writer, report *tofaniov1alpha1.Report) error {
+	writer := csv.NewWriter(w)
+	row := func(section, name, key, value string) []string {
+		return []string{report.Name, report.Spec.TestCaseRef, section, name, key, value}
+	}
+
+	run := report.Spec.Run
+	rows := [][]string{
+		csvHeader,
+		row("run", "phase", "", run.Phase),
+		row("run", "objectTemplate", "", run.ObjectTemplate),
+		row("run", "gvk", "", gvkString(run)),
+		row("run", "count", "", strconv.Itoa(run.Count)),
+		row("run", "concurrency", "", strconv.Itoa(run.Concurrency)),
+		row("run", "created", "", strconv.Itoa(run.Created)),
+		row("run", "ready", "", strconv.Itoa(run.Ready)),
+		row("run", "durationMs", "", formatFloat(milliseconds(runDuration(report)))),
+	}
+	if run.Partial {
+		rows = append(rows, row("run", "partial", "", "true"))
+	}
+	if run.StartTime != nil {
+		rows = append(rows, row("run", "startTime", "", run.StartTime.UTC().Format(time.RFC3339)))
+	}
+	if run.CompletionTime != nil {
+		rows = append(rows, row("run", "completionTime", "", run.CompletionTime.UTC().Format(time.RFC3339)))
+	}
+
+	for _, assertion := range report.Spec.Assertions {
+		rows = append(rows,
+			row("assertion", assertion.Name, "passed", strconv.FormatBool(assertion.Passed)),
+			row("assertion", assertion.Name, "message", assertion.Message),
+		)
+	}
+
+	for _, result := range report.Spec.ObjectAssertions {
+		rows = append(rows,
+			row("objectAssertion", result.Name, "passed", strconv.Itoa(result.Passed)),
+			row("objectAssertion", result.Name, "failed", strconv.Itoa(result.Failed)),
+		)
+		for _, failure := range result.Failures {
+			rows = append(rows, row("objectAssertion", result.Name, "failure:"+objectName(failure.Namespace, failure.Name), failure.Message))
+		}
+	}
+
+	for _, e := range report.Spec.Errors {
+		rows = append(rows,
+			row("error", e.Reason, "count", strconv.Itoa(e.Count)),
+			row("error", e.Reason, "message", e.Message),
+		)
+	}
+
+	for _, latency := range report.Spec.Latencies {
+		rows = append(rows, row("latency", latency.Name, "count", strconv.Itoa(latency.Count)))
+		for _, field := range latencyFields(latency) {
+			rows = append(rows, row("latency", latency.Name, field.Name+"Ms", formatFloat(milliseconds(field.Value))))
+		}
+	}
+
+	if requests := report.Spec.Requests; requests != nil {
+		rows = append(rows,
+			row("requests", "sent", "", strconv.FormatInt(requests.Sent, 10)),
+			row("requests", "clientThrottled", "", strconv.FormatInt(requests.ClientThrottled, 10)),
+			row("requests", "clientThrottledTimeMs", "", formatFloat(milliseconds(requests.ClientThrottledTime.Duration))),
+			row("requests", "serverThrottled", "", strconv.FormatInt(requests.ServerThrottled, 10)),
+		)
+	}
+
+	if writes := report.Spec.Writes; writes != nil {
+		rows = append(rows,
+			row("writes", "objects", "", strconv.Itoa(writes.Objects)),
+			row("writes", "total", "", strconv.FormatInt(writes.Total, 10)),
+			row("writes", "perObject", "", writes.PerObject),
+			row("writes", "max", "", strconv.FormatInt(writes.Max, 10)),
+		)
+		for _, manager := range writes.Managers {
+			rows = append(rows, row("writeManager", joinNonEmpty(manager.Manager, manager.Operation, manager.Subresource), "writes", strconv.FormatInt(manager.Writes, 10)))
+		}
+		for _, object := range writes.HotObjects {
+			rows = append(rows, row("hotObject", objectName(object.Namespace, object.Name), "writes", strconv.FormatInt(object.Writes, 10)))
+		}
+	}
+
+	if events := report.Spec.Events; events != nil {
+		rows = append(rows,
+			row("events", "total", "", strconv.FormatInt(events.Total, 10)),
+			row("events", "warnings", "", strconv.FormatInt(events.Warnings, 10)),
+			row("events", "objects", "", strconv.Itoa(events.Objects)),
+		)
+		for _, reason := range events.Reasons {
+			name := joinNonEmpty(reason.Type, reason.Reason, reason.Controller)
+			rows = append(rows,
+				row("eventReason", name, "count", strconv.FormatInt(reason.Count, 10)),
+				row("eventReason", name, "objects", strconv.Itoa(reason.Objects)),
+				row("eventReason", name, "message", reason.Message),
+			)
+		}
+	}
+
+	if apiServer := report.Spec.APIServer; apiServer != nil {
+		rows = append(rows,
+			row("apiServer", "intervalMs", "", formatFloat(milliseconds(apiServer.Interval.Duration))),
+			row("apiServer", "requests", "", strconv.FormatInt(apiServer.Requests, 10)),
+			row("apiServer", "rejected", "", strconv.FormatInt(apiServer.Rejected, 10)),
+		)
+		if stored := apiServer.StoredObjects; stored != nil {
+			rows = append(rows,
+				row("storedObjects", stored.Resource, "before", strconv.FormatInt(stored.Before, 10)),
+				row("storedObjects", stored.Resource, "after", strconv.FormatInt(stored.After, 10)),
+			)
+		}
+		for _, requests := range apiServer.RequestCounts {
+			name := joinNonEmpty(requests.Verb, requests.Resource, requests.Subresource, requests.Code, requests.Component)
+			rows = append(rows, row("apiServerRequests", name, "count", strconv.FormatInt(requests.Count, 10)))
+		}
+		rows = appendAPIServerLatencies(rows, row, "apiServerLatency", apiServer.Latencies)
+		rows = appendAPIServerLatencies(rows, row, "etcdLatency", apiServer.StorageLatencies)
+		for _, rejection := range apiServer.Rejections {
+			name := joinNonEmpty(rejection.PriorityLevel, rejection.FlowSchema, rejection.Reason)
+			rows = append(rows, row("apfRejections", name, "count", strconv.FormatInt(rejection.Count, 10)))
+		}
+	}
+
+	for _, point := range report.Spec.Timeline {
+		offset := formatFloat(milliseconds(point.Offset.Duration))
+		rows = append(rows,
+			row("timeline", offset, "created", strconv.Itoa(point.Created)),
+			row("timeline", offset, "ready", strconv.Itoa(point.Ready)),
+		)
+	}
+
+	for _, series := range report.Spec.Metrics {
+		for _, sample := range series.Samples {
+			rows = append(rows, row("metric", series.Name, sample.Time.UTC().Format(time.RFC3339), sample.Value))
+		}
+	}
+
+	if err := writer.WriteAll(rows); err != nil {
+		return err
+	}
+	return writer.Error()
+}
+
+// appendAPIServerLatencies appends the rows of the API server latencies to rows, under the given section.
+func appendAPIServerLatencies(rows [][]string, row func(section, name, key, value string) []string, section string, latencies []tofaniov1alpha1.APIServerLatency) [][]string {
+	for _, latency := range latencies {
+		name := joinNonEmpty(latency.Verb, latency.Resource)
+		rows = append(rows,
+			row(section, name, "count", strconv.FormatInt(latency.Count, 10)),
+			row(section, name, "meanMs", formatFloat(milliseconds(latency.Mean.Duration))),
+			row(section, name, "p50Ms", formatFloat(milliseconds(latency.P50.Duration))),
+			row(section, name, "p90Ms", formatFloat(milliseconds(latency.P90.Duration))),
+			row(section, name, "p99Ms", formatFloat(milliseconds(latency.P99.Duration))),
+		)
+	}
+	return rows
+}
@@ -0,0 +1,121 @@
+package report
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	tofaniov1alpha1 "github.com/invioteq/tofan/api/v1alpha1"
+)
+
+// Export renders the report in the given format and writes it to w.
+func Export(w io.Writer, report *tofaniov1alpha1.Report, format tofaniov1alpha1.ReportFormat) error {
+	switch format {
+	case tofaniov1alpha1.ReportFormatJUnit:
+		return writeJUnit(w, report)
+	case tofaniov1alpha1.ReportFormatJSON:
+		return writeJSON(w, report)
+	case tofaniov1alpha1.ReportFormatCSV:
+		return writeCSV(w, report)
+	case tofaniov1alpha1.ReportFormatHTML:
+		return WriteHTML(w, []*tofaniov1alpha1.Report{report})
+	default:
+		return fmt.Errorf("unsupported report format %q", format)
+	}
+}
+
+// ParseFormat validates a format name given on the command line.
+func ParseFormat(name string) (tofaniov1alpha1.ReportFormat, error) {
+	switch format := tofaniov1alpha1.ReportFormat(name); format {
+	case tofaniov1alpha1.ReportFormatJUnit, tofaniov1alpha1.ReportFormatJSON, tofaniov1alpha1.ReportFormatCSV, tofaniov1alpha1.ReportFormatHTML:
+		return format, nil
+	default:
+		return "", fmt.Errorf("unsupported report format %q, expected one of junit, json, csv, html", name)
+	}
+}
+
+// FileName returns the file name a report rendered in the given format is stored under.
+func FileName(report *tofaniov1alpha1.Report, format tofaniov1alpha1.ReportFormat) string {
+	return report.Name + "." + Extension(format)
+}
+
+// Extension returns the file extension used for the given format.
+func Extension(format tofaniov1alpha1.ReportFormat) string {
+	if format == tofaniov1alpha1.ReportFormatJUnit {
+		return "xml"
+	}
+	return string(format)
+}
+
+// latencyField is a single named statistic of a LatencySummary.
+type latencyField struct {
+	Name  string
+	Value time.Duration
+}
+
+// latencyFields lists the statistics of a LatencySummary in a stable order.
+func latencyFields(summary tofaniov1alpha1.LatencySummary) []latencyField {
+	return []latencyField{
+		{Name: "min", Value: summary.Min.Duration},
+		{Name: "max", Value: summary.Max.Duration},
+		{Name: "mean", Value: summary.Mean.Duration},
+		{Name: "p50", Value: summary.P50.Duration},
+		{Name: "p90", Value: summary.P90.Duration},
+		{Name: "p95", Value: summary.P95.Duration},
+		{Name: "p99", Value: summary.P99.Duration},
+	}
+}
+
+// milliseconds converts a duration to fractional milliseconds.
+func milliseconds(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
+
+// runDuration returns the wall-clock duration of the run, or zero if it has not completed.
+func runDuration(report *tofaniov1alpha1.Report) time.Duration {
+	run := report.Spec.Run
+	if run.StartTime == nil || run.CompletionTime == nil {
+		return 0
+	}
+	return run.CompletionTime.Sub(run.StartTime.Time)
+}
+
+// gvkString formats the group, version and kind of the created objects.
+func gvkString(run tofaniov1alpha1.RunInfo) string {
+	if run.Kind == "" {
+		return ""
+	}
+	if run.Group == "" {
+		return run.Version + ", Kind=" + run.Kind
+	}
+	return run.Group + "/" + run.Version + ", Kind=" + run.Kind
+}
+
+// objectName formats the namespace and name of an object as namespace/name.
+func objectName(namespace, name string) string {
+	if namespace == "" {
+		return name
+	}
+	return namespace + "/" + name
+}
+
+// joinNonEmpty joins the non-empty parts with spaces.
+func joinNonEmpty(parts ...string) string {
+	var nonEmpty []string
+	for _, part := range parts {
+		if part != "" {
+			nonEmpty = append(nonEmpty, part)
+		}
+	}
+	return strings.Join(nonEmpty, " ")
+}
+
+func formatSeconds(d time.Duration) string {
+	return strconv.FormatFloat(d.Seconds(), 'f', 3, 64)
+}
+
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
@@ -0,0 +1,238 @@
+package report
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"strings"
+	"testing"
+	"time"
+
+	tofaniov1alpha1 "github.com/invioteq/tofan/api/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// testReport returns a report of a completed run with a passed and a failed assertion, and an
+// object assertion that failed for one object.
+func testReport() *tofaniov1alpha1.Report {
+	start := metav1.NewTime(time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC))
+	completion := metav1.NewTime(start.Add(90 * time.Second))
+	return &tofaniov1alpha1.Report{
+		ObjectMeta: metav1.ObjectMeta{Name: "widgets-abcde", Namespace: "default"},
+		Spec: tofaniov1alpha1.ReportSpec{
+			TestCaseRef: "widgets",
+			Run: tofaniov1alpha1.RunInfo{
+				Phase:          "Completed",
+				StartTime:      &start,
+				CompletionTime: &completion,
+				ObjectTemplate: "widget",
+				Group:          "simulator.tofan.io",
+				Version:        "v1alpha1",
+				Kind:           "Widget",
+				Count:          10,
+				Concurrency:    2,
+				Created:        10,
+				Ready:          9,
+			},
+			Assertions: []tofaniov1alpha1.Assertion{
+				{Name: "objectsCreated", Passed: true, Message: "10 objects created"},
+				{Name: "objectsReady", Passed: false, Message: "9 of 10 objects ready"},
+			},
+			Latencies: []tofaniov1alpha1.LatencySummary{
+				Summarize("timeToReady", []time.Duration{time.Second, 2 * time.Second, 3 * time.Second}),
+			},
+			Metrics: []tofaniov1alpha1.MetricSeries{{
+				Name: "cpu",
+				Expr: "sum(rate(cpu[1m]))",
+				Samples: []tofaniov1alpha1.MetricSample{
+					{Time: start, Value: "0.5"},
+					{Time: completion, Value: "1.25"},
+				},
+			}},
+			Timeline: []tofaniov1alpha1.TimelinePoint{
+				{},
+				{Offset: metav1.Duration{Duration: 90 * time.Second}, Created: 10, Ready: 9},
+			},
+			ObjectAssertions: []tofaniov1alpha1.ObjectAssertionResult{{
+				Name:     "sizeSet",
+				Passed:   9,
+				Failed:   1,
+				Failures: []tofaniov1alpha1.ObjectFailure{{Namespace: "default", Name: "widget-3", Message: "size is 0"}},
+			}},
+			Requests: &tofaniov1alpha1.RequestStats{Sent: 12, ClientThrottled: 3, ClientThrottledTime: metav1.Duration{Duration: 1500 * time.Millisecond}},
+			Errors:   []tofaniov1alpha1.ErrorCount{{Reason: "Invalid", Count: 1, Message: "spec.size: Invalid value"}},
+			Writes: &tofaniov1alpha1.WriteStats{
+				Objects: 10, Total: 25, PerObject: "2.5", Max: 4,
+				Managers: []tofaniov1alpha1.ManagerWrites{{Manager: "widget-controller", Operation: "Update", Subresource: "status", Writes: 20}},
+			},
+			Events: &tofaniov1alpha1.EventStats{
+				Total: 11, Warnings: 1, Objects: 10,
+				Reasons: []tofaniov1alpha1.EventReason{{Type: "Warning", Reason: "Failed", Controller: "widget-controller", Count: 1, Objects: 1}},
+			},
+			APIServer: &tofaniov1alpha1.APIServerStats{
+				Interval:      metav1.Duration{Duration: 90 * time.Second},
+				Requests:      140,
+				RequestCounts: []tofaniov1alpha1.APIServerRequests{{Verb: "POST", Resource: "widgets.simulator.tofan.io", Code: "201", Component: "apiserver", Count: 10}},
+				Latencies:     []tofaniov1alpha1.APIServerLatency{{Verb: "POST", Resource: "widgets.simulator.tofan.io", Count: 10, P99: metav1.Duration{Duration: 40 * time.Millisecond}}},
+				StoredObjects: &tofaniov1alpha1.StoredObjects{Resource: "widgets.simulator.tofan.io", Before: 0, After: 10},
+			},
+		},
+	}
+}
+
+func TestExportJUnit(t *testing.T) {
+	var out bytes.Buffer
+	if err := Export(&out, testReport(), tofaniov1alpha1.ReportFormatJUnit); err != nil {
+		t.Fatalf("Export() error = %v", err)
+	}
+
+	var suites junitTestSuites
+	if err := xml.Unmarshal(out.Bytes(), &suites); err != nil {
+		t.Fatalf("invalid JUnit XML: %v", err)
+	}
+	if suites.Tests != 3 || suites.Failures != 2 || suites.Time != "90.000" || len(suites.Suites) != 1 {
+		t.Fatalf("testsuites = %d tests, %d failures, time %s, want 3, 2 and 90.000", suites.Tests, suites.Failures, suites.Time)
+	}
+	suite := suites.Suites[0]
+	if suite.Name != "widgets" || suite.Timestamp != "2024-05-01T10:00:00" {
+		t.Errorf("testsuite = %s at %s, want widgets at 2024-05-01T10:00:00", suite.Name, suite.Timestamp)
+	}
+	properties := map[string]string{}
+	for _, p := range suite.Properties {
+		properties[p.Name] = p.Value
+	}
+	for name, want := range map[string]string{
+		"gvk":                "simulator.tofan.io/v1alpha1, Kind=Widget",
+		"created":            "10",
+		"timeToReady.count":  "3",
+		"timeToReady.p50":    "2s",
+		"requests.sent":      "12",
+		"errors.Invalid":     "1",
+		"writes.perObject":   "2.5",
+		"events.warnings":    "1",
+		"apiServer.requests": "140",
+	} {
+		if properties[name] != want {
+			t.Errorf("property %s = %q, want %q", name, properties[name], want)
+		}
+	}
+	if failure := suite.TestCases[1].Failure; failure == nil || failure.Message != "9 of 10 objects ready" {
+		t.Errorf("objectsReady failure = %+v, want the assertion message", failure)
+	}
+	if suite.TestCases[0].Failure != nil {
+		t.Errorf("objectsCreated failed, want it passed")
+	}
+	if failure := suite.TestCases[2].Failure; failure == nil || failure.Message != "9 of 10 objects passed" || failure.Text != "default/widget-3: size is 0\n" {
+		t.Errorf("sizeSet failure = %+v, want the failed objects", failure)
+	}
+}
+
+func TestExportCSV(t *testing.T) {
+	var out bytes.Buffer
+	if err := Export(&out, testReport(), tofaniov1alpha1.ReportFormatCSV); err != nil {
+		t.Fatalf("Export() error = %v", err)
+	}
+
+	rows, err := csv.NewReader(&out).ReadAll()
+	if err != nil {
+		t.Fatalf("invalid CSV: %v", err)
+	}
+	if strings.Join(rows[0], ",") != strings.Join(csvHeader, ",") {
+		t.Fatalf("header = %v, want %v", rows[0], csvHeader)
+	}
+	values := map[string]string{}
+	for _, row := range rows[1:] {
+		if len(row) != len(csvHeader) || row[0] != "widgets-abcde" || row[1] != "widgets" {
+			t.Fatalf("row %v does not name the report and TestCase", row)
+		}
+		values[row[2]+"/"+row[3]+"/"+row[4]] = row[5]
+	}
+	for key, want := range map[string]string{
+		"run/phase/":                                          "Completed",
+		"run/durationMs/":                                     "90000",
+		"run/startTime/":                                      "2024-05-01T10:00:00Z",
+		"assertion/objectsReady/passed":                       "false",
+		"latency/timeToReady/count":                           "3",
+		"latency/timeToReady/meanMs":                          "2000",
+		"metric/cpu/2024-05-01T10:01:30Z":                     "1.25",
+		"timeline/90000/ready":                                "9",
+		"objectAssertion/sizeSet/failed":                      "1",
+		"objectAssertion/sizeSet/failure:default/widget-3":    "size is 0",
+		"error/Invalid/count":                                 "1",
+		"requests/clientThrottledTimeMs/":                     "1500",
+		"writes/perObject/":                                   "2.5",
+		"writeManager/widget-controller Update status/writes": "20",
+		"events/total/":                                       "11",
+		"eventReason/Warning Failed widget-controller/count":  "1",
+		"apiServer/requests/":                                 "140",
+		"storedObjects/widgets.simulator.tofan.io/after":      "10",
+		"apiServerRequests/POST widgets.simulator.tofan.io 201 apiserver/count": "10",
+		"apiServerLatency/POST widgets.simulator.tofan.io/p99Ms":                "40",
+	} {
+		if got, ok := values[key]; !ok || got != want {
+			t.Errorf("%s = %q, want %q", key, got, want)
+		}
+	}
+}
+
+func TestExportJSON(t *testing.T) {
+	var out bytes.Buffer
+	if err := Export(&out, testReport(), tofaniov1alpha1.ReportFormatJSON); err != nil {
+		t.Fatalf("Export() error = %v", err)
+	}
+
+	var doc jsonDocument
+	if err := json.Unmarshal(out.Bytes(), &doc); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if doc.Name != "widgets-abcde" || doc.TestCase != "widgets" || doc.Run.DurationMs != 90000 || doc.Run.Ready != 9 {
+		t.Errorf("document = %+v, want the report and its run", doc)
+	}
+	if len(doc.Latencies) != 1 || doc.Latencies[0].P99Ms != 3000 {
+		t.Errorf("latencies = %+v, want timeToReady with a p99 of 3000ms", doc.Latencies)
+	}
+	if len(doc.Metrics) != 1 || len(doc.Metrics[0].Samples) != 2 {
+		t.Errorf("metrics = %+v, want the cpu series with its samples", doc.Metrics)
+	}
+	if len(doc.Assertions) != 2 || doc.Assertions[1].Passed {
+		t.Errorf("assertions = %+v, want objectsReady failed", doc.Assertions)
+	}
+	if doc.Requests == nil || doc.Requests.Sent != 12 || len(doc.Errors) != 1 {
+		t.Errorf("requests = %+v and errors = %+v, want them exported", doc.Requests, doc.Errors)
+	}
+	if len(doc.Timeline) != 2 || doc.Timeline[1].OffsetMs != 90000 || doc.Timeline[1].Ready != 9 {
+		t.Errorf("timeline = %+v, want its points in milliseconds", doc.Timeline)
+	}
+}
+
+func TestParseFormat(t *testing.T) {
+	for _, name := range []string{"junit", "json", "csv", "html"} {
+		if format, err := ParseFormat(name); err != nil || string(format) != name {
+			t.Errorf("ParseFormat(%q) = %q, %v", name, format, err)
+		}
+	}
+	if _, err := ParseFormat("pdf"); err == nil {
+		t.Error("ParseFormat(\"pdf\") returned no error")
+	}
+	if err := Export(&bytes.Buffer{}, testReport(), "pdf"); err == nil {
+		t.Error("Export() accepted the pdf format")
+	}
+}
+
+func TestFileName(t *testing.T) {
+	tests := []struct {
+		format tofaniov1alpha1.ReportFormat
+		want   string
+	}{
+		{tofaniov1alpha1.ReportFormatJUnit, "widgets-abcde.xml"},
+		{tofaniov1alpha1.ReportFormatJSON, "widgets-abcde.json"},
+		{tofaniov1alpha1.ReportFormatCSV, "widgets-abcde.csv"},
+		{tofaniov1alpha1.ReportFormatHTML, "widgets-abcde.html"},
+	}
+	for _, tt := range tests {
+		if got := FileName(testReport(), tt.format); got != tt.want {
+			t.Errorf("FileName(%s) = %s, want %s", tt.format, got, tt.want)
+		}
+	}
+}
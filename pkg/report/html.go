@@ -0,0 +1,374 @@
+package report
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+	"strconv"
+	"time"
+
+	tofaniov1alpha1 "github.com/invioteq/tofan/api/v1alpha1"
+)
+
+type htmlPage struct {
+	Title      string
+	Generated  string
+	Reports    []htmlReport
+	Assertions []htmlRow
+	Latencies  []htmlLatencyTable
+	Histograms []*chart
+	Timeline   *chart
+	Metrics    []*chart
+	APIServer  []htmlRow
+}
+
+type htmlReport struct {
+	Name        string
+	TestCase    string
+	Phase       string
+	Start       string
+	Duration    string
+	GVK         string
+	Count       int
+	Concurrency int
+	Created     int
+	Ready       int
+	Color       string
+}
+
+type htmlRow struct {
+	Name   string
+	Values []htmlCell
+}
+
+type htmlCell struct {
+	Text  string
+	Class string
+}
+
+type htmlLatencyTable struct {
+	Name string
+	Rows []htmlRow
+}
+
+// WriteHTML renders one or more reports into a single self-contained HTML page. When several reports
+// are given, their tables are laid out side by side and their series are overlaid for comparison.
+func WriteHTML(w io.Writer, reports []*tofaniov1alpha1.Report) error {
+	if len(reports) == 0 {
+		return fmt.Errorf("no reports to render")
+	}
+
+	page := htmlPage{
+		Title:     "Tofan report: " + reports[0].Spec.TestCaseRef,
+		Generated: time.Now().UTC().Format(time.RFC3339),
+	}
+	if len(reports) > 1 {
+		page.Title = fmt.Sprintf("Tofan comparison of %d reports", len(reports))
+	}
+
+	for i, rep := range reports {
+		run := rep.Spec.Run
+		entry := htmlReport{
+			Name:        rep.Name,
+			TestCase:    rep.Spec.TestCaseRef,
+			Phase:       run.Phase,
+			Duration:    runDuration(rep).Round(time.Millisecond).String(),
+			GVK:         gvkString(run),
+			Count:       run.Count,
+			Concurrency: run.Concurrency,
+			Created:     run.Created,
+			Ready:       run.Ready,
+			Color:       color(i),
+		}
+		if run.Partial {
+			entry.Phase += " (partial)"
+		}
+		if run.StartTime != nil {
+			entry.Start = run.StartTime.UTC().Format(time.RFC3339)
+		}
+		page.Reports = append(page.Reports, entry)
+	}
+
+	page.Assertions = assertionRows(reports)
+	page.Latencies = latencyTables(reports)
+	page.Histograms = histogramCharts(reports)
+	page.Timeline = timelineChart(reports)
+	page.Metrics = metricCharts(reports)
+	page.APIServer = apiServerRows(reports)
+
+	return htmlTemplate.Execute(w, page)
+}
+
+// assertionRows lines up the assertions of every report by name.
+func assertionRows(reports []*tofaniov1alpha1.Report) []htmlRow {
+	var rows []htmlRow
+	index := map[string]int{}
+	for i, rep := range reports {
+		for _, assertion := range rep.Spec.Assertions {
+			idx, ok := index[assertion.Name]
+			if !ok {
+				idx = len(rows)
+				index[assertion.Name] = idx
+				rows = append(rows, htmlRow{Name: assertion.Name, Values: make([]htmlCell, len(reports))})
+			}
+			cell := htmlCell{Text: "FAIL: " + assertion.Message, Class: "fail"}
+			if assertion.Passed {
+				cell = htmlCell{Text: "PASS: " + assertion.Message, Class: "pass"}
+			}
+			rows[idx].Values[i] = cell
+		}
+	}
+	return rows
+}
+
+// apiServerRows lines up the API server request counts of every report by verb, resource, code and
+// component.
+func apiServerRows(reports []*tofaniov1alpha1.Report) []htmlRow {
+	var rows []htmlRow
+	index := map[string]int{}
+	for i, rep := range reports {
+		if rep.Spec.APIServer == nil {
+			continue
+		}
+		for _, requests := range rep.Spec.APIServer.RequestCounts {
+			resource := requests.Resource
+			if requests.Subresource != "" {
+				resource += "/" + requests.Subresource
+			}
+			name := joinNonEmpty(requests.Verb, resource, requests.Code, requests.Component)
+			idx, ok := index[name]
+			if !ok {
+				idx = len(rows)
+				index[name] = idx
+				rows = append(rows, htmlRow{Name: name, Values: make([]htmlCell, len(reports))})
+			}
+			rows[idx].Values[i] = htmlCell{Text: strconv.FormatInt(requests.Count, 10)}
+		}
+	}
+	return rows
+}
+
+// latencyTables builds one percentile table per latency distribution with a column per report.
+func latencyTables(reports []*tofaniov1alpha1.Report) []htmlLatencyTable {
+	var tables []htmlLatencyTable
+	index := map[string]int{}
+	for i, rep := range reports {
+		for _, latency := range rep.Spec.Latencies {
+			idx, ok := index[latency.Name]
+			if !ok {
+				idx = len(tables)
+				index[latency.Name] = idx
+				table := htmlLatencyTable{Name: latency.Name}
+				table.Rows = append(table.Rows, htmlRow{Name: "count", Values: make([]htmlCell, len(reports))})
+				for _, field := range latencyFields(latency) {
+					table.Rows = append(table.Rows, htmlRow{Name: field.Name, Values: make([]htmlCell, len(reports))})
+				}
+				tables = append(tables, table)
+			}
+			tables[idx].Rows[0].Values[i] = htmlCell{Text: strconv.Itoa(latency.Count)}
+			for j, field := range latencyFields(latency) {
+				tables[idx].Rows[j+1].Values[i] = htmlCell{Text: field.Value.String()}
+			}
+		}
+	}
+	return tables
+}
+
+// histogramCharts draws a histogram for every latency distribution of every report.
+func histogramCharts(reports []*tofaniov1alpha1.Report) []*chart {
+	var charts []*chart
+	for _, rep := range reports {
+		for _, latency := range rep.Spec.Latencies {
+			if len(latency.Buckets) == 0 {
+				continue
+			}
+			s := series{Name: rep.Name}
+			for _, bucket := range latency.Buckets {
+				s.Points = append(s.Points, point{X: bucket.UpperBound.Seconds(), Y: float64(bucket.Count)})
+			}
+			lower := latency.Min.Seconds()
+			if len(latency.Buckets) == 1 {
+				lower = 0
+			}
+			title := latency.Name
+			if len(reports) > 1 {
+				title += " (" + rep.Name + ")"
+			}
+			charts = append(charts, newBarChart(title, "latency", "objects", s, lower, formatAxisSeconds, formatAxisNumber))
+		}
+	}
+	return charts
+}
+
+// timelineChart overlays the created and ready curves of every report.
+func timelineChart(reports []*tofaniov1alpha1.Report) *chart {
+	var data []series
+	for _, rep := range reports {
+		if len(rep.Spec.Timeline) == 0 {
+			continue
+		}
+		created := series{Name: rep.Name + " created"}
+		ready := series{Name: rep.Name + " ready"}
+		for _, p := range rep.Spec.Timeline {
+			created.Points = append(created.Points, point{X: p.Offset.Seconds(), Y: float64(p.Created)})
+			ready.Points = append(ready.Points, point{X: p.Offset.Seconds(), Y: float64(p.Ready)})
+		}
+		data = append(data, created, ready)
+	}
+	if len(data) == 0 {
+		return nil
+	}
+	return newLineChart("Objects over time", "time since start", "objects", data, formatAxisSeconds, formatAxisNumber)
+}
+
+// metricCharts draws one chart per TargetMetrics series name, with a line per report.
+func metricCharts(reports []*tofaniov1alpha1.Report) []*chart {
+	var names []string
+	byName := map[string][]series{}
+	for _, rep := range reports {
+		var start time.Time
+		if rep.Spec.Run.StartTime != nil {
+			start = rep.Spec.Run.StartTime.Time
+		}
+		for _, metric := range rep.Spec.Metrics {
+			s := series{Name: rep.Name}
+			for _, sample := range metric.Samples {
+				value, err := strconv.ParseFloat(sample.Value, 64)
+				if err != nil {
+					continue
+				}
+				if start.IsZero() {
+					start = sample.Time.Time
+				}
+				s.Points = append(s.Points, point{X: sample.Time.Sub(start).Seconds(), Y: value})
+			}
+			if len(s.Points) == 0 {
+				continue
+			}
+			if _, ok := byName[metric.Name]; !ok {
+				names = append(names, metric.Name)
+			}
+			byName[metric.Name] = append(byName[metric.Name], s)
+		}
+	}
+
+	var charts []*chart
+	for _, name := range names {
+		charts = append(charts, newLineChart(name, "time since start", name, byName[name], formatAxisSeconds, formatAxisNumber))
+	}
+	return charts
+}
+
+var htmlTemplate = template.Must(template.New("report").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+<style>
+body { font-family: -apple-system, "Segoe UI", Helvetica, Arial, sans-serif; margin: 2em; color: #222; }
+h1 { font-size: 1.5em; }
+h2 { font-size: 1.2em; margin-top: 2em; border-bottom: 1px solid #ddd; padding-bottom: .2em; }
+table { border-collapse: collapse; margin: .5em 0; }
+th, td { border: 1px solid #ddd; padding: .3em .6em; text-align: left; font-size: .9em; }
+th { background: #f5f5f5; }
+td.pass { color: #1a7f37; }
+td.fail { color: #cf222e; font-weight: bold; }
+.swatch { display: inline-block; width: .8em; height: .8em; margin-right: .3em; }
+.legend { font-size: .85em; margin: .3em 0 1em 0; }
+.legend span { margin-right: 1.2em; }
+svg { display: block; }
+svg text { font-size: 11px; fill: #444; }
+svg .axis { stroke: #888; }
+svg .grid { stroke: #eee; }
+footer { margin-top: 3em; font-size: .8em; color: #888; }
+</style>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+
+<h2>Runs</h2>
+<table>
+<tr><th>Report</th><th>TestCase</th><th>Phase</th><th>Start</th><th>Duration</th><th>GVK</th><th>Count</th><th>Concurrency</th><th>Created</th><th>Ready</th></tr>
+{{- range .Reports}}
+<tr><td><span class="swatch" style="background: {{.Color}}"></span>{{.Name}}</td><td>{{.TestCase}}</td><td>{{.Phase}}</td><td>{{.Start}}</td><td>{{.Duration}}</td><td>{{.GVK}}</td><td>{{.Count}}</td><td>{{.Concurrency}}</td><td>{{.Created}}</td><td>{{.Ready}}</td></tr>
+{{- end}}
+</table>
+
+{{- if .Assertions}}
+<h2>Assertions</h2>
+<table>
+<tr><th>Assertion</th>{{range .Reports}}<th>{{.Name}}</th>{{end}}</tr>
+{{- range .Assertions}}
+<tr><td>{{.Name}}</td>{{range .Values}}<td class="{{.Class}}">{{.Text}}</td>{{end}}</tr>
+{{- end}}
+</table>
+{{- end}}
+
+{{- if .Latencies}}
+<h2>Latencies</h2>
+{{- range .Latencies}}
+<h3>{{.Name}}</h3>
+<table>
+<tr><th></th>{{range $.Reports}}<th>{{.Name}}</th>{{end}}</tr>
+{{- range .Rows}}
+<tr><td>{{.Name}}</td>{{range .Values}}<td>{{.Text}}</td>{{end}}</tr>
+{{- end}}
+</table>
+{{- end}}
+{{- range .Histograms}}{{template "chart" .}}{{end}}
+{{- end}}
+
+{{- if .Timeline}}
+<h2>Objects over time</h2>
+{{template "chart" .Timeline}}
+{{- end}}
+
+{{- if .Metrics}}
+<h2>Target metrics</h2>
+{{- range .Metrics}}{{template "chart" .}}{{end}}
+{{- end}}
+
+{{- if .APIServer}}
+<h2>API server requests</h2>
+<p>Requests of every client: apiserver_request_total has no client label, the component is the part of the API server that served them.</p>
+<table>
+<tr><th>Request</th>{{range .Reports}}<th>{{.Name}}</th>{{end}}</tr>
+{{- range .APIServer}}
+<tr><td>{{.Name}}</td>{{range .Values}}<td>{{.Text}}</td>{{end}}</tr>
+{{- end}}
+</table>
+{{- end}}
+
+<footer>Generated by tofan at {{.Generated}}</footer>
+</body>
+</html>
+{{define "chart"}}
+<h3>{{.Title}}</h3>
+{{- if .Empty}}
+<p>No data.</p>
+{{- else}}
+<svg xmlns="http://www.w3.org/2000/svg" width="{{.Width}}" height="{{.Height}}" viewBox="0 0 {{.Width}} {{.Height}}">
+{{- $c := .}}
+{{- range .YTicks}}
+<line class="grid" x1="{{$c.Left}}" x2="{{$c.Right}}" y1="{{.Pos}}" y2="{{.Pos}}"/>
+<text x="{{$c.Left}}" y="{{.Pos}}" dx="-6" dy="4" text-anchor="end">{{.Label}}</text>
+{{- end}}
+{{- range .XTicks}}
+<text x="{{.Pos}}" y="{{$c.Bottom}}" dy="16" text-anchor="middle">{{.Label}}</text>
+{{- end}}
+<line class="axis" x1="{{.Left}}" x2="{{.Right}}" y1="{{.Bottom}}" y2="{{.Bottom}}"/>
+<line class="axis" x1="{{.Left}}" x2="{{.Left}}" y1="{{.Top}}" y2="{{.Bottom}}"/>
+{{- range .Bars}}
+<rect x="{{.X}}" y="{{.Y}}" width="{{.Width}}" height="{{.Height}}" fill="{{.Color}}"><title>{{.Title}}</title></rect>
+{{- end}}
+{{- range .Paths}}{{if .Dot}}
+<circle cx="{{.Dot.X}}" cy="{{.Dot.Y}}" r="3" fill="{{.Color}}"/>
+{{- else if .D}}
+<path d="{{.D}}" fill="none" stroke="{{.Color}}" stroke-width="2"/>
+{{- end}}{{end}}
+<text x="{{.Right}}" y="{{.Height}}" dy="-6" text-anchor="end">{{.XLabel}}</text>
+<text x="12" y="{{.Top}}" transform="rotate(-90 12 {{.Top}})" text-anchor="end">{{.YLabel}}</text>
+</svg>
+<div class="legend">{{range .Paths}}<span><span class="swatch" style="background: {{.Color}}"></span>{{.Name}}</span>{{end}}</div>
+{{- end}}
+{{end}}`))
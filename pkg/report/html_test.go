@@ -0,0 +1,63 @@
+package report
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	tofaniov1alpha1 "github.com/invioteq/tofan/api/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestWriteHTML(t *testing.T) {
+	if err := WriteHTML(&bytes.Buffer{}, nil); err == nil {
+		t.Error("WriteHTML() accepted no reports")
+	}
+
+	rep := testReport()
+	rep.Spec.Timeline = []tofaniov1alpha1.TimelinePoint{
+		{Created: 1},
+		{Offset: metav1.Duration{Duration: time.Minute}, Created: 10, Ready: 9},
+	}
+	other := testReport()
+	other.Name = "widgets-fghij"
+
+	tests := []struct {
+		name     string
+		reports  []*tofaniov1alpha1.Report
+		contains []string
+	}{
+		{
+			name:    "single report",
+			reports: []*tofaniov1alpha1.Report{rep},
+			contains: []string{
+				"<title>Tofan report: widgets</title>",
+				"<h2>Assertions</h2>", "objectsReady",
+				"<h2>Latencies</h2>", "<h3>timeToReady</h3>",
+				"<h2>Objects over time</h2>",
+				"<h2>Target metrics</h2>",
+				"<h2>API server requests</h2>", "POST widgets.simulator.tofan.io 201 apiserver",
+				"<svg",
+			},
+		},
+		{
+			name:     "comparison",
+			reports:  []*tofaniov1alpha1.Report{rep, other},
+			contains: []string{"<title>Tofan comparison of 2 reports</title>", "widgets-abcde", "widgets-fghij"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			if err := WriteHTML(&out, tt.reports); err != nil {
+				t.Fatalf("WriteHTML() error = %v", err)
+			}
+			for _, want := range tt.contains {
+				if !strings.Contains(out.String(), want) {
+					t.Errorf("WriteHTML() output does not contain %q", want)
+				}
+			}
+		})
+	}
+}
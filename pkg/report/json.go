@@ -0,0 +1,152 @@
+package report
+
+import (
+	"encoding/json"
+	"io"
+	"time"
+
+	tofaniov1alpha1 "github.com/invioteq/tofan/api/v1alpha1"
+)
+
+type jsonDocument struct {
+	Name       string                      `json:"name"`
+	Namespace  string                      `json:"namespace,omitempty"`
+	TestCase   string                      `json:"testCase"`
+	Run        jsonRun                     `json:"run"`
+	Assertions []tofaniov1alpha1.Assertion `json:"assertions"`
+	Latencies  []jsonLatency               `json:"latencies"`
+	Metrics    []jsonMetric                `json:"metrics"`
+	// Timeline holds the number of created and ready objects over the course of the run
+	Timeline []jsonTimelinePoint `json:"timeline,omitempty"`
+	// Requests describes the requests the run sent to create objects
+	Requests *tofaniov1alpha1.RequestStats `json:"requests,omitempty"`
+	// Errors counts the instances that could not be created by reason
+	Errors []tofaniov1alpha1.ErrorCount `json:"errors,omitempty"`
+	// ObjectAssertions holds the per-object outcome of the assertions of the TestCase
+	ObjectAssertions []tofaniov1alpha1.ObjectAssertionResult `json:"objectAssertions,omitempty"`
+	// Writes breaks down the writes to the objects of the run
+	Writes *tofaniov1alpha1.WriteStats `json:"writes,omitempty"`
+	// Events aggregates the Kubernetes Events emitted for the objects of the run
+	Events *tofaniov1alpha1.EventStats `json:"events,omitempty"`
+	// APIServer holds the change of the metrics of the API server over the run
+	APIServer *tofaniov1alpha1.APIServerStats `json:"apiServer,omitempty"`
+	// Diagnostics lists the objects of a failed or aborted run captured before teardown
+	Diagnostics *tofaniov1alpha1.Diagnostics `json:"diagnostics,omitempty"`
+}
+
+type jsonRun struct {
+	Phase          string     `json:"phase,omitempty"`
+	StartTime      *time.Time `json:"startTime,omitempty"`
+	CompletionTime *time.Time `json:"completionTime,omitempty"`
+	DurationMs     float64    `json:"durationMs"`
+	ObjectTemplate string     `json:"objectTemplate,omitempty"`
+	GVK            string     `json:"gvk,omitempty"`
+	Count          int        `json:"count"`
+	Concurrency    int        `json:"concurrency"`
+	Created        int        `json:"created"`
+	Ready          int        `json:"ready"`
+	Partial        bool       `json:"partial,omitempty"`
+}
+
+type jsonLatency struct {
+	Name   string  `json:"name"`
+	Count  int     `json:"count"`
+	MinMs  float64 `json:"minMs"`
+	MaxMs  float64 `json:"maxMs"`
+	MeanMs float64 `json:"meanMs"`
+	P50Ms  float64 `json:"p50Ms"`
+	P90Ms  float64 `json:"p90Ms"`
+	P95Ms  float64 `json:"p95Ms"`
+	P99Ms  float64 `json:"p99Ms"`
+}
+
+type jsonMetric struct {
+	Name    string       `json:"name"`
+	Expr    string       `json:"expr,omitempty"`
+	Samples []jsonSample `json:"samples"`
+}
+
+type jsonTimelinePoint struct {
+	OffsetMs float64 `json:"offsetMs"`
+	Created  int     `json:"created"`
+	Ready    int     `json:"ready"`
+}
+
+type jsonSample struct {
+	Time  time.Time `json:"time"`
+	Value string    `json:"value"`
+}
+
+// writeJSON renders the report as a self-describing JSON document with durations in milliseconds.
+func writeJSON(w io.Writer, report *tofaniov1alpha1.Report) error {
+	run := report.Spec.Run
+	doc := jsonDocument{
+		Name:             report.Name,
+		Namespace:        report.Namespace,
+		TestCase:         report.Spec.TestCaseRef,
+		Assertions:       report.Spec.Assertions,
+		Latencies:        []jsonLatency{},
+		Metrics:          []jsonMetric{},
+		Requests:         report.Spec.Requests,
+		Errors:           report.Spec.Errors,
+		ObjectAssertions: report.Spec.ObjectAssertions,
+		Writes:           report.Spec.Writes,
+		Events:           report.Spec.Events,
+		APIServer:        report.Spec.APIServer,
+		Diagnostics:      report.Spec.Diagnostics,
+		Run: jsonRun{
+			Phase:          run.Phase,
+			DurationMs:     milliseconds(runDuration(report)),
+			ObjectTemplate: run.ObjectTemplate,
+			GVK:            gvkString(run),
+			Count:          run.Count,
+			Concurrency:    run.Concurrency,
+			Created:        run.Created,
+			Ready:          run.Ready,
+			Partial:        run.Partial,
+		},
+	}
+	if doc.Assertions == nil {
+		doc.Assertions = []tofaniov1alpha1.Assertion{}
+	}
+	if run.StartTime != nil {
+		doc.Run.StartTime = &run.StartTime.Time
+	}
+	if run.CompletionTime != nil {
+		doc.Run.CompletionTime = &run.CompletionTime.Time
+	}
+
+	for _, latency := range report.Spec.Latencies {
+		doc.Latencies = append(doc.Latencies, jsonLatency{
+			Name:   latency.Name,
+			Count:  latency.Count,
+			MinMs:  milliseconds(latency.Min.Duration),
+			MaxMs:  milliseconds(latency.Max.Duration),
+			MeanMs: milliseconds(latency.Mean.Duration),
+			P50Ms:  milliseconds(latency.P50.Duration),
+			P90Ms:  milliseconds(latency.P90.Duration),
+			P95Ms:  milliseconds(latency.P95.Duration),
+			P99Ms:  milliseconds(latency.P99.Duration),
+		})
+	}
+
+	for _, series := range report.Spec.Metrics {
+		metric := jsonMetric{Name: series.Name, Expr: series.Expr, Samples: []jsonSample{}}
+		for _, sample := range series.Samples {
+			metric.Samples = append(metric.Samples, jsonSample{Time: sample.Time.Time, Value: sample.Value})
+		}
+		doc.Metrics = append(doc.Metrics, metric)
+	}
+
+	for _, point := range report.Spec.Timeline {
+		doc.Timeline = append(doc.Timeline, jsonTimelinePoint{
+			OffsetMs: milliseconds(point.Offset.Duration),
+			Created:  point.Created,
+			Ready:    point.Ready,
+		})
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(doc)
+}
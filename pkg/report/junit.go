@@ -0,0 +1,186 @@
+package report
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	tofaniov1alpha1 "github.com/invioteq/tofan/api/v1alpha1"
+)
+
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Name     string           `xml:"name,attr"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Time     string           `xml:"time,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name       string          `xml:"name,attr"`
+	Tests      int             `xml:"tests,attr"`
+	Failures   int             `xml:"failures,attr"`
+	Time       string          `xml:"time,attr"`
+	Timestamp  string          `xml:"timestamp,attr,omitempty"`
+	Properties []junitProperty `xml:"properties>property"`
+	TestCases  []junitTestCase `xml:"testcase"`
+}
+
+type junitProperty struct {
+	Name  string `xml:"name,attr"`
+	Value string `xml:"value,attr"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+	SystemOut string        `xml:"system-out,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Text    string `xml:",chardata"`
+}
+
+// writeJUnit renders the report as a JUnit XML document with one test case per assertion and per
+// object assertion. The run, its latencies and the totals of its requests, errors, writes, Events and
+// API server metrics are properties of the suite, their breakdowns are only exported as JSON and CSV.
+func writeJUnit(w io.Writer, report *tofaniov1alpha1.Report) error {
+	run := report.Spec.Run
+	suite := junitTestSuite{
+		Name: report.Spec.TestCaseRef,
+		Time: formatSeconds(runDuration(report)),
+	}
+	if run.StartTime != nil {
+		suite.Timestamp = run.StartTime.UTC().Format("2006-01-02T15:04:05")
+	}
+
+	suite.Properties = append(suite.Properties,
+		junitProperty{Name: "report", Value: report.Name},
+		junitProperty{Name: "phase", Value: run.Phase},
+		junitProperty{Name: "partial", Value: strconv.FormatBool(run.Partial)},
+		junitProperty{Name: "objectTemplate", Value: run.ObjectTemplate},
+		junitProperty{Name: "gvk", Value: gvkString(run)},
+		junitProperty{Name: "count", Value: strconv.Itoa(run.Count)},
+		junitProperty{Name: "concurrency", Value: strconv.Itoa(run.Concurrency)},
+		junitProperty{Name: "created", Value: strconv.Itoa(run.Created)},
+		junitProperty{Name: "ready", Value: strconv.Itoa(run.Ready)},
+	)
+	for _, latency := range report.Spec.Latencies {
+		suite.Properties = append(suite.Properties, junitProperty{
+			Name:  latency.Name + ".count",
+			Value: strconv.Itoa(latency.Count),
+		})
+		for _, field := range latencyFields(latency) {
+			suite.Properties = append(suite.Properties, junitProperty{
+				Name:  latency.Name + "." + field.Name,
+				Value: field.Value.String(),
+			})
+		}
+	}
+
+	if requests := report.Spec.Requests; requests != nil {
+		suite.Properties = append(suite.Properties,
+			junitProperty{Name: "requests.sent", Value: strconv.FormatInt(requests.Sent, 10)},
+			junitProperty{Name: "requests.clientThrottled", Value: strconv.FormatInt(requests.ClientThrottled, 10)},
+			junitProperty{Name: "requests.clientThrottledTime", Value: requests.ClientThrottledTime.Duration.String()},
+			junitProperty{Name: "requests.serverThrottled", Value: strconv.FormatInt(requests.ServerThrottled, 10)},
+		)
+	}
+	for _, e := range report.Spec.Errors {
+		suite.Properties = append(suite.Properties, junitProperty{Name: "errors." + e.Reason, Value: strconv.Itoa(e.Count)})
+	}
+	if writes := report.Spec.Writes; writes != nil {
+		suite.Properties = append(suite.Properties,
+			junitProperty{Name: "writes.objects", Value: strconv.Itoa(writes.Objects)},
+			junitProperty{Name: "writes.total", Value: strconv.FormatInt(writes.Total, 10)},
+			junitProperty{Name: "writes.perObject", Value: writes.PerObject},
+			junitProperty{Name: "writes.max", Value: strconv.FormatInt(writes.Max, 10)},
+		)
+	}
+	if events := report.Spec.Events; events != nil {
+		suite.Properties = append(suite.Properties,
+			junitProperty{Name: "events.total", Value: strconv.FormatInt(events.Total, 10)},
+			junitProperty{Name: "events.warnings", Value: strconv.FormatInt(events.Warnings, 10)},
+			junitProperty{Name: "events.objects", Value: strconv.Itoa(events.Objects)},
+		)
+	}
+	if apiServer := report.Spec.APIServer; apiServer != nil {
+		suite.Properties = append(suite.Properties,
+			junitProperty{Name: "apiServer.interval", Value: apiServer.Interval.Duration.String()},
+			junitProperty{Name: "apiServer.requests", Value: strconv.FormatInt(apiServer.Requests, 10)},
+			junitProperty{Name: "apiServer.rejected", Value: strconv.FormatInt(apiServer.Rejected, 10)},
+		)
+		if stored := apiServer.StoredObjects; stored != nil {
+			suite.Properties = append(suite.Properties,
+				junitProperty{Name: "apiServer.storedObjects.before", Value: strconv.FormatInt(stored.Before, 10)},
+				junitProperty{Name: "apiServer.storedObjects.after", Value: strconv.FormatInt(stored.After, 10)},
+			)
+		}
+	}
+
+	for _, assertion := range report.Spec.Assertions {
+		testCase := junitTestCase{
+			Name:      assertion.Name,
+			ClassName: "tofan." + report.Spec.TestCaseRef,
+		}
+		if assertion.Passed {
+			testCase.SystemOut = assertion.Message
+		} else {
+			testCase.Failure = &junitFailure{
+				Message: assertion.Message,
+				Type:    "AssertionFailed",
+				Text:    assertion.Message,
+			}
+			suite.Failures++
+		}
+		suite.TestCases = append(suite.TestCases, testCase)
+	}
+	for _, result := range report.Spec.ObjectAssertions {
+		testCase := junitTestCase{
+			Name:      result.Name,
+			ClassName: "tofan." + report.Spec.TestCaseRef + ".objects",
+		}
+		message := fmt.Sprintf("%d of %d objects passed", result.Passed, result.Passed+result.Failed)
+		if result.Failed == 0 {
+			testCase.SystemOut = message
+		} else {
+			var text strings.Builder
+			for _, failure := range result.Failures {
+				fmt.Fprintf(&text, "%s: %s\n", objectName(failure.Namespace, failure.Name), failure.Message)
+			}
+			testCase.Failure = &junitFailure{
+				Message: message,
+				Type:    "ObjectAssertionFailed",
+				Text:    text.String(),
+			}
+			suite.Failures++
+		}
+		suite.TestCases = append(suite.TestCases, testCase)
+	}
+	suite.Tests = len(suite.TestCases)
+
+	suites := junitTestSuites{
+		Name:     "tofan",
+		Tests:    suite.Tests,
+		Failures: suite.Failures,
+		Time:     suite.Time,
+		Suites:   []junitTestSuite{suite},
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(suites); err != nil {
+		return fmt.Errorf("failed to encode junit report: %w", err)
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
@@ -0,0 +1,126 @@
+package report
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	tofaniov1alpha1 "github.com/invioteq/tofan/api/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Summarize computes the percentiles of the given latency samples. The samples slice is sorted in place.
+func Summarize(name string, samples []time.Duration) tofaniov1alpha1.LatencySummary {
+	summary := tofaniov1alpha1.LatencySummary{Name: name, Count: len(samples)}
+	if len(samples) == 0 {
+		return summary
+	}
+
+	sort.Slice(samples, func(i, j int) bool { return samples[i] < samples[j] })
+
+	var total time.Duration
+	for _, sample := range samples {
+		total += sample
+	}
+
+	summary.Min = metav1.Duration{Duration: samples[0]}
+	summary.Max = metav1.Duration{Duration: samples[len(samples)-1]}
+	summary.Mean = metav1.Duration{Duration: total / time.Duration(len(samples))}
+	summary.P50 = metav1.Duration{Duration: Percentile(samples, 50)}
+	summary.P90 = metav1.Duration{Duration: Percentile(samples, 90)}
+	summary.P95 = metav1.Duration{Duration: Percentile(samples, 95)}
+	summary.P99 = metav1.Duration{Duration: Percentile(samples, 99)}
+	summary.Buckets = histogram(samples, histogramBuckets)
+
+	return summary
+}
+
+// histogramBuckets is the number of buckets Summarize splits the samples into.
+const histogramBuckets = 20
+
+// histogram splits sorted samples into n equal width buckets between the smallest and largest sample.
+func histogram(sorted []time.Duration, n int) []tofaniov1alpha1.LatencyBucket {
+	lo, hi := sorted[0], sorted[len(sorted)-1]
+	if lo == hi {
+		return []tofaniov1alpha1.LatencyBucket{{UpperBound: metav1.Duration{Duration: hi}, Count: len(sorted)}}
+	}
+
+	width := (hi - lo) / time.Duration(n)
+	if width <= 0 {
+		width = 1
+	}
+	buckets := make([]tofaniov1alpha1.LatencyBucket, n)
+	for i := range buckets {
+		buckets[i].UpperBound = metav1.Duration{Duration: lo + width*time.Duration(i+1)}
+	}
+	buckets[n-1].UpperBound = metav1.Duration{Duration: hi}
+
+	i := 0
+	for _, sample := range sorted {
+		for i < n-1 && sample > buckets[i].UpperBound.Duration {
+			i++
+		}
+		buckets[i].Count++
+	}
+	return buckets
+}
+
+// Timeline samples the number of created and ready objects at up to points evenly spaced instants
+// between start and the last creation or readiness event.
+func Timeline(start time.Time, created, ready []time.Time, points int) []tofaniov1alpha1.TimelinePoint {
+	if len(created) == 0 || points < 2 {
+		return nil
+	}
+	sortTimes(created)
+	sortTimes(ready)
+
+	end := created[len(created)-1]
+	if len(ready) > 0 && ready[len(ready)-1].After(end) {
+		end = ready[len(ready)-1]
+	}
+	if start.IsZero() || start.After(created[0]) {
+		start = created[0]
+	}
+	span := end.Sub(start)
+
+	timeline := make([]tofaniov1alpha1.TimelinePoint, 0, points)
+	c, r := 0, 0
+	for i := 0; i < points; i++ {
+		offset := span * time.Duration(i) / time.Duration(points-1)
+		at := start.Add(offset)
+		for c < len(created) && !created[c].After(at) {
+			c++
+		}
+		for r < len(ready) && !ready[r].After(at) {
+			r++
+		}
+		timeline = append(timeline, tofaniov1alpha1.TimelinePoint{
+			Offset:  metav1.Duration{Duration: offset},
+			Created: c,
+			Ready:   r,
+		})
+		if span == 0 {
+			break
+		}
+	}
+	return timeline
+}
+
+func sortTimes(times []time.Time) {
+	sort.Slice(times, func(i, j int) bool { return times[i].Before(times[j]) })
+}
+
+// Percentile returns the p-th percentile of sorted samples using the nearest-rank method.
+func Percentile(sorted []time.Duration, p float64) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	if rank < 1 {
+		rank = 1
+	}
+	if rank > len(sorted) {
+		rank = len(sorted)
+	}
+	return sorted[rank-1]
+}
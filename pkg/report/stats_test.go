@@ -0,0 +1,148 @@
+package report
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	tofaniov1alpha1 "github.com/invioteq/tofan/api/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestPercentile(t *testing.T) {
+	sorted := []time.Duration{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}
+	tests := []struct {
+		name   string
+		sorted []time.Duration
+		p      float64
+		want   time.Duration
+	}{
+		{"empty", nil, 50, 0},
+		{"single", []time.Duration{7}, 99, 7},
+		{"p0 is the smallest", sorted, 0, 1},
+		{"p50", sorted, 50, 5},
+		{"p90", sorted, 90, 9},
+		{"p95 rounds the rank up", sorted, 95, 10},
+		{"p100", sorted, 100, 10},
+		{"above 100 is clamped", sorted, 150, 10},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Percentile(tt.sorted, tt.p); got != tt.want {
+				t.Errorf("Percentile(%v) = %v, want %v", tt.p, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSummarize(t *testing.T) {
+	tests := []struct {
+		name                     string
+		samples                  []time.Duration
+		count                    int
+		min, max, mean, p50, p99 time.Duration
+	}{
+		{name: "empty"},
+		{
+			name:    "single",
+			samples: []time.Duration{time.Second},
+			count:   1,
+			min:     time.Second, max: time.Second, mean: time.Second, p50: time.Second, p99: time.Second,
+		},
+		{
+			name:    "unsorted",
+			samples: []time.Duration{4 * time.Second, time.Second, 3 * time.Second, 2 * time.Second},
+			count:   4,
+			min:     time.Second, max: 4 * time.Second, mean: 2500 * time.Millisecond, p50: 2 * time.Second, p99: 4 * time.Second,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Summarize("timeToReady", tt.samples)
+			if got.Name != "timeToReady" || got.Count != tt.count {
+				t.Fatalf("Summarize() = %s with %d samples, want timeToReady with %d", got.Name, got.Count, tt.count)
+			}
+			if got.Min.Duration != tt.min || got.Max.Duration != tt.max || got.Mean.Duration != tt.mean ||
+				got.P50.Duration != tt.p50 || got.P99.Duration != tt.p99 {
+				t.Errorf("Summarize() = min %s max %s mean %s p50 %s p99 %s, want %s %s %s %s %s",
+					got.Min.Duration, got.Max.Duration, got.Mean.Duration, got.P50.Duration, got.P99.Duration,
+					tt.min, tt.max, tt.mean, tt.p50, tt.p99)
+			}
+		})
+	}
+}
+
+func TestHistogram(t *testing.T) {
+	t.Run("equal samples share one bucket", func(t *testing.T) {
+		got := histogram([]time.Duration{time.Second, time.Second, time.Second}, 20)
+		if len(got) != 1 || got[0].Count != 3 || got[0].UpperBound.Duration != time.Second {
+			t.Errorf("histogram() = %+v, want one bucket of 3 up to 1s", got)
+		}
+	})
+
+	t.Run("spread samples fill every bucket range", func(t *testing.T) {
+		var sorted []time.Duration
+		for i := 1; i <= 100; i++ {
+			sorted = append(sorted, time.Duration(i)*time.Millisecond)
+		}
+		got := histogram(sorted, 20)
+		if len(got) != 20 {
+			t.Fatalf("histogram() returned %d buckets, want 20", len(got))
+		}
+		total := 0
+		for i, bucket := range got {
+			total += bucket.Count
+			if i > 0 && bucket.UpperBound.Duration <= got[i-1].UpperBound.Duration {
+				t.Errorf("bucket %d ends at %s, not after %s", i, bucket.UpperBound.Duration, got[i-1].UpperBound.Duration)
+			}
+		}
+		if total != len(sorted) {
+			t.Errorf("histogram() counts %d samples, want %d", total, len(sorted))
+		}
+		if last := got[len(got)-1].UpperBound.Duration; last != 100*time.Millisecond {
+			t.Errorf("last bucket ends at %s, want the maximum 100ms", last)
+		}
+	})
+}
+
+func TestTimeline(t *testing.T) {
+	start := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	at := func(seconds int) time.Time { return start.Add(time.Duration(seconds) * time.Second) }
+
+	tests := []struct {
+		name    string
+		created []time.Time
+		ready   []time.Time
+		points  int
+		want    []tofaniov1alpha1.TimelinePoint
+	}{
+		{name: "no creations", points: 5},
+		{name: "too few points", created: []time.Time{at(1)}, points: 1},
+		{
+			name:    "single instant",
+			created: []time.Time{at(0)},
+			ready:   []time.Time{at(0)},
+			points:  5,
+			want:    []tofaniov1alpha1.TimelinePoint{{Created: 1, Ready: 1}},
+		},
+		{
+			name:    "counts grow over the run",
+			created: []time.Time{at(2), at(0), at(1)},
+			ready:   []time.Time{at(4), at(3)},
+			points:  3,
+			want: []tofaniov1alpha1.TimelinePoint{
+				{Offset: metav1.Duration{}, Created: 1, Ready: 0},
+				{Offset: metav1.Duration{Duration: 2 * time.Second}, Created: 3, Ready: 0},
+				{Offset: metav1.Duration{Duration: 4 * time.Second}, Created: 3, Ready: 2},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Timeline(start, tt.created, tt.ready, tt.points)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Timeline() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
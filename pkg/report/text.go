@@ -0,0 +1,239 @@
+package report
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	tofaniov1alpha1 "github.com/invioteq/tofan/api/v1alpha1"
+)
+
+// WriteSummary prints a human readable summary of the report.
+func WriteSummary(w io.Writer, report *tofaniov1alpha1.Report) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	run := report.Spec.Run
+
+	fmt.Fprintf(tw, "Report:\t%s\n", report.Name)
+	fmt.Fprintf(tw, "TestCase:\t%s\n", report.Spec.TestCaseRef)
+	fmt.Fprintf(tw, "Phase:\t%s\n", run.Phase)
+	if run.Partial {
+		fmt.Fprintln(tw, "Partial:\tthe operator restarted during the run, the measurements made before are missing")
+	}
+	if run.StartTime != nil {
+		fmt.Fprintf(tw, "Started:\t%s\n", run.StartTime.UTC().Format(time.RFC3339))
+	}
+	fmt.Fprintf(tw, "Duration:\t%s\n", runDuration(report))
+	fmt.Fprintf(tw, "Objects:\t%s, %d requested, %d created, %d ready\n", gvkString(run), run.Count, run.Created, run.Ready)
+	if requests := report.Spec.Requests; requests != nil {
+		fmt.Fprintf(tw, "Requests:\t%d sent, %d throttled by the client for %s, %d throttled by the server\n",
+			requests.Sent, requests.ClientThrottled, requests.ClientThrottledTime.Duration, requests.ServerThrottled)
+	}
+	if writes := report.Spec.Writes; writes != nil {
+		fmt.Fprintf(tw, "Writes:\t%d to %d objects, %s per object, %d at most\n", writes.Total, writes.Objects, writes.PerObject, writes.Max)
+	}
+	if events := report.Spec.Events; events != nil {
+		fmt.Fprintf(tw, "Events:\t%d for %d objects, %d warnings\n", events.Total, events.Objects, events.Warnings)
+	}
+	if apiServer := report.Spec.APIServer; apiServer != nil {
+		fmt.Fprintf(tw, "API server:\t%d requests in %s, %d rejected by priority and fairness\n", apiServer.Requests, apiServer.Interval.Duration, apiServer.Rejected)
+		if stored := apiServer.StoredObjects; stored != nil {
+			fmt.Fprintf(tw, "Stored objects:\t%s, %d before, %d after\n", stored.Resource, stored.Before, stored.After)
+		}
+	}
+	if diagnostics := report.Spec.Diagnostics; diagnostics != nil {
+		var location []string
+		if diagnostics.ConfigMapName != "" {
+			location = append(location, "configmap/"+diagnostics.ConfigMapName)
+		}
+		if diagnostics.Path != "" {
+			location = append(location, diagnostics.Path)
+		}
+		if len(location) == 0 {
+			location = append(location, "not stored")
+		}
+		fmt.Fprintf(tw, "Diagnostics:\t%d objects captured in %s\n", len(diagnostics.Objects), strings.Join(location, ", "))
+	}
+
+	if len(report.Spec.Assertions) > 0 {
+		fmt.Fprintln(tw, "\nASSERTION\tRESULT\tMESSAGE")
+		for _, assertion := range report.Spec.Assertions {
+			result := "FAIL"
+			if assertion.Passed {
+				result = "PASS"
+			}
+			fmt.Fprintf(tw, "%s\t%s\t%s\n", assertion.Name, result, assertion.Message)
+		}
+	}
+
+	var failures [][3]string
+	for _, result := range report.Spec.ObjectAssertions {
+		for _, failure := range result.Failures {
+			name := objectName(failure.Namespace, failure.Name)
+			failures = append(failures, [3]string{result.Name, name, failure.Message})
+		}
+	}
+	if len(failures) > 0 {
+		fmt.Fprintln(tw, "\nFAILED ASSERTION\tOBJECT\tMESSAGE")
+		for _, failure := range failures {
+			fmt.Fprintf(tw, "%s\t%s\t%s\n", failure[0], failure[1], failure[2])
+		}
+	}
+
+	if len(report.Spec.Errors) > 0 {
+		fmt.Fprintln(tw, "\nERROR\tCOUNT\tLAST MESSAGE")
+		for _, e := range report.Spec.Errors {
+			fmt.Fprintf(tw, "%s\t%d\t%s\n", e.Reason, e.Count, e.Message)
+		}
+	}
+
+	if len(report.Spec.Latencies) > 0 {
+		fmt.Fprintln(tw, "\nLATENCY\tCOUNT\tMIN\tMEAN\tP50\tP90\tP95\tP99\tMAX")
+		for _, latency := range report.Spec.Latencies {
+			fmt.Fprintf(tw, "%s\t%d\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n", latency.Name, latency.Count,
+				latency.Min.Duration, latency.Mean.Duration, latency.P50.Duration, latency.P90.Duration,
+				latency.P95.Duration, latency.P99.Duration, latency.Max.Duration)
+		}
+	}
+
+	if writes := report.Spec.Writes; writes != nil && len(writes.Managers) > 0 {
+		fmt.Fprintln(tw, "\nFIELD MANAGER\tOPERATION\tSUBRESOURCE\tWRITES")
+		for _, manager := range writes.Managers {
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%d\n", manager.Manager, manager.Operation, manager.Subresource, manager.Writes)
+		}
+	}
+	if writes := report.Spec.Writes; writes != nil && len(writes.HotObjects) > 0 {
+		fmt.Fprintln(tw, "\nHOT OBJECT\tWRITES\tTOP MANAGER")
+		for _, object := range writes.HotObjects {
+			name := objectName(object.Namespace, object.Name)
+			top := "-"
+			if len(object.Managers) > 0 {
+				top = fmt.Sprintf("%s (%d)", object.Managers[0].Manager, object.Managers[0].Writes)
+			}
+			fmt.Fprintf(tw, "%s\t%d\t%s\n", name, object.Writes, top)
+		}
+	}
+
+	if events := report.Spec.Events; events != nil && len(events.Reasons) > 0 {
+		fmt.Fprintln(tw, "\nEVENT\tREASON\tCONTROLLER\tCOUNT\tOBJECTS\tLAST MESSAGE")
+		for _, reason := range events.Reasons {
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%d\t%d\t%s\n", reason.Type, reason.Reason, reason.Controller, reason.Count, reason.Objects, reason.Message)
+		}
+	}
+	if apiServer := report.Spec.APIServer; apiServer != nil && len(apiServer.RequestCounts) > 0 {
+		// apiserver_request_total has no client label, the tofan requests cannot be told apart
+		fmt.Fprintln(tw, "\nRequests of every client, COMPONENT is the part of the API server that served them")
+		fmt.Fprintln(tw, "API REQUEST\tRESOURCE\tSUBRESOURCE\tCODE\tCOMPONENT\tCOUNT")
+		for _, requests := range apiServer.RequestCounts {
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%d\n", requests.Verb, requests.Resource, requests.Subresource, requests.Code, requests.Component, requests.Count)
+		}
+	}
+	if apiServer := report.Spec.APIServer; apiServer != nil && len(apiServer.Latencies) > 0 {
+		fmt.Fprintln(tw, "\nAPI LATENCY\tRESOURCE\tCOUNT\tMEAN\tP50\tP90\tP99")
+		for _, latency := range apiServer.Latencies {
+			fmt.Fprintf(tw, "%s\t%s\t%d\t%s\t%s\t%s\t%s\n", latency.Verb, latency.Resource, latency.Count,
+				latency.Mean.Duration, latency.P50.Duration, latency.P90.Duration, latency.P99.Duration)
+		}
+	}
+	if apiServer := report.Spec.APIServer; apiServer != nil && len(apiServer.StorageLatencies) > 0 {
+		fmt.Fprintln(tw, "\nETCD LATENCY\tTYPE\tCOUNT\tMEAN\tP50\tP90\tP99")
+		for _, latency := range apiServer.StorageLatencies {
+			fmt.Fprintf(tw, "%s\t%s\t%d\t%s\t%s\t%s\t%s\n", latency.Verb, latency.Resource, latency.Count,
+				latency.Mean.Duration, latency.P50.Duration, latency.P90.Duration, latency.P99.Duration)
+		}
+	}
+	if apiServer := report.Spec.APIServer; apiServer != nil && len(apiServer.Rejections) > 0 {
+		fmt.Fprintln(tw, "\nAPF REJECTION\tFLOW SCHEMA\tREASON\tCOUNT")
+		for _, rejection := range apiServer.Rejections {
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%d\n", rejection.PriorityLevel, rejection.FlowSchema, rejection.Reason, rejection.Count)
+		}
+	}
+	if diagnostics := report.Spec.Diagnostics; diagnostics != nil && len(diagnostics.Objects) > 0 {
+		fmt.Fprintln(tw, "\nCAPTURED OBJECT\tREADY\tEVENTS\tCHILDREN\tKEY")
+		for _, object := range diagnostics.Objects {
+			name := objectName(object.Namespace, object.Name)
+			fmt.Fprintf(tw, "%s\t%t\t%d\t%d\t%s\n", name, object.Ready, object.Events, object.Children, object.Key)
+		}
+	}
+
+	if len(report.Spec.Metrics) > 0 {
+		fmt.Fprintln(tw, "\nMETRIC\tSAMPLES\tLAST")
+		for _, metric := range report.Spec.Metrics {
+			last := "-"
+			if len(metric.Samples) > 0 {
+				last = metric.Samples[len(metric.Samples)-1].Value
+			}
+			fmt.Fprintf(tw, "%s\t%d\t%s\n", metric.Name, len(metric.Samples), last)
+		}
+	}
+
+	return tw.Flush()
+}
+
+// WriteComparison prints the differences between a baseline and a candidate report.
+func WriteComparison(w io.Writer, base, candidate *tofaniov1alpha1.Report) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+
+	fmt.Fprintf(tw, "\t%s\t%s\tDELTA\n", base.Name, candidate.Name)
+	fmt.Fprintf(tw, "testcase\t%s\t%s\t\n", base.Spec.TestCaseRef, candidate.Spec.TestCaseRef)
+	fmt.Fprintf(tw, "phase\t%s\t%s\t\n", base.Spec.Run.Phase, candidate.Spec.Run.Phase)
+	fmt.Fprintf(tw, "duration\t%s\t%s\t%s\n", runDuration(base), runDuration(candidate), durationDelta(runDuration(base), runDuration(candidate)))
+	fmt.Fprintf(tw, "created\t%d\t%d\t%+d\n", base.Spec.Run.Created, candidate.Spec.Run.Created, candidate.Spec.Run.Created-base.Spec.Run.Created)
+	fmt.Fprintf(tw, "ready\t%d\t%d\t%+d\n", base.Spec.Run.Ready, candidate.Spec.Run.Ready, candidate.Spec.Run.Ready-base.Spec.Run.Ready)
+
+	for _, latency := range base.Spec.Latencies {
+		other, ok := findLatency(candidate, latency.Name)
+		if !ok {
+			continue
+		}
+		baseFields, otherFields := latencyFields(latency), latencyFields(other)
+		for i, field := range baseFields {
+			fmt.Fprintf(tw, "%s %s\t%s\t%s\t%s\n", latency.Name, field.Name, field.Value, otherFields[i].Value, durationDelta(field.Value, otherFields[i].Value))
+		}
+	}
+
+	var changed []string
+	for _, assertion := range base.Spec.Assertions {
+		for _, other := range candidate.Spec.Assertions {
+			if other.Name == assertion.Name && other.Passed != assertion.Passed {
+				changed = append(changed, fmt.Sprintf("%s: %s -> %s", assertion.Name, passFail(assertion.Passed), passFail(other.Passed)))
+			}
+		}
+	}
+	if len(changed) > 0 {
+		fmt.Fprintf(tw, "changed assertions\t%s\t\t\n", strings.Join(changed, ", "))
+	}
+
+	return tw.Flush()
+}
+
+func findLatency(report *tofaniov1alpha1.Report, name string) (tofaniov1alpha1.LatencySummary, bool) {
+	for _, latency := range report.Spec.Latencies {
+		if latency.Name == name {
+			return latency, true
+		}
+	}
+	return tofaniov1alpha1.LatencySummary{}, false
+}
+
+// durationDelta formats the change from base to candidate as an absolute and relative difference.
+func durationDelta(base, candidate time.Duration) string {
+	diff := candidate - base
+	sign := "+"
+	if diff < 0 {
+		sign = "-"
+		diff = -diff
+	}
+	if base == 0 {
+		return sign + diff.String()
+	}
+	return fmt.Sprintf("%s%s (%+.1f%%)", sign, diff, float64(candidate-base)/float64(base)*100)
+}
+
+func passFail(passed bool) string {
+	if passed {
+		return "PASS"
+	}
+	return "FAIL"
+}